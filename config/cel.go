@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
 	"github.com/google/cel-go/ext"
 	"github.com/santhosh-tekuri/jsonschema/v6"
 	"golang.org/x/text/message"
@@ -36,8 +37,21 @@ type celVocabulary struct {
 // Available CEL extensions: semver() constructor with comparison operators and
 // accessors, plus cel-go's strings and lists extensions.
 func NewCELVocabulary() (*jsonschema.Vocabulary, error) {
-	env, err := cel.NewEnv(
-		cel.Variable("self", cel.DynType),
+	env, err := NewCELEnv(cel.Variable("self", cel.DynType))
+	if err != nil {
+		return nil, err
+	}
+	cv := &celVocabulary{env: env}
+	return &jsonschema.Vocabulary{
+		URL:     "https://github.com/Azure/ARO-Tools/cel-validation",
+		Compile: cv.compile,
+	}, nil
+}
+
+// NewCELEnv creates a CEL environment with the extensions available to every CEL expression in ARO-Tools: the
+// semver() library, plus cel-go's strings and lists extensions. Callers declare their variables with opts.
+func NewCELEnv(opts ...cel.EnvOption) (*cel.Env, error) {
+	env, err := cel.NewEnv(append([]cel.EnvOption{
 		cel.HomogeneousAggregateLiterals(),
 		cel.EagerlyValidateDeclarations(true),
 		cel.DefaultUTCTimeZone(true),
@@ -46,21 +60,20 @@ func NewCELVocabulary() (*jsonschema.Vocabulary, error) {
 		ext.Lists(),
 
 		semvers(),
-	)
+	}, opts...)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
 	}
-	cv := &celVocabulary{env: env}
-	return &jsonschema.Vocabulary{
-		URL:     "https://github.com/Azure/ARO-Tools/cel-validation",
-		Compile: cv.compile,
-	}, nil
+	return env, nil
 }
 
 type celRule struct {
 	program    cel.Program
 	expression string
 	message    string
+	// field is the only property of self that the rule reads, if there is exactly one. Failures are attributed to
+	// it, so that they can be traced back to the line that set the value.
+	field string
 }
 
 // celExtension implements jsonschema.SchemaExt for CEL validation.
@@ -73,6 +86,7 @@ const celValidationsKey = "x-cel-validations"
 // celErrorKind implements jsonschema.ErrorKind for CEL validation failures.
 type celErrorKind struct {
 	message string
+	field   string
 }
 
 func (e *celErrorKind) KeywordPath() []string {
@@ -134,6 +148,7 @@ func (cv *celVocabulary) compile(_ *jsonschema.CompilerContext, obj map[string]a
 			program:    program,
 			expression: ruleExpr,
 			message:    ruleMessage,
+			field:      selfField(checked.NativeRep().Expr()),
 		})
 	}
 
@@ -162,7 +177,35 @@ func (c *celExtension) Validate(ctx *jsonschema.ValidatorContext, v any) {
 		if !b {
 			ctx.AddError(&celErrorKind{
 				message: rule.message,
+				field:   rule.field,
 			})
 		}
 	}
 }
+
+// selfField returns the name of the property of self that an expression reads, if it reads exactly one and never
+// uses self in any other way.
+func selfField(expr ast.Expr) string {
+	fields := map[string]bool{}
+	var references, selections int
+	ast.PostOrderVisit(expr, ast.NewExprVisitor(func(e ast.Expr) {
+		switch e.Kind() {
+		case ast.IdentKind:
+			if e.AsIdent() == "self" {
+				references++
+			}
+		case ast.SelectKind:
+			if operand := e.AsSelect().Operand(); operand.Kind() == ast.IdentKind && operand.AsIdent() == "self" {
+				selections++
+				fields[e.AsSelect().FieldName()] = true
+			}
+		}
+	}))
+	if len(fields) != 1 || references != selections {
+		return ""
+	}
+	for field := range fields {
+		return field
+	}
+	return ""
+}
@@ -16,15 +16,22 @@ package config
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/sync/errgroup"
+
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"sigs.k8s.io/yaml"
 
@@ -68,6 +75,9 @@ type ConfigProvider interface {
 	// The cloud and environment provided in the replacements must be literal values, used to
 	// constrain the resolver further and ensure that configurations it resolves are correct.
 	GetResolver(configReplacements *ConfigReplacements) (ConfigResolver, error)
+	// ResolveAll resolves the configuration for every region of every cloud and environment, concurrently, using
+	// DefaultReplacements for each. The result is keyed by cloud, environment and region.
+	ResolveAll(ctx context.Context, opts ...ResolveOption) (map[string]map[string]map[string]types.Configuration, error)
 }
 
 // ConfigResolver resolves service configuration for a specific environment and cloud using a processed configuration file.
@@ -86,6 +96,8 @@ type ConfigResolver interface {
 	GetRegionOverrides(region string) (types.Configuration, error)
 	// ValueProvenance divulges how the value at 'path' is overridden to arrive at the result.
 	ValueProvenance(region, path string) (*Provenance, error)
+	// ProvenanceReport divulges how every value in the configuration for the region is overridden to arrive at the result.
+	ProvenanceReport(region string) (*ProvenanceReport, error)
 }
 
 // NewConfigProvider creates a configuration provider by knowing the path to the configuration file.
@@ -106,14 +118,22 @@ func NewConfigProvider(config string) (ConfigProvider, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path for config file %q: %w", config, err)
 	}
-	return NewConfigProviderFromData(raw, absPath)
+	return newConfigProvider(raw, absPath, config)
 }
 
 // NewConfigProviderFromData creates a configuration provider from raw configuration data and the reference directory
 // for resolving relative schema paths. The schemaBaseDir is used to turn a relative schema path into an absolute one.
 func NewConfigProviderFromData(raw []byte, schemaBaseDir string) (ConfigProvider, error) {
-	cp := configProvider{
-		raw: raw,
+	return newConfigProvider(raw, schemaBaseDir, "")
+}
+
+// newConfigProvider creates a configuration provider, recording the file the raw data was read from so that
+// validation errors can point at it. The file may be empty when the data did not come from disk.
+func newConfigProvider(raw []byte, schemaBaseDir, file string) (ConfigProvider, error) {
+	cp := &configProvider{
+		file:     file,
+		raw:      raw,
+		resolved: map[string]*renderedConfiguration{},
 	}
 
 	ev2Cfg, err := ev2config.ResolveConfig("public", "uksouth")
@@ -145,22 +165,44 @@ func NewConfigProviderFromData(raw []byte, schemaBaseDir string) (ConfigProvider
 		}
 	}
 	cp.absoluteSchemaPath = schemaPath
+	cp.schema = sync.OnceValues(func() (*jsonschema.Schema, error) {
+		return compileConfigSchema(schemaPath)
+	})
 
 	forValidation := map[string]any{}
 	if err := yaml.Unmarshal(rawContent, &forValidation); err != nil {
 		return nil, err
 	}
-	if err := ValidateConfigMetaSchema(forValidation); err != nil {
+	// positions are a diagnostic aid, so a document we can't map back to the source is validated without them
+	positions, _ := NewPositions(cp.file, cp.raw, rawContent)
+	if err := validateConfigMetaSchema(forValidation, positions); err != nil {
+		return nil, err
+	}
+	if err := validateMergeDirectives(cp.withFakeReplacements); err != nil {
 		return nil, err
 	}
 
-	return &cp, nil
+	return cp, nil
 }
 
 type configProvider struct {
 	absoluteSchemaPath   string
+	file                 string
 	raw                  []byte
 	withFakeReplacements configurationOverrides
+
+	// schema compiles the schema at absoluteSchemaPath once, for every resolver to validate with.
+	schema func() (*jsonschema.Schema, error)
+
+	// resolved caches the configuration rendered for each set of replacements, keyed by resolverCacheKey.
+	resolvedLock sync.Mutex
+	resolved     map[string]*renderedConfiguration
+}
+
+// renderedConfiguration is the configuration file rendered for one set of replacements.
+type renderedConfiguration struct {
+	cfg       configurationOverrides
+	positions Positions
 }
 
 // AllContexts returns all clouds, environments and regions in the configuration.
@@ -178,6 +220,9 @@ func (cp *configProvider) AllContexts() map[string]map[string][]string {
 	return contexts
 }
 
+// GetResolver renders the configuration file for the replacements. Rendered configuration is cached for each set of
+// replacements, so that resolvers for the same replacements are cheap to create; every resolver holds its own copy
+// of the rendered configuration, so configuration it returns may be changed freely.
 func (cp *configProvider) GetResolver(configReplacements *ConfigReplacements) (ConfigResolver, error) {
 	for description, value := range map[string]*string{
 		"cloud":       &configReplacements.CloudReplacement,
@@ -188,6 +233,33 @@ func (cp *configProvider) GetResolver(configReplacements *ConfigReplacements) (C
 		}
 	}
 
+	rendered, err := cp.render(configReplacements)
+	if err != nil {
+		return nil, err
+	}
+	return &configResolver{
+		cloud:              configReplacements.CloudReplacement,
+		environment:        configReplacements.EnvironmentReplacement,
+		cfg:                rendered.cfg.deepCopy(),
+		absoluteSchemaPath: cp.absoluteSchemaPath,
+		schema:             cp.schema,
+		positions:          rendered.positions,
+	}, nil
+}
+
+// render renders the configuration file for the replacements, or fetches it from the cache if it has already been.
+func (cp *configProvider) render(configReplacements *ConfigReplacements) (*renderedConfiguration, error) {
+	key, err := resolverCacheKey(configReplacements)
+	if err != nil {
+		return nil, err
+	}
+	cp.resolvedLock.Lock()
+	rendered, cached := cp.resolved[key]
+	cp.resolvedLock.Unlock()
+	if cached {
+		return rendered, nil
+	}
+
 	// TODO validate that field names are unique regardless of casing
 	// parse, execute and unmarshal the config file as a template to generate the final config file
 	rawContent, err := PreprocessContent(cp.raw, configReplacements.AsMap())
@@ -199,24 +271,148 @@ func (cp *configProvider) GetResolver(configReplacements *ConfigReplacements) (C
 	if err := yaml.Unmarshal(rawContent, &currentVariableOverrides); err != nil {
 		return nil, err
 	}
-	return &configResolver{
-		cloud:              configReplacements.CloudReplacement,
-		environment:        configReplacements.EnvironmentReplacement,
-		cfg:                currentVariableOverrides,
-		absoluteSchemaPath: cp.absoluteSchemaPath,
-	}, nil
+	positions, _ := NewPositions(cp.file, cp.raw, rawContent)
+	rendered = &renderedConfiguration{cfg: currentVariableOverrides, positions: positions}
+
+	cp.resolvedLock.Lock()
+	defer cp.resolvedLock.Unlock()
+	if existing, cached := cp.resolved[key]; cached {
+		// another caller rendered the same replacements concurrently
+		return existing, nil
+	}
+	cp.resolved[key] = rendered
+	return rendered, nil
+}
+
+// resolverCacheKey identifies a set of replacements, as everything in them may be used when rendering the file.
+func resolverCacheKey(configReplacements *ConfigReplacements) (string, error) {
+	key, err := json.Marshal(configReplacements.AsMap())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode configuration replacements: %w", err)
+	}
+	return string(key), nil
+}
+
+// DefaultReplacements determines the replacements for the first stamp in a region. The region short name and Ev2
+// configuration are filled in when Ev2 has a record of the cloud and region.
+func DefaultReplacements(cloud, environment, region string) *ConfigReplacements {
+	replacements := &ConfigReplacements{
+		CloudReplacement:       cloud,
+		EnvironmentReplacement: environment,
+		RegionReplacement:      region,
+		StampReplacement:       "1",
+	}
+	ev2, err := ev2config.ResolveConfig(cloud, region)
+	if err != nil {
+		return replacements
+	}
+	replacements.Ev2Config = ev2
+	if short, err := ev2.GetByPath("regionShortName"); err == nil {
+		if regionShort, ok := short.(string); ok {
+			replacements.RegionShortReplacement = regionShort
+		}
+	}
+	return replacements
+}
+
+// ResolveOption customizes how ResolveAll resolves regions.
+type ResolveOption func(*resolveOptions)
+
+type resolveOptions struct {
+	workers int
+}
+
+// WithWorkers bounds the number of regions that ResolveAll resolves at once. By default, there are as many workers
+// as there are CPUs.
+func WithWorkers(workers int) ResolveOption {
+	return func(o *resolveOptions) {
+		o.workers = workers
+	}
+}
+
+// ResolveAll resolves every region concurrently, with a bounded number of workers. Resolution stops at the first
+// failure, or when the context is cancelled.
+func (cp *configProvider) ResolveAll(ctx context.Context, opts ...ResolveOption) (map[string]map[string]map[string]types.Configuration, error) {
+	options := &resolveOptions{workers: runtime.NumCPU()}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.workers < 1 {
+		return nil, fmt.Errorf("at least one worker is required, got %d", options.workers)
+	}
+
+	// the maps are all created up front, so that workers only ever write to their own region's entry
+	type regionContext struct {
+		cloud, environment, region string
+	}
+	var contexts []regionContext
+	resolved := map[string]map[string]map[string]types.Configuration{}
+	for cloud, environments := range cp.AllContexts() {
+		resolved[cloud] = map[string]map[string]types.Configuration{}
+		for environment, regions := range environments {
+			resolved[cloud][environment] = map[string]types.Configuration{}
+			for _, region := range regions {
+				contexts = append(contexts, regionContext{cloud: cloud, environment: environment, region: region})
+			}
+		}
+	}
+
+	var lock sync.Mutex
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(options.workers)
+	for _, c := range contexts {
+		group.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			resolver, err := cp.GetResolver(DefaultReplacements(c.cloud, c.environment, c.region))
+			if err != nil {
+				return fmt.Errorf("%s/%s/%s: failed to get configuration resolver: %w", c.cloud, c.environment, c.region, err)
+			}
+			cfg, err := resolver.GetRegionConfiguration(c.region)
+			if err != nil {
+				return fmt.Errorf("%s/%s/%s: failed to resolve region configuration: %w", c.cloud, c.environment, c.region, err)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			resolved[c.cloud][c.environment][c.region] = cfg
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return resolved, nil
 }
 
 type configResolver struct {
 	cloud, environment string
 	cfg                configurationOverrides
 	absoluteSchemaPath string
+	schema             func() (*jsonschema.Schema, error)
+	positions          Positions
 }
 
+// ValidateSchema validates the configuration with the schema, which is compiled once for the provider that created
+// this resolver and shared by every resolver it creates.
 func (cr *configResolver) ValidateSchema(config types.Configuration) error {
+	sch, err := cr.schema()
+	if err != nil {
+		return err
+	}
+
+	err = sch.Validate(map[string]any(config))
+	if err != nil {
+		return fmt.Errorf("failed to validate schema: %v", annotateSchemaError(err, cr.sourcePosition))
+	}
+	return nil
+}
+
+// compileConfigSchema compiles the JSONSchema for configuration, with the CEL vocabulary registered.
+func compileConfigSchema(path string) (*jsonschema.Schema, error) {
 	celVocab, err := NewCELVocabulary()
 	if err != nil {
-		return fmt.Errorf("failed to create CEL vocabulary: %w", err)
+		return nil, fmt.Errorf("failed to create CEL vocabulary: %w", err)
 	}
 
 	loader := jsonschema.SchemeURLLoader{
@@ -226,16 +422,46 @@ func (cr *configResolver) ValidateSchema(config types.Configuration) error {
 	c.UseLoader(loader)
 	c.RegisterVocabulary(celVocab)
 	c.AssertVocabs()
-	sch, err := c.Compile(cr.absoluteSchemaPath)
+	sch, err := c.Compile(path)
 	if err != nil {
-		return fmt.Errorf("failed to compile schema: %v", err)
+		return nil, fmt.Errorf("failed to compile schema: %v", err)
 	}
+	return sch, nil
+}
 
-	err = sch.Validate(map[string]any(config))
-	if err != nil {
-		return fmt.Errorf("failed to validate schema: %v", err)
+// sourcePosition finds where a value in the resolved configuration for this cloud and environment was set, looking
+// through the layers of defaults from the most specific to the least. A value that any region overrides is not
+// attributed to a layer, since we can't know which region the configuration being validated was resolved for.
+func (cr *configResolver) sourcePosition(location string) (Position, bool) {
+	environment := JoinLocation(JoinLocation("clouds", cr.cloud), JoinLocation("environments", cr.environment))
+	if location != "" {
+		for region := range cr.cfg.Overrides[cr.cloud].Overrides[cr.environment].Overrides {
+			if _, overridden := cr.positions[JoinLocation(JoinLocation(environment, "regions"), JoinLocation(region, location))]; overridden {
+				return Position{}, false
+			}
+		}
+	}
+
+	layers := []string{
+		JoinLocation(environment, "defaults"),
+		JoinLocation(JoinLocation("clouds", cr.cloud), "defaults"),
+		"defaults",
+	}
+	for {
+		for _, layer := range layers {
+			key := layer
+			if location != "" {
+				key = JoinLocation(layer, location)
+			}
+			if position, ok := cr.positions[key]; ok {
+				return position, true
+			}
+		}
+		if location == "" {
+			return Position{}, false
+		}
+		location = parentLocation(location)
 	}
-	return nil
 }
 
 func (cr *configResolver) SchemaPath() (string, error) {
@@ -261,7 +487,7 @@ func (cr *configResolver) GetRegions() ([]string, error) {
 
 // GetRegionConfiguration merges values to resolve the configuration for a region.
 func (cr *configResolver) GetRegionConfiguration(region string) (types.Configuration, error) {
-	cfg := cr.cfg.Defaults
+	var cfg types.Configuration = types.MergeConfiguration(nil, cr.cfg.Defaults)
 	cloudCfg, hasCloud := cr.cfg.Overrides[cr.cloud]
 	if !hasCloud {
 		return nil, fmt.Errorf("the cloud %s is not found in the config", cr.cloud)
@@ -283,7 +509,7 @@ func (cr *configResolver) GetRegionConfiguration(region string) (types.Configura
 
 // GetConfiguration merges values to resolve the configuration for this cloud and environment.
 func (cr *configResolver) GetConfiguration() (types.Configuration, error) {
-	cfg := cr.cfg.Defaults
+	var cfg types.Configuration = types.MergeConfiguration(nil, cr.cfg.Defaults)
 	cloudCfg, hasCloud := cr.cfg.Overrides[cr.cloud]
 	if !hasCloud {
 		return nil, fmt.Errorf("the cloud %s is not found in the config", cr.cloud)
@@ -316,18 +542,26 @@ func (cr *configResolver) GetRegionOverrides(region string) (types.Configuration
 	return regionCfg, nil
 }
 
+// Provenance records the value that each layer of the configuration sets at a path, and the result of merging them.
+// When a layer uses a merge directive at the path, or on an ancestor of it, the directive closest to the path is
+// recorded: a layer that deletes or replaces an ancestor of the path determines the value even without setting it,
+// and the value recorded for a list directive is its items.
 type Provenance struct {
-	Default    any
-	DefaultSet bool
+	Default          any
+	DefaultSet       bool
+	DefaultDirective types.PatchDirective
 
-	Cloud    any
-	CloudSet bool
+	Cloud          any
+	CloudSet       bool
+	CloudDirective types.PatchDirective
 
-	Environment    any
-	EnvironmentSet bool
+	Environment          any
+	EnvironmentSet       bool
+	EnvironmentDirective types.PatchDirective
 
-	Region    any
-	RegionSet bool
+	Region          any
+	RegionSet       bool
+	RegionDirective types.PatchDirective
 
 	Result    any
 	ResultSet bool
@@ -356,29 +590,69 @@ func (cr *configResolver) ValueProvenance(region, path string) (*Provenance, err
 	}
 
 	p := &Provenance{}
+	keys := strings.Split(path, ".")
 	for name, part := range map[string]struct {
-		from  *types.Configuration
-		value *any
-		set   *bool
+		from      *types.Configuration
+		value     *any
+		set       *bool
+		directive *types.PatchDirective
 	}{
-		"default":     {from: &cr.cfg.Defaults, value: &p.Default, set: &p.DefaultSet},
-		"cloud":       {from: &cloudCfg.Defaults, value: &p.Cloud, set: &p.CloudSet},
-		"environment": {from: &envCfg.Defaults, value: &p.Environment, set: &p.EnvironmentSet},
-		"region":      {from: &regionCfg, value: &p.Region, set: &p.RegionSet},
+		"default":     {from: &cr.cfg.Defaults, value: &p.Default, set: &p.DefaultSet, directive: &p.DefaultDirective},
+		"cloud":       {from: &cloudCfg.Defaults, value: &p.Cloud, set: &p.CloudSet, directive: &p.CloudDirective},
+		"environment": {from: &envCfg.Defaults, value: &p.Environment, set: &p.EnvironmentSet, directive: &p.EnvironmentDirective},
+		"region":      {from: &regionCfg, value: &p.Region, set: &p.RegionSet, directive: &p.RegionDirective},
 		"result":      {from: &mergedCfg, value: &p.Result, set: &p.ResultSet},
 	} {
-		val, err := part.from.GetByPath(path)
+		_, err := part.from.GetByPath(path)
 		var missingKeyErr *types.MissingKeyError
-		isMissing := errors.As(err, &missingKeyErr)
-		if err != nil && !isMissing {
+		if err != nil && !errors.As(err, &missingKeyErr) {
 			return nil, fmt.Errorf("failed to get value from %s config: %w", name, err)
 		}
+		val, _, directive, found := lookupKeys(*part.from, keys)
 		*part.value = val
-		*part.set = !isMissing
+		*part.set = found
+		if part.directive != nil {
+			*part.directive = directive
+		}
 	}
 	return p, nil
 }
 
+// validateMergeDirectives checks the merge directives in every layer of the configuration, so that mistakes are
+// found when the configuration is loaded.
+func validateMergeDirectives(cfg configurationOverrides) error {
+	var errs []error
+	validate := func(location string, layer types.Configuration) {
+		if err := types.ValidateMergeDirectives(layer); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", location, err))
+		}
+	}
+	validate("defaults", cfg.Defaults)
+	for _, cloud := range sets.List(sets.KeySet(cfg.Overrides)) {
+		cloudCfg := cfg.Overrides[cloud]
+		if cloudCfg == nil {
+			continue
+		}
+		cloudLocation := JoinLocation("clouds", cloud)
+		validate(JoinLocation(cloudLocation, "defaults"), cloudCfg.Defaults)
+		for _, environment := range sets.List(sets.KeySet(cloudCfg.Overrides)) {
+			envCfg := cloudCfg.Overrides[environment]
+			if envCfg == nil {
+				continue
+			}
+			envLocation := JoinLocation(cloudLocation, JoinLocation("environments", environment))
+			validate(JoinLocation(envLocation, "defaults"), envCfg.Defaults)
+			for _, region := range sets.List(sets.KeySet(envCfg.Overrides)) {
+				validate(JoinLocation(JoinLocation(envLocation, "regions"), region), envCfg.Overrides[region])
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("config has invalid merge directives: %w", err)
+	}
+	return nil
+}
+
 // PreprocessFile reads and processes a gotemplate
 // The path will be read as is. It parses the file as a template, and executes it with the pro
 func PreprocessFile(templateFilePath string, vars map[string]any) ([]byte, error) {
@@ -420,6 +694,12 @@ var configSchemaV1Content []byte
 const schemaRef = "config.meta.schema.v1.json"
 
 func ValidateConfigMetaSchema(config map[string]any) error {
+	return validateConfigMetaSchema(config, nil)
+}
+
+// validateConfigMetaSchema validates config against the meta schema, annotating violations with their positions in
+// the source document when they are known.
+func validateConfigMetaSchema(config map[string]any, positions Positions) error {
 	pipelineSchema, err := compileSchema()
 	if err != nil {
 		return fmt.Errorf("failed to load pipeline schema: %v", err)
@@ -427,12 +707,16 @@ func ValidateConfigMetaSchema(config map[string]any) error {
 
 	err = pipelineSchema.Validate(config)
 	if err != nil {
+		if positions != nil {
+			err = annotateSchemaError(err, positions.Lookup)
+		}
 		return fmt.Errorf("config is not compliant with meta schema %s: %v", schemaRef, err)
 	}
 	return nil
 }
 
-func compileSchema() (*jsonschema.Schema, error) {
+// compileSchema compiles the embedded meta schema once, as it never changes.
+var compileSchema = sync.OnceValues(func() (*jsonschema.Schema, error) {
 	// parse schema content
 	schemaMap := make(map[string]interface{})
 	err := json.Unmarshal(configSchemaV1Content, &schemaMap)
@@ -452,4 +736,4 @@ func compileSchema() (*jsonschema.Schema, error) {
 	}
 
 	return pipelineSchema, nil
-}
+})
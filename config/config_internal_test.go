@@ -82,5 +82,7 @@ func TestValidateSchemaWithCEL(t *testing.T) {
 		require.Error(t, validationErr)
 		require.Contains(t, validationErr.Error(), "key2 must be positive")
 		require.Contains(t, validationErr.Error(), "version must be valid semver")
+		require.Contains(t, validationErr.Error(), "testdata/config-cel-invalid.yaml:8:11: key2: key2 must be positive")
+		require.Contains(t, validationErr.Error(), "testdata/config-cel-invalid.yaml:9:11: version: version must be valid semver")
 	})
 }
@@ -15,6 +15,7 @@
 package config_test
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -56,6 +57,115 @@ func TestConfigProvider(t *testing.T) {
 	testutil.CompareWithFixture(t, cfg)
 }
 
+func TestGetResolverCache(t *testing.T) {
+	configProvider, err := config.NewConfigProvider("./testdata/pipelines/config.yaml")
+	require.NoError(t, err)
+	replacements := config.DefaultReplacements("public", "int", "uksouth")
+
+	first, err := configProvider.GetResolver(replacements)
+	require.NoError(t, err)
+	cfg, err := first.GetRegionConfiguration("uksouth")
+	require.NoError(t, err)
+	expected := cfg.DeepCopy()
+
+	// configuration from one resolver must not change when configuration from another resolver for the same
+	// replacements is changed
+	svc := cfg["svc"].(map[string]any)
+	svc["subscription"].(map[string]any)["key"] = "changed"
+	svc["subscription"].(map[string]any)["certificateDomains"].([]any)[0] = "changed"
+	overrides, err := first.GetRegionOverrides("uksouth")
+	require.NoError(t, err)
+	overrides["changed"] = true
+
+	second, err := configProvider.GetResolver(replacements)
+	require.NoError(t, err)
+	cfg, err = second.GetRegionConfiguration("uksouth")
+	require.NoError(t, err)
+	if diff := cmp.Diff(expected, cfg); diff != "" {
+		t.Errorf("configuration changed between resolvers (-want +got):\n%s", diff)
+	}
+	require.NoError(t, second.ValidateSchema(cfg))
+
+	other, err := configProvider.GetResolver(config.DefaultReplacements("public", "int", "eastus"))
+	require.NoError(t, err)
+	cfg, err = other.GetRegionConfiguration("uksouth")
+	require.NoError(t, err)
+	require.NotEqual(t, expected["region"], cfg["region"], "resolvers for different replacements must not share configuration")
+}
+
+func TestResolveAll(t *testing.T) {
+	configProvider, err := config.NewConfigProvider("./testdata/pipelines/config.yaml")
+	require.NoError(t, err)
+
+	resolved, err := configProvider.ResolveAll(context.Background())
+	require.NoError(t, err)
+	serial, err := configProvider.ResolveAll(context.Background(), config.WithWorkers(1))
+	require.NoError(t, err)
+	require.Equal(t, resolved, serial)
+	var regions int
+	for cloud, environments := range configProvider.AllContexts() {
+		for environment, contextRegions := range environments {
+			for _, region := range contextRegions {
+				regions++
+				configResolver, err := configProvider.GetResolver(config.DefaultReplacements(cloud, environment, region))
+				require.NoError(t, err)
+				expected, err := configResolver.GetRegionConfiguration(region)
+				require.NoError(t, err)
+				if diff := cmp.Diff(expected, resolved[cloud][environment][region]); diff != "" {
+					t.Errorf("%s/%s/%s: configuration mismatch (-want +got):\n%s", cloud, environment, region, diff)
+				}
+			}
+		}
+	}
+	require.Positive(t, regions)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = configProvider.ResolveAll(cancelled)
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = configProvider.ResolveAll(context.Background(), config.WithWorkers(0))
+	require.EqualError(t, err, "at least one worker is required, got 0")
+
+	// many regions across several clouds and environments, so that workers run alongside each other
+	many, err := config.NewConfigProviderFromData([]byte(`$schema: schema.json
+defaults:
+  name: '{{ .ctx.cloud }}-{{ .ctx.environment }}-{{ .ctx.region }}'
+clouds:
+  public:
+    defaults: {}
+    environments:
+      int:
+        defaults: {}
+        regions:
+          uksouth: {}
+          eastus: {}
+          westus3: {}
+      stg:
+        defaults: {}
+        regions:
+          uksouth: {}
+          eastus: {}
+  ff:
+    defaults: {}
+    environments:
+      prod:
+        defaults: {}
+        regions:
+          usgovvirginia: {}
+`), t.TempDir())
+	require.NoError(t, err)
+	resolved, err = many.ResolveAll(context.Background(), config.WithWorkers(3))
+	require.NoError(t, err)
+	for cloud, environments := range many.AllContexts() {
+		for environment, contextRegions := range environments {
+			for _, region := range contextRegions {
+				require.Equal(t, cloud+"-"+environment+"-"+region, resolved[cloud][environment][region]["name"])
+			}
+		}
+	}
+}
+
 func TestConfigProvenance(t *testing.T) {
 	region := "uksouth"
 	regionShort := "uks"
@@ -115,6 +225,126 @@ func TestConfigProvenance(t *testing.T) {
 	}
 }
 
+const mergeDirectivesConfig = `$schema: schema.json
+defaults:
+  tags:
+    owner: aro
+    team: hcp
+  zones: [1, 2]
+  pools:
+  - name: system
+    size: 3
+  - name: user
+    size: 5
+clouds:
+  public:
+    defaults:
+      zones:
+        $patch: append
+        $items: [3]
+    environments:
+      int:
+        defaults:
+          tags:
+            team:
+              $patch: delete
+        regions:
+          uksouth:
+            tags:
+              $patch: delete
+            zones:
+              $patch: replace
+              $items: [1]
+            pools:
+              $patch: merge
+              $mergeKey: name
+              $items:
+              - name: system
+                size: 1
+              - name: user
+                $patch: delete
+`
+
+func mergeDirectivesResolver(t *testing.T) config.ConfigResolver {
+	t.Helper()
+	configProvider, err := config.NewConfigProviderFromData([]byte(mergeDirectivesConfig), ".")
+	require.NoError(t, err)
+	configResolver, err := configProvider.GetResolver(&config.ConfigReplacements{CloudReplacement: "public", EnvironmentReplacement: "int"})
+	require.NoError(t, err)
+	return configResolver
+}
+
+func TestMergeDirectives(t *testing.T) {
+	configResolver := mergeDirectivesResolver(t)
+
+	cfg, err := configResolver.GetConfiguration()
+	require.NoError(t, err)
+	if diff := cmp.Diff(types.Configuration{
+		"tags":  map[string]any{"owner": "aro"},
+		"zones": []any{int64(1), int64(2), int64(3)},
+		"pools": []any{
+			map[string]any{"name": "system", "size": int64(3)},
+			map[string]any{"name": "user", "size": int64(5)},
+		},
+	}, cfg); diff != "" {
+		t.Errorf("configuration mismatch (-want +got):\n%s", diff)
+	}
+
+	cfg, err = configResolver.GetRegionConfiguration("uksouth")
+	require.NoError(t, err)
+	if diff := cmp.Diff(types.Configuration{
+		"zones": []any{int64(1)},
+		"pools": []any{map[string]any{"name": "system", "size": int64(1)}},
+	}, cfg); diff != "" {
+		t.Errorf("region configuration mismatch (-want +got):\n%s", diff)
+	}
+
+	owner, err := configResolver.ValueProvenance("uksouth", "tags.owner")
+	require.NoError(t, err)
+	if diff := cmp.Diff(&config.Provenance{
+		Default:         "aro",
+		DefaultSet:      true,
+		RegionSet:       true,
+		RegionDirective: types.PatchDelete,
+	}, owner); diff != "" {
+		t.Errorf("provenance mismatch for tags.owner (-want +got):\n%s", diff)
+	}
+
+	zones, err := configResolver.ValueProvenance("uksouth", "zones")
+	require.NoError(t, err)
+	if diff := cmp.Diff(&config.Provenance{
+		Default:         []any{int64(1), int64(2)},
+		DefaultSet:      true,
+		Cloud:           []any{int64(3)},
+		CloudSet:        true,
+		CloudDirective:  types.PatchAppend,
+		Region:          []any{int64(1)},
+		RegionSet:       true,
+		RegionDirective: types.PatchReplace,
+		Result:          []any{int64(1)},
+		ResultSet:       true,
+	}, zones); diff != "" {
+		t.Errorf("provenance mismatch for zones (-want +got):\n%s", diff)
+	}
+}
+
+func TestMergeDirectivesInvalid(t *testing.T) {
+	_, err := config.NewConfigProviderFromData([]byte(`$schema: schema.json
+defaults:
+  zones: [1]
+clouds:
+  public:
+    environments:
+      int:
+        regions:
+          uksouth:
+            zones:
+              $patch: prepend
+              $items: [2]
+`), ".")
+	require.ErrorContains(t, err, `clouds.public.environments.int.regions.uksouth: configuration[zones]: unknown merge directive "prepend"`)
+}
+
 func TestMergeConfiguration(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -169,6 +399,78 @@ func TestMergeConfiguration(t *testing.T) {
 			override: types.Configuration{"key1": map[string]any{"key2": map[string]any{"key4": "value4"}}, "key5": "value5"},
 			expected: types.Configuration{"key1": map[string]any{"key2": map[string]any{"key3": "value3", "key4": "value4"}}, "key5": "value5"},
 		},
+		{
+			name:     "delete directive removes key",
+			base:     types.Configuration{"key1": map[string]any{"key2": "value2"}, "key3": "value3"},
+			override: types.Configuration{"key1": map[string]any{"$patch": "delete"}},
+			expected: types.Configuration{"key3": "value3"},
+		},
+		{
+			name:     "delete directive without base",
+			base:     types.Configuration{},
+			override: types.Configuration{"key1": map[string]any{"$patch": "delete"}},
+			expected: types.Configuration{},
+		},
+		{
+			name:     "null overrides without deleting",
+			base:     types.Configuration{"key1": "value1"},
+			override: types.Configuration{"key1": nil},
+			expected: types.Configuration{"key1": nil},
+		},
+		{
+			name:     "replace directive replaces map",
+			base:     types.Configuration{"key1": map[string]any{"key2": "value2"}},
+			override: types.Configuration{"key1": map[string]any{"$patch": "replace", "key3": map[string]any{"$patch": "delete"}, "key4": "value4"}},
+			expected: types.Configuration{"key1": map[string]any{"key4": "value4"}},
+		},
+		{
+			name:     "replace directive at top level",
+			base:     types.Configuration{"key1": "value1"},
+			override: types.Configuration{"$patch": "replace", "key2": "value2"},
+			expected: types.Configuration{"key2": "value2"},
+		},
+		{
+			name:     "replace directive replaces list",
+			base:     types.Configuration{"key1": []any{"a", "b"}},
+			override: types.Configuration{"key1": map[string]any{"$patch": "replace", "$items": []any{"c"}}},
+			expected: types.Configuration{"key1": []any{"c"}},
+		},
+		{
+			name:     "append directive appends to list",
+			base:     types.Configuration{"key1": []any{"a", "b"}},
+			override: types.Configuration{"key1": map[string]any{"$patch": "append", "$items": []any{"c"}}},
+			expected: types.Configuration{"key1": []any{"a", "b", "c"}},
+		},
+		{
+			name:     "append directive without base list",
+			base:     types.Configuration{"key1": "value1"},
+			override: types.Configuration{"key1": map[string]any{"$patch": "append", "$items": []any{"c"}}},
+			expected: types.Configuration{"key1": []any{"c"}},
+		},
+		{
+			name: "merge directive merges list by key",
+			base: types.Configuration{"key1": []any{
+				map[string]any{"name": "a", "value": "1", "kept": true},
+				map[string]any{"name": "b", "value": "2"},
+				map[string]any{"name": "c", "value": "3"},
+			}},
+			override: types.Configuration{"key1": map[string]any{"$patch": "merge", "$mergeKey": "name", "$items": []any{
+				map[string]any{"name": "a", "value": "10"},
+				map[string]any{"name": "b", "$patch": "delete"},
+				map[string]any{"name": "d", "value": "4"},
+			}}},
+			expected: types.Configuration{"key1": []any{
+				map[string]any{"name": "a", "value": "10", "kept": true},
+				map[string]any{"name": "c", "value": "3"},
+				map[string]any{"name": "d", "value": "4"},
+			}},
+		},
+		{
+			name:     "directives without base are removed",
+			base:     types.Configuration{},
+			override: types.Configuration{"key1": map[string]any{"key2": map[string]any{"$patch": "replace", "key3": "value3"}}},
+			expected: types.Configuration{"key1": map[string]any{"key2": map[string]any{"key3": "value3"}}},
+		},
 		{
 			name:     "non-string-key maps get overridden, not merged",
 			base:     types.Configuration{"key1": map[int]any{1: map[string]any{"key3": "value3"}}},
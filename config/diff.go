@@ -0,0 +1,188 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/config/types"
+)
+
+// ChangeKind classifies how a leaf of the configuration differs between two configurations.
+type ChangeKind string
+
+const (
+	ChangeKindAdded   ChangeKind = "added"
+	ChangeKindRemoved ChangeKind = "removed"
+	ChangeKindChanged ChangeKind = "changed"
+)
+
+// Change records how one leaf differs between two configurations. Leaves are values that are not maps, or empty maps;
+// a leaf that changes between a map and another value is recorded as removed and added.
+type Change struct {
+	// Path is the dot-separated path to the leaf, as used by ValueProvenance.
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	// Old is the value in the old configuration, unset when the leaf was added.
+	Old any `json:"old,omitempty"`
+	// New is the value in the new configuration, unset when the leaf was removed.
+	New any `json:"new,omitempty"`
+}
+
+// ConfigurationDiff records every difference between two configurations.
+type ConfigurationDiff struct {
+	// Changes lists the leaves that differ, ordered by path.
+	Changes []Change `json:"changes"`
+}
+
+// Empty determines if the configurations are the same.
+func (d *ConfigurationDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// String renders the diff for humans, one change per line: additions prefixed with +, removals with - and changes
+// with ~. Values are rendered as JSON.
+func (d *ConfigurationDiff) String() string {
+	var out strings.Builder
+	for _, change := range d.Changes {
+		switch change.Kind {
+		case ChangeKindAdded:
+			fmt.Fprintf(&out, "+ %s: %s\n", change.Path, renderValue(change.New))
+		case ChangeKindRemoved:
+			fmt.Fprintf(&out, "- %s: %s\n", change.Path, renderValue(change.Old))
+		case ChangeKindChanged:
+			fmt.Fprintf(&out, "~ %s: %s -> %s\n", change.Path, renderValue(change.Old), renderValue(change.New))
+		}
+	}
+	return out.String()
+}
+
+func renderValue(value any) string {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return strings.TrimSuffix(encoded.String(), "\n")
+}
+
+// MaskedValue replaces values that Mask determines are secret-like.
+const MaskedValue = "<masked>"
+
+// DefaultSecretPatterns match string values that look like credentials: private keys, JSON web tokens, connection
+// strings with keys or passwords, and long opaque base64 or hex strings.
+var DefaultSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`^eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`),
+	regexp.MustCompile(`(?i)(AccountKey|SharedAccessKey|Password|Pwd)=`),
+	regexp.MustCompile(`^[A-Za-z0-9+/]{40,}={0,2}$`),
+	regexp.MustCompile(`^[a-fA-F0-9]{40,}$`),
+}
+
+// Mask replaces every string value in the diff that matches any of the patterns with MaskedValue, including strings
+// held in lists and in maps within them, so that the diff may be shared without disclosing secrets. A changed value
+// stays recorded as changed when only one side is masked. Values that hold masked strings are copied, rather than
+// modified in place, as they may be shared with the configurations that were compared.
+func (d *ConfigurationDiff) Mask(patterns []*regexp.Regexp) {
+	var mask func(value any) any
+	mask = func(value any) any {
+		switch v := value.(type) {
+		case string:
+			if slices.ContainsFunc(patterns, func(pattern *regexp.Regexp) bool { return pattern.MatchString(v) }) {
+				return MaskedValue
+			}
+		case []any:
+			masked := make([]any, len(v))
+			for i, item := range v {
+				masked[i] = mask(item)
+			}
+			return masked
+		case map[string]any:
+			masked := make(map[string]any, len(v))
+			for key, item := range v {
+				masked[key] = mask(item)
+			}
+			return masked
+		case types.Configuration:
+			return mask(map[string]any(v))
+		}
+		return value
+	}
+	for i := range d.Changes {
+		d.Changes[i].Old = mask(d.Changes[i].Old)
+		d.Changes[i].New = mask(d.Changes[i].New)
+	}
+}
+
+// Diff compares two resolved configurations, recording every leaf that was added, removed or changed.
+func Diff(before, after types.Configuration) *ConfigurationDiff {
+	oldLeaves, newLeaves := leavesByPath(before), leavesByPath(after)
+	diff := &ConfigurationDiff{}
+	for path, oldValue := range oldLeaves {
+		newValue, exists := newLeaves[path]
+		switch {
+		case !exists:
+			diff.Changes = append(diff.Changes, Change{Path: path, Kind: ChangeKindRemoved, Old: oldValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			diff.Changes = append(diff.Changes, Change{Path: path, Kind: ChangeKindChanged, Old: oldValue, New: newValue})
+		}
+	}
+	for path, newValue := range newLeaves {
+		if _, exists := oldLeaves[path]; !exists {
+			diff.Changes = append(diff.Changes, Change{Path: path, Kind: ChangeKindAdded, New: newValue})
+		}
+	}
+	slices.SortFunc(diff.Changes, func(a, b Change) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return diff
+}
+
+// DiffRegions resolves the configuration for the region in each set of replacements, with the resolver the provider
+// creates for them, and compares the two. The providers may differ, to compare revisions of a configuration file.
+func DiffRegions(oldProvider ConfigProvider, oldReplacements *ConfigReplacements, newProvider ConfigProvider, newReplacements *ConfigReplacements) (*ConfigurationDiff, error) {
+	before, err := resolveRegion(oldProvider, oldReplacements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve old configuration: %w", err)
+	}
+	after, err := resolveRegion(newProvider, newReplacements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve new configuration: %w", err)
+	}
+	return Diff(before, after), nil
+}
+
+func resolveRegion(provider ConfigProvider, replacements *ConfigReplacements) (types.Configuration, error) {
+	resolver, err := provider.GetResolver(replacements)
+	if err != nil {
+		return nil, err
+	}
+	return resolver.GetRegionConfiguration(replacements.RegionReplacement)
+}
+
+func leavesByPath(cfg types.Configuration) map[string]any {
+	leaves := map[string]any{}
+	walkLeaves(cfg, nil, func(keys []string, value any) {
+		leaves[strings.Join(keys, ".")] = value
+	})
+	return leaves
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diffcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func NewDiffCommand() (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show how the configuration resolved for one context differs from another, or between revisions of the configuration file",
+		Example: `  # what differs between int and stg in uksouth
+  diff --config config.yaml --cloud public --environment int --region uksouth --to-environment stg
+
+  # what this change does to prod in uksouth
+  diff --config config.yaml --cloud public --environment prod --region uksouth --revision origin/main`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	opts := DefaultOptions()
+	if err := BindOptions(opts, cmd); err != nil {
+		return nil, fmt.Errorf("failed to bind options: %w", err)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		validated, err := opts.Validate()
+		if err != nil {
+			return err
+		}
+		completed, err := validated.Complete(cmd.Context())
+		if err != nil {
+			return err
+		}
+		return completed.Diff(cmd.OutOrStdout())
+	}
+
+	return cmd, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diffcmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const configTemplate = `$schema: schema.json
+defaults:
+  replicas: 1
+  password: hunter2
+clouds:
+  public:
+    defaults: {}
+    environments:
+      int:
+        defaults:
+          replicas: 2
+          storageKey: %s
+      stg:
+        defaults:
+          replicas: 3
+          storageKey: %s
+`
+
+func writeConfig(t *testing.T, dir, intKey, stgKey string) string {
+	t.Helper()
+	path := filepath.Join(dir, "config.yaml")
+	content := bytes.Replace([]byte(configTemplate), []byte("%s"), []byte(intKey), 1)
+	content = bytes.Replace(content, []byte("%s"), []byte(stgKey), 1)
+	require.NoError(t, os.WriteFile(path, content, 0644))
+	return path
+}
+
+func runDiff(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd, err := NewDiffCommand()
+	require.NoError(t, err)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	err = cmd.Execute()
+	return out.String(), err
+}
+
+func TestDiffContexts(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "AccountKey=secret", "AccountKey=other")
+
+	out, err := runDiff(t, "--config", path, "--cloud", "public", "--environment", "int", "--region", "uksouth", "--to-environment", "stg")
+	require.NoError(t, err)
+	assert.Equal(t, `--- public/int/uksouth
++++ public/stg/uksouth
+~ replicas: 2 -> 3
+~ storageKey: "<masked>" -> "<masked>"
+`, out)
+
+	out, err = runDiff(t, "--config", path, "--cloud", "public", "--environment", "int", "--region", "uksouth", "--to-environment", "stg", "--mask-secrets=false")
+	require.NoError(t, err)
+	assert.Contains(t, out, `~ storageKey: "AccountKey=secret" -> "AccountKey=other"`)
+
+	_, err = runDiff(t, "--config", path, "--cloud", "public", "--environment", "int", "--region", "uksouth")
+	assert.ErrorContains(t, err, "nothing to compare")
+}
+
+func TestDiffRevisions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	git("init", "--quiet")
+	path := writeConfig(t, dir, "one", "two")
+	git("add", "config.yaml")
+	git("commit", "--quiet", "--message", "initial")
+	writeConfig(t, dir, "changed", "two")
+
+	out, err := runDiff(t, "--config", path, "--cloud", "public", "--environment", "int", "--region", "uksouth", "--revision", "HEAD", "--format", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"changes": [{"path": "storageKey", "kind": "changed", "old": "one", "new": "changed"}]}`, out)
+
+	_, err = runDiff(t, "--config", path, "--cloud", "public", "--environment", "int", "--region", "uksouth", "--revision", "missing")
+	assert.ErrorContains(t, err, "failed to read")
+
+	_, err = runDiff(t, "--config", path, "--cloud", "public", "--environment", "int", "--region", "uksouth", "--to-revision", "--output=pwned")
+	assert.EqualError(t, err, `invalid --to-revision "--output=pwned", must not start with '-'`)
+	assert.NoFileExists(t, filepath.Join(dir, "pwned"))
+}
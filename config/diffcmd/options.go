@@ -0,0 +1,253 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diffcmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-Tools/config"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var formats = []string{FormatText, FormatJSON}
+
+func DefaultOptions() *RawOptions {
+	return &RawOptions{
+		MaskSecrets: true,
+		Format:      FormatText,
+	}
+}
+
+func BindOptions(opts *RawOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringVar(&opts.ConfigPath, "config", opts.ConfigPath, "Path to the service configuration file.")
+	cmd.Flags().StringVar(&opts.From.Cloud, "cloud", opts.From.Cloud, "Cloud of the context to compare from.")
+	cmd.Flags().StringVar(&opts.From.Environment, "environment", opts.From.Environment, "Environment of the context to compare from.")
+	cmd.Flags().StringVar(&opts.From.Region, "region", opts.From.Region, "Region of the context to compare from.")
+	cmd.Flags().StringVar(&opts.From.Revision, "revision", opts.From.Revision, "Git revision of the configuration file to compare from. Defaults to the file on disk. The $schema of a revision is resolved against the working tree.")
+	cmd.Flags().StringVar(&opts.To.Cloud, "to-cloud", opts.To.Cloud, "Cloud of the context to compare to. Defaults to --cloud.")
+	cmd.Flags().StringVar(&opts.To.Environment, "to-environment", opts.To.Environment, "Environment of the context to compare to. Defaults to --environment.")
+	cmd.Flags().StringVar(&opts.To.Region, "to-region", opts.To.Region, "Region of the context to compare to. Defaults to --region.")
+	cmd.Flags().StringVar(&opts.To.Revision, "to-revision", opts.To.Revision, "Git revision of the configuration file to compare to. Defaults to the file on disk. The $schema of a revision is resolved against the working tree.")
+	cmd.Flags().BoolVar(&opts.MaskSecrets, "mask-secrets", opts.MaskSecrets, "Mask values that look like secrets.")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, fmt.Sprintf("Output format, one of %v.", formats))
+
+	if err := cmd.MarkFlagFilename("config"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a file: %w", "config", err)
+	}
+	return nil
+}
+
+// Side identifies one side of the comparison: a context, and the revision of the configuration file to resolve it from.
+type Side struct {
+	Cloud       string
+	Environment string
+	Region      string
+	Revision    string
+}
+
+func (s Side) String() string {
+	side := fmt.Sprintf("%s/%s/%s", s.Cloud, s.Environment, s.Region)
+	if s.Revision != "" {
+		side += "@" + s.Revision
+	}
+	return side
+}
+
+// RawOptions holds input values.
+type RawOptions struct {
+	ConfigPath  string
+	From        Side
+	To          Side
+	MaskSecrets bool
+	Format      string
+}
+
+// validatedOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedOptions struct {
+	ConfigPath  string
+	From        Side
+	To          Side
+	MaskSecrets bool
+	Format      string
+}
+
+type ValidatedOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedOptions
+}
+
+// completedOptions is a private wrapper that enforces a call of Complete() before the diff can be invoked.
+type completedOptions struct {
+	From, To                 Side
+	FromProvider, ToProvider config.ConfigProvider
+	FromReplacements         *config.ConfigReplacements
+	ToReplacements           *config.ConfigReplacements
+	MaskSecrets              bool
+	Format                   string
+}
+
+type Options struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedOptions
+}
+
+func (o *RawOptions) Validate() (*ValidatedOptions, error) {
+	if o.ConfigPath == "" {
+		return nil, fmt.Errorf("the service configuration file must be provided with --config")
+	}
+	for _, flag := range []struct{ name, value string }{
+		{name: "cloud", value: o.From.Cloud},
+		{name: "environment", value: o.From.Environment},
+		{name: "region", value: o.From.Region},
+	} {
+		if flag.value == "" {
+			return nil, fmt.Errorf("the %s must be provided with --%s", flag.name, flag.name)
+		}
+	}
+	if !slices.Contains(formats, o.Format) {
+		return nil, fmt.Errorf("invalid --format %q, must be one of %v", o.Format, formats)
+	}
+	for _, flag := range []struct{ name, value string }{
+		{name: "revision", value: o.From.Revision},
+		{name: "to-revision", value: o.To.Revision},
+	} {
+		// git would otherwise parse the revision as an option
+		if strings.HasPrefix(flag.value, "-") {
+			return nil, fmt.Errorf("invalid --%s %q, must not start with '-'", flag.name, flag.value)
+		}
+	}
+
+	to := o.To
+	for _, field := range []struct {
+		to   *string
+		from string
+	}{
+		{to: &to.Cloud, from: o.From.Cloud},
+		{to: &to.Environment, from: o.From.Environment},
+		{to: &to.Region, from: o.From.Region},
+	} {
+		if *field.to == "" {
+			*field.to = field.from
+		}
+	}
+	if to == o.From {
+		return nil, fmt.Errorf("nothing to compare: provide a different context with --to-cloud, --to-environment or --to-region, or a revision with --revision or --to-revision")
+	}
+
+	return &ValidatedOptions{
+		validatedOptions: &validatedOptions{
+			ConfigPath:  o.ConfigPath,
+			From:        o.From,
+			To:          to,
+			MaskSecrets: o.MaskSecrets,
+			Format:      o.Format,
+		},
+	}, nil
+}
+
+func (o *ValidatedOptions) Complete(ctx context.Context) (*Options, error) {
+	completed := &completedOptions{
+		From:        o.From,
+		To:          o.To,
+		MaskSecrets: o.MaskSecrets,
+		Format:      o.Format,
+	}
+	for _, side := range []struct {
+		side         Side
+		provider     *config.ConfigProvider
+		replacements **config.ConfigReplacements
+	}{
+		{side: o.From, provider: &completed.FromProvider, replacements: &completed.FromReplacements},
+		{side: o.To, provider: &completed.ToProvider, replacements: &completed.ToReplacements},
+	} {
+		provider, err := loadProvider(ctx, o.ConfigPath, side.side.Revision)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", side.side, err)
+		}
+		*side.provider = provider
+		*side.replacements = config.DefaultReplacements(side.side.Cloud, side.side.Environment, side.side.Region)
+	}
+	return &Options{completedOptions: completed}, nil
+}
+
+// loadProvider loads the configuration file as it is on disk, or at the git revision if one is provided. Only the
+// configuration file is read at the revision: its $schema is resolved against the working tree, which is harmless as
+// diffs are not validated against the schema.
+func loadProvider(ctx context.Context, path, revision string) (config.ConfigProvider, error) {
+	if revision == "" {
+		provider, err := config.NewConfigProvider(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load service configuration: %w", err)
+		}
+		return provider, nil
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for config file %q: %w", path, err)
+	}
+	var stdout, stderr bytes.Buffer
+	// a path starting with ./ is resolved relative to the directory git runs in, rather than the repository root
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "show", fmt.Sprintf("%s:./%s", revision, filepath.Base(path)))
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to read %s at revision %s: %w: %s", path, revision, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	provider, err := config.NewConfigProviderFromData(stdout.Bytes(), dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service configuration at revision %s: %w", revision, err)
+	}
+	return provider, nil
+}
+
+// Diff compares the two sides, writing the changes to out in the chosen format.
+func (opts *Options) Diff(out io.Writer) error {
+	diff, err := config.DiffRegions(opts.FromProvider, opts.FromReplacements, opts.ToProvider, opts.ToReplacements)
+	if err != nil {
+		return err
+	}
+	if opts.MaskSecrets {
+		diff.Mask(config.DefaultSecretPatterns)
+	}
+
+	var encoded []byte
+	switch opts.Format {
+	case FormatJSON:
+		if encoded, err = json.MarshalIndent(diff, "", "  "); err != nil {
+			return fmt.Errorf("failed to encode diff: %w", err)
+		}
+		encoded = append(encoded, '\n')
+	default:
+		encoded = fmt.Appendf(nil, "--- %s\n+++ %s\n%s", opts.From, opts.To, diff.String())
+	}
+	if _, err := out.Write(encoded); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.yaml.in/yaml/v3"
+)
+
+// Position identifies a line and column in a source file. Lines and columns are 1-indexed.
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// String formats the position as file:line:col, omitting the file when it is not known.
+func (p Position) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Positions records where each node of a YAML document starts in the original, pre-template source. Nodes are
+// keyed by JSONPath-like locations: map keys are joined with dots and list indices use brackets, like
+// resourceGroups[2].steps[4] or clouds.public.defaults.region. Entries in a mapping are positioned at their key.
+type Positions map[string]Position
+
+// Lookup finds the position for a location, falling back to the closest ancestor that has a recorded position when
+// the location itself does not - for instance, when a schema reports a missing property.
+func (p Positions) Lookup(location string) (Position, bool) {
+	for {
+		if position, ok := p[location]; ok {
+			return position, true
+		}
+		if location == "" {
+			return Position{}, false
+		}
+		location = parentLocation(location)
+	}
+}
+
+func parentLocation(location string) string {
+	cut := max(strings.LastIndex(location, "."), strings.LastIndex(location, "["))
+	if cut < 0 {
+		return ""
+	}
+	return location[:cut]
+}
+
+// JoinLocation appends a map key to a JSONPath-like location.
+func JoinLocation(location, key string) string {
+	if location == "" {
+		return key
+	}
+	return location + "." + key
+}
+
+// IndexLocation appends a list index to a JSONPath-like location.
+func IndexLocation(location string, index int) string {
+	return fmt.Sprintf("%s[%d]", location, index)
+}
+
+// LocationFromTokens formats the tokens of a JSON pointer as a JSONPath-like location. Numeric tokens are treated
+// as list indices.
+func LocationFromTokens(tokens []string) string {
+	var location string
+	for _, token := range tokens {
+		if index, err := strconv.Atoi(token); err == nil {
+			location = IndexLocation(location, index)
+			continue
+		}
+		location = JoinLocation(location, token)
+	}
+	return location
+}
+
+// NewPositions parses rendered YAML - the output of running source through PreprocessContent - and records the
+// position of every node in it. Positions are mapped back onto source, so that they point at the line the author
+// wrote rather than at the rendered output. The file name is recorded on every position, and may be empty.
+func NewPositions(file string, source, rendered []byte) (Positions, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(rendered, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML for source positions: %w", err)
+	}
+
+	sourceLines := strings.Split(string(source), "\n")
+	renderedLines := strings.Split(string(rendered), "\n")
+	lines := alignLines(sourceLines, renderedLines)
+
+	positions := Positions{}
+	record := func(location string, node *yaml.Node) {
+		renderedLine := node.Line - 1
+		if renderedLine < 0 || renderedLine >= len(lines) {
+			return
+		}
+		sourceLine := lines[renderedLine]
+		positions[location] = Position{
+			File:   file,
+			Line:   sourceLine + 1,
+			Column: sourceColumn(sourceLines[sourceLine], renderedLines[renderedLine], node.Column),
+		}
+	}
+
+	var walk func(location string, node *yaml.Node)
+	walk = func(location string, node *yaml.Node) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(location, child)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				child := JoinLocation(location, key.Value)
+				record(child, key)
+				walk(child, value)
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				child := IndexLocation(location, i)
+				record(child, item)
+				walk(child, item)
+			}
+		}
+	}
+	if document.Kind != 0 {
+		record("", &document)
+	}
+	walk("", &document)
+	return positions, nil
+}
+
+// sourceColumn translates a column in a rendered line to the source line it came from. Columns before the first
+// character that templating changed are preserved; anything later points at the first template action in the
+// source line, as that is what produced the rendered content.
+func sourceColumn(sourceLine, renderedLine string, column int) int {
+	if sourceLine == renderedLine {
+		return column
+	}
+	prefix := 0
+	for prefix < len(sourceLine) && prefix < len(renderedLine) && sourceLine[prefix] == renderedLine[prefix] {
+		prefix++
+	}
+	if column-1 <= prefix {
+		return column
+	}
+	if action := strings.Index(sourceLine, "{{"); action >= 0 {
+		return action + 1
+	}
+	return prefix + 1
+}
+
+// alignLines maps every rendered line to the source line it most likely came from. When every rendered line could
+// have been rendered from the source line at the same index, they are mapped one to one. Otherwise, lines that
+// templating left untouched are matched exactly using a Myers diff; lines in between two matches are paired up in
+// order with the unmatched source lines in the same gap.
+func alignLines(source, rendered []string) []int {
+	mapping := make([]int, len(rendered))
+	if len(source) == len(rendered) && slices.EqualFunc(source, rendered, rendersTo) {
+		for i := range mapping {
+			mapping[i] = i
+		}
+		return mapping
+	}
+
+	matches := diffMatches(source, rendered)
+	// sentinel match after the end of both inputs simplifies gap handling
+	matches = append(matches, [2]int{len(source), len(rendered)})
+	previousSource, previousRendered := -1, -1
+	for _, match := range matches {
+		gapSource := match[0] - previousSource - 1
+		for offset := range match[1] - previousRendered - 1 {
+			// with no unmatched source lines in the gap, the rendered lines were produced by a multi-line
+			// expansion of the last matched source line
+			line := previousSource
+			if gapSource > 0 {
+				line = previousSource + 1 + min(offset, gapSource-1)
+			}
+			mapping[previousRendered+1+offset] = max(min(line, len(source)-1), 0)
+		}
+		if match[1] < len(rendered) {
+			mapping[match[1]] = match[0]
+		}
+		previousSource, previousRendered = match[0], match[1]
+	}
+	return mapping
+}
+
+// rendersTo determines whether the rendered line could have been produced by the source line alone: the text outside
+// of template actions must be unchanged, while each action may have rendered to anything on the same line.
+func rendersTo(source, rendered string) bool {
+	if source == rendered {
+		return true
+	}
+	var literals []string
+	rest := source
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return false
+		}
+		literal, action := rest[:start], rest[start:start+end+len("}}")]
+		// trim markers remove the whitespace next to the action
+		if strings.HasPrefix(action, "{{-") {
+			literal = strings.TrimRight(literal, " \t")
+		}
+		literals = append(literals, literal)
+		rest = rest[start+len(action):]
+		if strings.HasSuffix(action, "-}}") {
+			rest = strings.TrimLeft(rest, " \t")
+		}
+	}
+	if len(literals) == 0 {
+		return false
+	}
+	literals = append(literals, rest)
+
+	// the literals must appear in order, anchored at either end of the line
+	if !strings.HasPrefix(rendered, literals[0]) {
+		return false
+	}
+	remaining := rendered[len(literals[0]):]
+	for _, literal := range literals[1 : len(literals)-1] {
+		index := strings.Index(remaining, literal)
+		if index < 0 {
+			return false
+		}
+		remaining = remaining[index+len(literal):]
+	}
+	return strings.HasSuffix(remaining, literals[len(literals)-1])
+}
+
+// diffMatches returns the pairs of (source, rendered) line indices that a shortest edit script leaves unchanged,
+// in increasing order.
+func diffMatches(a, b []string) [][2]int {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// trace records, for every edit distance d, the furthest reaching x on the diagonals -d+1 to d-1 that the
+	// previous distance reached, which is all that backtracking through distance d needs
+	var trace [][]int
+
+	var d int
+search:
+	for d = 0; d <= n+m; d++ {
+		if d == 0 {
+			trace = append(trace, nil)
+		} else {
+			trace = append(trace, slices.Clone(v[offset-d+1:offset+d]))
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var matches [][2]int
+	x, y := n, m
+	for ; d > 0; d-- {
+		// diagonal k of distance d-1 is at index k+d-1 of its window
+		previous := trace[d]
+		k := x - y
+		var previousK int
+		if k == -d || (k != d && previous[k-1+d-1] < previous[k+1+d-1]) {
+			previousK = k + 1
+		} else {
+			previousK = k - 1
+		}
+		previousX := previous[previousK+d-1]
+		previousY := previousX - previousK
+		for x > previousX && y > previousY {
+			x--
+			y--
+			matches = append(matches, [2]int{x, y})
+		}
+		x, y = previousX, previousY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		matches = append(matches, [2]int{x, y})
+	}
+	slices.Reverse(matches)
+	return matches
+}
+
+// SchemaViolations flattens a schema validation error into the leaf errors that describe concrete violations.
+func SchemaViolations(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, SchemaViolations(cause)...)
+	}
+	return leaves
+}
+
+// annotatedSchemaError lists the violations of a schema validation error with their source positions. The
+// original error remains available through errors.As.
+type annotatedSchemaError struct {
+	err        *jsonschema.ValidationError
+	violations []string
+}
+
+func (e *annotatedSchemaError) Error() string {
+	header, _, _ := strings.Cut(e.err.Error(), "\n")
+	return fmt.Sprintf("%s\n- %s", header, strings.Join(e.violations, "\n- "))
+}
+
+func (e *annotatedSchemaError) Unwrap() error {
+	return e.err
+}
+
+// annotateSchemaError rewrites a schema validation error as a list of violations, each prefixed with the source
+// position that position finds for the offending node. Other errors are returned as-is.
+func annotateSchemaError(err error, position func(location string) (Position, bool)) error {
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	var violations []string
+	seen := map[string]bool{}
+	for _, leaf := range SchemaViolations(validationErr) {
+		location := LocationFromTokens(leaf.InstanceLocation)
+		if celErr, ok := leaf.ErrorKind.(*celErrorKind); ok && celErr.field != "" {
+			location = JoinLocation(location, celErr.field)
+		}
+		violation := leaf.BasicOutput().Error.String()
+		if location != "" {
+			violation = fmt.Sprintf("%s: %s", location, violation)
+		}
+		if pos, ok := position(location); ok {
+			violation = fmt.Sprintf("%s: %s", pos, violation)
+		}
+		if !seen[violation] {
+			seen[violation] = true
+			violations = append(violations, violation)
+		}
+	}
+	return &annotatedSchemaError{err: validationErr, violations: violations}
+}
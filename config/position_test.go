@@ -0,0 +1,127 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPositions(t *testing.T) {
+	source := []byte(`defaults:
+  region: {{ .ctx.region }}
+{{- if eq .ctx.cloud "public" }}
+  public: true
+{{- end }}
+  items:
+  - name: first
+  - name: {{ .ctx.stamp }}
+`)
+	vars := ConfigReplacements{RegionReplacement: "uksouth", CloudReplacement: "public", StampReplacement: "1"}.AsMap()
+	rendered, err := PreprocessContent(source, vars)
+	require.NoError(t, err)
+
+	positions, err := NewPositions("config.yaml", source, rendered)
+	require.NoError(t, err)
+
+	for location, expected := range map[string]string{
+		"defaults":               "config.yaml:1:1",
+		"defaults.region":        "config.yaml:2:3",
+		"defaults.public":        "config.yaml:4:3",
+		"defaults.items":         "config.yaml:6:3",
+		"defaults.items[1]":      "config.yaml:8:5",
+		"defaults.items[1].name": "config.yaml:8:5",
+	} {
+		position, ok := positions.Lookup(location)
+		require.True(t, ok, "no position for %s", location)
+		require.Equal(t, expected, position.String(), "wrong position for %s", location)
+	}
+
+	position, ok := positions.Lookup("defaults.items[0].missing")
+	require.True(t, ok)
+	require.Equal(t, "config.yaml:7:5", position.String())
+
+	_, ok = Positions{}.Lookup("defaults")
+	require.False(t, ok)
+}
+
+func TestAlignLines(t *testing.T) {
+	for _, testCase := range []struct {
+		name             string
+		source, rendered []string
+		expected         []int
+	}{
+		{
+			name:     "unchanged line count",
+			source:   []string{"a: {{ .a }}", "b: 1"},
+			rendered: []string{"a: 1", "b: 1"},
+			expected: []int{0, 1},
+		},
+		{
+			name:     "removed lines balanced by an expansion",
+			source:   []string{"a: 1", "{{- if .x }}", "x: 1", "{{- end }}", "b: 1", "c: {{ .c }}"},
+			rendered: []string{"a: 1", "b: 1", "c:", "  - one", "  - two", "  - three"},
+			expected: []int{0, 4, 5, 5, 5, 5},
+		},
+		{
+			name:     "removed lines",
+			source:   []string{"a: 1", "{{- if .b }}", "b: 1", "{{- end }}", "c: 1"},
+			rendered: []string{"a: 1", "c: 1"},
+			expected: []int{0, 4},
+		},
+		{
+			name:     "multi-line expansion",
+			source:   []string{"a: 1", "b: {{ .b }}", "c: 1"},
+			rendered: []string{"a: 1", "b:", "  - one", "  - two", "c: 1"},
+			expected: []int{0, 1, 1, 1, 2},
+		},
+		{
+			name:     "expansion of an unchanged line",
+			source:   []string{"a:", "{{ .a }}", "c: 1"},
+			rendered: []string{"a:", "  - one", "  - two", "c: 1"},
+			expected: []int{0, 1, 1, 2},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.Equal(t, testCase.expected, alignLines(testCase.source, testCase.rendered))
+		})
+	}
+}
+
+func TestRendersTo(t *testing.T) {
+	for _, testCase := range []struct {
+		source, rendered string
+		expected         bool
+	}{
+		{source: "a: 1", rendered: "a: 1", expected: true},
+		{source: "a: 1", rendered: "a: 2", expected: false},
+		{source: "a: {{ .a }}", rendered: "a: 1", expected: true},
+		{source: "a: {{ .a }}", rendered: "b: 1", expected: false},
+		{source: "{{ .k }}: {{ .v }} # comment", rendered: "key: value # comment", expected: true},
+		{source: "{{ .k }}: {{ .v }} # comment", rendered: "key = value # comment", expected: false},
+		{source: "a: {{- .a }}", rendered: "a:1", expected: true},
+		{source: "a: {{ .a", rendered: "a: {{ .a", expected: true},
+		{source: "a: {{ .a", rendered: "a: 1", expected: false},
+	} {
+		require.Equal(t, testCase.expected, rendersTo(testCase.source, testCase.rendered), "%q -> %q", testCase.source, testCase.rendered)
+	}
+}
+
+func TestLocationFromTokens(t *testing.T) {
+	require.Equal(t, "", LocationFromTokens(nil))
+	require.Equal(t, "resourceGroups[2].steps[4]", LocationFromTokens([]string{"resourceGroups", "2", "steps", "4"}))
+	require.Equal(t, "clouds.public.defaults.region", LocationFromTokens([]string{"clouds", "public", "defaults", "region"}))
+}
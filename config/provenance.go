@@ -0,0 +1,284 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/Azure/ARO-Tools/config/types"
+)
+
+// ProvenanceLayer names a layer of the configuration that may set a value. Layers are merged from the least specific,
+// the defaults, to the most specific, the region overrides.
+type ProvenanceLayer string
+
+const (
+	ProvenanceLayerDefault     ProvenanceLayer = "default"
+	ProvenanceLayerCloud       ProvenanceLayer = "cloud"
+	ProvenanceLayerEnvironment ProvenanceLayer = "environment"
+	ProvenanceLayerRegion      ProvenanceLayer = "region"
+)
+
+// ProvenanceValue records a value that a layer set.
+type ProvenanceValue struct {
+	Layer ProvenanceLayer `json:"layer"`
+	// Path is where the layer set the value, when that is not the leaf itself but an ancestor of it - a layer may set
+	// a value that a more specific layer replaces with a map.
+	Path  string `json:"path,omitempty"`
+	Value any    `json:"value"`
+	// Directive is the merge directive with which the layer set the value, if any.
+	Directive types.PatchDirective `json:"directive,omitempty"`
+	// Position is where the value is set in the configuration file, when it is known.
+	Position *Position `json:"position,omitempty"`
+}
+
+// LeafProvenance records how a leaf of the resolved configuration came to hold its value. Leaves are values that are
+// not maps, or empty maps, as only maps are merged between layers.
+type LeafProvenance struct {
+	// Path is the dot-separated path to the leaf, as used by ValueProvenance.
+	Path string `json:"path"`
+	// Value is the resolved value of the leaf.
+	Value any `json:"value"`
+	// Layer is the most specific layer that set the value, and Position is where it did so, when that is known.
+	Layer    ProvenanceLayer `json:"layer"`
+	Position *Position       `json:"position,omitempty"`
+	// Directive is the merge directive with which the layer set the value, if any. Less specific layers still
+	// contribute to values that are appended to or merged by key.
+	Directive types.PatchDirective `json:"directive,omitempty"`
+	// Shadowed lists the values that less specific layers set for the leaf and were overridden, from the most specific
+	// layer to the least. Layers beneath one that deletes or replaces the leaf or its ancestors are not listed, as
+	// nothing they set survives.
+	Shadowed []ProvenanceValue `json:"shadowed,omitempty"`
+
+	keys []string
+}
+
+// ProvenanceReport records the provenance of every leaf in the configuration resolved for a region. The report
+// marshals to JSON, and may be rendered as the resolved configuration annotated with provenance by AnnotatedYAML.
+type ProvenanceReport struct {
+	Cloud       string `json:"cloud"`
+	Environment string `json:"environment"`
+	Region      string `json:"region"`
+	// Leaves lists the provenance of every leaf, ordered by path.
+	Leaves []LeafProvenance `json:"leaves"`
+
+	resolved types.Configuration
+}
+
+// provenanceLayer is a layer of the configuration along with the location at which it is set in the file.
+type provenanceLayer struct {
+	name     ProvenanceLayer
+	location string
+	cfg      types.Configuration
+}
+
+// ProvenanceReport determines the provenance of every leaf of the configuration for a region, like ValueProvenance
+// does for one path: which layer set it, which layers it shadowed, and where each value is set in the file.
+func (cr *configResolver) ProvenanceReport(region string) (*ProvenanceReport, error) {
+	cloudCfg, hasCloud := cr.cfg.Overrides[cr.cloud]
+	if !hasCloud {
+		return nil, fmt.Errorf("the cloud %s is not found in the config", cr.cloud)
+	}
+	envCfg, hasEnv := cloudCfg.Overrides[cr.environment]
+	if !hasEnv {
+		return nil, fmt.Errorf("the deployment env %s is not found under cloud %s", cr.environment, cr.cloud)
+	}
+	resolved, err := cr.GetRegionConfiguration(region)
+	if err != nil {
+		return nil, err
+	}
+
+	cloud := JoinLocation("clouds", cr.cloud)
+	environment := JoinLocation(cloud, JoinLocation("environments", cr.environment))
+	// ordered from the most specific layer to the least, as that is the order in which they shadow each other
+	layers := []provenanceLayer{
+		{name: ProvenanceLayerRegion, location: JoinLocation(JoinLocation(environment, "regions"), region), cfg: envCfg.Overrides[region]},
+		{name: ProvenanceLayerEnvironment, location: JoinLocation(environment, "defaults"), cfg: envCfg.Defaults},
+		{name: ProvenanceLayerCloud, location: JoinLocation(cloud, "defaults"), cfg: cloudCfg.Defaults},
+		{name: ProvenanceLayerDefault, location: "defaults", cfg: cr.cfg.Defaults},
+	}
+
+	report := &ProvenanceReport{
+		Cloud:       cr.cloud,
+		Environment: cr.environment,
+		Region:      region,
+		resolved:    resolved,
+	}
+	walkLeaves(resolved, nil, func(keys []string, value any) {
+		leaf := LeafProvenance{Path: strings.Join(keys, "."), Value: value, keys: keys}
+		setBy := -1
+		for i, layer := range layers {
+			set, depth, directive, found := lookupKeys(layer.cfg, keys)
+			if !found {
+				continue
+			}
+			location := layer.location
+			for _, key := range keys[:depth] {
+				location = JoinLocation(location, key)
+			}
+			var position *Position
+			if p, ok := cr.positions[location]; ok {
+				position = &p
+			}
+			if setBy < 0 && depth == len(keys) {
+				setBy = i
+				leaf.Layer, leaf.Position, leaf.Directive = layer.name, position, directive
+			} else {
+				shadowed := ProvenanceValue{Layer: layer.name, Value: set, Directive: directive, Position: position}
+				if depth != len(keys) {
+					shadowed.Path = strings.Join(keys[:depth], ".")
+				}
+				leaf.Shadowed = append(leaf.Shadowed, shadowed)
+			}
+			if directive == types.PatchDelete || directive == types.PatchReplace {
+				break
+			}
+		}
+		report.Leaves = append(report.Leaves, leaf)
+	})
+	return report, nil
+}
+
+// walkLeaves visits every leaf in the configuration in order of their keys.
+func walkLeaves(cfg map[string]any, parent []string, visit func(keys []string, value any)) {
+	keys := make([]string, 0, len(cfg))
+	for key := range cfg {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		path := append(slices.Clone(parent), key)
+		if nested, ok := cfg[key].(map[string]any); ok && len(nested) > 0 {
+			walkLeaves(nested, path, visit)
+			continue
+		}
+		visit(path, cfg[key])
+	}
+}
+
+// lookupKeys finds the value that the configuration sets for the keys. When the configuration sets an ancestor of
+// the keys to a value that is not a map, that value is returned along with the depth of the ancestor. The merge
+// directive closest to the keys is returned too: an ancestor that is deleted is returned as nil along with its depth,
+// as is an ancestor that is replaced by a map without the next key, and the items of a list directive are returned
+// in place of the directive.
+func lookupKeys(cfg map[string]any, keys []string) (any, int, types.PatchDirective, bool) {
+	var current any = cfg
+	var directive types.PatchDirective
+	for depth, key := range keys {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return current, depth, directive, true
+		}
+		d, isDirective := types.Directive(m)
+		if isDirective {
+			directive = d
+		}
+		next, ok := m[key]
+		if !ok {
+			if isDirective && (d == types.PatchDelete || d == types.PatchReplace) {
+				return nil, depth, d, true
+			}
+			return nil, 0, "", false
+		}
+		current = next
+	}
+	if d, ok := types.Directive(current); ok {
+		directive = d
+		m := current.(map[string]any)
+		switch {
+		case d == types.PatchDelete:
+			current = nil
+		case m[types.PatchItemsKey] != nil:
+			current = m[types.PatchItemsKey]
+		default:
+			current = types.MergeConfiguration(nil, m)
+		}
+	}
+	return current, len(keys), directive, true
+}
+
+// AnnotatedYAML renders the resolved configuration as YAML, commenting every leaf with the layer that set it and the
+// layers it shadowed, along with where they are set in the configuration file.
+func (r *ProvenanceReport) AnnotatedYAML() ([]byte, error) {
+	var document yaml.Node
+	if err := document.Encode(map[string]any(r.resolved)); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	document.HeadComment = fmt.Sprintf("configuration for cloud %s, environment %s, region %s", r.Cloud, r.Environment, r.Region)
+
+	leaves := map[string]LeafProvenance{}
+	for _, leaf := range r.Leaves {
+		leaves[strings.Join(leaf.keys, "\x00")] = leaf
+	}
+	var annotate func(node *yaml.Node, parent []string)
+	annotate = func(node *yaml.Node, parent []string) {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keys := append(slices.Clone(parent), key.Value)
+			if leaf, ok := leaves[strings.Join(keys, "\x00")]; ok {
+				key.LineComment = leaf.annotation()
+				if value.Kind == yaml.ScalarNode {
+					value.LineComment, key.LineComment = key.LineComment, ""
+				}
+				continue
+			}
+			if value.Kind == yaml.MappingNode {
+				annotate(value, keys)
+			}
+		}
+	}
+	annotate(&document, nil)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func (l LeafProvenance) annotation() string {
+	annotation := string(l.Layer)
+	if l.Directive != "" {
+		annotation += " (" + string(l.Directive) + ")"
+	}
+	if l.Position != nil {
+		annotation += " " + l.Position.String()
+	}
+	if len(l.Shadowed) > 0 {
+		var shadowed []string
+		for _, value := range l.Shadowed {
+			description := string(value.Layer)
+			if value.Directive != "" {
+				description += " (" + string(value.Directive) + ")"
+			}
+			if value.Position != nil {
+				description += " " + value.Position.String()
+			}
+			shadowed = append(shadowed, description)
+		}
+		annotation += ", shadows " + strings.Join(shadowed, ", ")
+	}
+	return "# " + annotation
+}
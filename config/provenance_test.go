@@ -0,0 +1,180 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/config/ev2config"
+	"github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/testutil"
+)
+
+func provenanceReport(t *testing.T) *config.ProvenanceReport {
+	t.Helper()
+	ev2, err := ev2config.ResolveConfig("public", "uksouth")
+	require.NoError(t, err)
+
+	configProvider, err := config.NewConfigProvider("./testdata/pipelines/config.yaml")
+	require.NoError(t, err)
+	configResolver, err := configProvider.GetResolver(&config.ConfigReplacements{
+		RegionReplacement:      "uksouth",
+		RegionShortReplacement: "uks",
+		StampReplacement:       "1",
+		CloudReplacement:       "public",
+		EnvironmentReplacement: "int",
+		Ev2Config:              ev2,
+	})
+	require.NoError(t, err)
+
+	report, err := configResolver.ProvenanceReport("uksouth")
+	require.NoError(t, err)
+	return report
+}
+
+func TestProvenanceReport(t *testing.T) {
+	report := provenanceReport(t)
+
+	leaves := map[string]config.LeafProvenance{}
+	for _, leaf := range report.Leaves {
+		leaves[leaf.Path] = leaf
+	}
+	position := func(line, column int) *config.Position {
+		return &config.Position{File: "./testdata/pipelines/config.yaml", Line: line, Column: column}
+	}
+	for path, expected := range map[string]config.LeafProvenance{
+		"ubiquitousValue": {
+			Path:     "ubiquitousValue",
+			Value:    "public-int-uksouth-value",
+			Layer:    config.ProvenanceLayerRegion,
+			Position: position(97, 13),
+			Shadowed: []config.ProvenanceValue{
+				{Layer: config.ProvenanceLayerEnvironment, Value: "public-int-value", Position: position(93, 11)},
+				{Layer: config.ProvenanceLayerCloud, Value: "public-value", Position: position(82, 7)},
+				{Layer: config.ProvenanceLayerDefault, Value: "global-value", Position: position(71, 3)},
+			},
+		},
+		"partialValue": {
+			Path:     "partialValue",
+			Value:    "public-int-uksouth-value",
+			Layer:    config.ProvenanceLayerRegion,
+			Position: position(98, 13),
+			Shadowed: []config.ProvenanceValue{
+				{Layer: config.ProvenanceLayerDefault, Value: "global-value", Position: position(72, 3)},
+			},
+		},
+		"clustersService.replicas": {
+			Path:     "clustersService.replicas",
+			Value:    int64(3),
+			Layer:    config.ProvenanceLayerDefault,
+			Position: position(31, 5),
+		},
+	} {
+		if diff := cmp.Diff(expected, leaves[path], cmpopts.IgnoreUnexported(config.LeafProvenance{})); diff != "" {
+			t.Errorf("provenance mismatch for %s (-want +got):\n%s", path, diff)
+		}
+	}
+
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	require.NoError(t, err)
+	testutil.CompareWithFixture(t, encoded, testutil.WithExtension(".json"))
+}
+
+func TestProvenanceReportAnnotatedYAML(t *testing.T) {
+	annotated, err := provenanceReport(t).AnnotatedYAML()
+	require.NoError(t, err)
+	testutil.CompareWithFixture(t, annotated, testutil.WithExtension(".yaml"))
+}
+
+func TestProvenanceReportShadowedAncestors(t *testing.T) {
+	configProvider, err := config.NewConfigProviderFromData([]byte(`$schema: schema.json
+defaults:
+  network: none
+  nested:
+    kept: true
+clouds:
+  public:
+    defaults:
+      network:
+        cidr: 10.0.0.0/8
+    environments:
+      int:
+        defaults: {}
+`), ".")
+	require.NoError(t, err)
+	configResolver, err := configProvider.GetResolver(&config.ConfigReplacements{CloudReplacement: "public", EnvironmentReplacement: "int"})
+	require.NoError(t, err)
+
+	report, err := configResolver.ProvenanceReport("uksouth")
+	require.NoError(t, err)
+	expected := []config.LeafProvenance{
+		{
+			Path:     "nested.kept",
+			Value:    true,
+			Layer:    config.ProvenanceLayerDefault,
+			Position: &config.Position{Line: 5, Column: 5},
+		},
+		{
+			Path:     "network.cidr",
+			Value:    "10.0.0.0/8",
+			Layer:    config.ProvenanceLayerCloud,
+			Position: &config.Position{Line: 10, Column: 9},
+			Shadowed: []config.ProvenanceValue{
+				{Layer: config.ProvenanceLayerDefault, Path: "network", Value: "none", Position: &config.Position{Line: 3, Column: 3}},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, report.Leaves, cmpopts.IgnoreUnexported(config.LeafProvenance{})); diff != "" {
+		t.Errorf("provenance mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestProvenanceReportMergeDirectives(t *testing.T) {
+	report, err := mergeDirectivesResolver(t).ProvenanceReport("uksouth")
+	require.NoError(t, err)
+	expected := []config.LeafProvenance{
+		{
+			Path:      "pools",
+			Value:     []any{map[string]any{"name": "system", "size": int64(1)}},
+			Layer:     config.ProvenanceLayerRegion,
+			Position:  &config.Position{Line: 31, Column: 13},
+			Directive: types.PatchMerge,
+			Shadowed: []config.ProvenanceValue{{
+				Layer: config.ProvenanceLayerDefault,
+				Value: []any{
+					map[string]any{"name": "system", "size": int64(3)},
+					map[string]any{"name": "user", "size": int64(5)},
+				},
+				Position: &config.Position{Line: 7, Column: 3},
+			}},
+		},
+		{
+			Path:      "zones",
+			Value:     []any{int64(1)},
+			Layer:     config.ProvenanceLayerRegion,
+			Position:  &config.Position{Line: 28, Column: 13},
+			Directive: types.PatchReplace,
+		},
+	}
+	if diff := cmp.Diff(expected, report.Leaves, cmpopts.IgnoreUnexported(config.LeafProvenance{})); diff != "" {
+		t.Errorf("provenance mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -24,13 +24,60 @@ type configurationOverrides struct {
 	Schema   string              `json:"$schema"`
 	Defaults types.Configuration `json:"defaults"`
 	// key is the cloud alias
-	Overrides map[string]*struct {
-		Defaults types.Configuration `json:"defaults"`
-		// key is the deploy env
-		Overrides map[string]*struct {
-			Defaults types.Configuration `json:"defaults"`
-			// key is the region name
-			Overrides map[string]types.Configuration `json:"regions"`
-		} `json:"environments"`
-	} `json:"clouds"`
+	Overrides map[string]*cloudOverrides `json:"clouds"`
+}
+
+type cloudOverrides struct {
+	Defaults types.Configuration `json:"defaults"`
+	// key is the deploy env
+	Overrides map[string]*environmentOverrides `json:"environments"`
+}
+
+type environmentOverrides struct {
+	Defaults types.Configuration `json:"defaults"`
+	// key is the region name
+	Overrides map[string]types.Configuration `json:"regions"`
+}
+
+// deepCopy copies the configuration, so that changes to the copy are not visible in the original.
+func (c configurationOverrides) deepCopy() configurationOverrides {
+	copied := configurationOverrides{
+		Schema:   c.Schema,
+		Defaults: c.Defaults.DeepCopy(),
+	}
+	if c.Overrides != nil {
+		copied.Overrides = make(map[string]*cloudOverrides, len(c.Overrides))
+		for cloud, cloudCfg := range c.Overrides {
+			copied.Overrides[cloud] = cloudCfg.deepCopy()
+		}
+	}
+	return copied
+}
+
+func (c *cloudOverrides) deepCopy() *cloudOverrides {
+	if c == nil {
+		return nil
+	}
+	copied := &cloudOverrides{Defaults: c.Defaults.DeepCopy()}
+	if c.Overrides != nil {
+		copied.Overrides = make(map[string]*environmentOverrides, len(c.Overrides))
+		for environment, envCfg := range c.Overrides {
+			copied.Overrides[environment] = envCfg.deepCopy()
+		}
+	}
+	return copied
+}
+
+func (c *environmentOverrides) deepCopy() *environmentOverrides {
+	if c == nil {
+		return nil
+	}
+	copied := &environmentOverrides{Defaults: c.Defaults.DeepCopy()}
+	if c.Overrides != nil {
+		copied.Overrides = make(map[string]types.Configuration, len(c.Overrides))
+		for region, regionCfg := range c.Overrides {
+			copied.Overrides[region] = regionCfg.DeepCopy()
+		}
+	}
+	return copied
 }
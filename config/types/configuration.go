@@ -3,10 +3,13 @@ package types
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -97,34 +100,313 @@ func (v Configuration) GetByPath(path string) (any, error) {
 	return current, nil
 }
 
+// DeepCopy copies the configuration, so that changes to the copy are not visible in the original.
+func (v Configuration) DeepCopy() Configuration {
+	if v == nil {
+		return nil
+	}
+	return Configuration(deepCopyValue(map[string]any(v)).(map[string]any))
+}
+
+func deepCopyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, inner := range v {
+			copied[key] = deepCopyValue(inner)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, inner := range v {
+			copied[i] = deepCopyValue(inner)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// Merge directives let a more specific layer of configuration do more than overlay keys on the layers beneath it.
+// A directive is a map holding the PatchDirectiveKey, in place of the value it changes:
+//
+//   - {$patch: delete} removes the key, along with everything the layers beneath set for it.
+//   - {$patch: replace, ...} replaces the map beneath instead of merging into it, with the map of the other keys.
+//     At the top level of a layer, it replaces everything the layers beneath set.
+//   - {$patch: replace, $items: [...]} replaces the list beneath with the items, as a plain list would.
+//   - {$patch: append, $items: [...]} appends the items to the list beneath.
+//   - {$patch: merge, $mergeKey: name, $items: [...]} merges each item into the item of the list beneath with the
+//     same value for the merge key, and appends the items that match none. An item holding {$patch: delete} and the
+//     merge key removes the matching item instead.
+//
+// An explicit null is not a directive: it overrides the value beneath like any other value.
+const (
+	PatchDirectiveKey = "$patch"
+	PatchItemsKey     = "$items"
+	PatchMergeKeyKey  = "$mergeKey"
+)
+
+// PatchDirective names a merge directive.
+type PatchDirective string
+
+const (
+	PatchDelete  PatchDirective = "delete"
+	PatchReplace PatchDirective = "replace"
+	PatchAppend  PatchDirective = "append"
+	PatchMerge   PatchDirective = "merge"
+)
+
+// Directive determines the merge directive that a value holds, if it holds one.
+func Directive(value any) (PatchDirective, bool) {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	directive, ok := m[PatchDirectiveKey].(string)
+	if !ok {
+		return "", false
+	}
+	return PatchDirective(directive), true
+}
+
 // MergeConfiguration returns a new configuration holding keys from base, unless they have been overridden.
+// Merge directives in the override are honored, and are not present in the output. The base is expected to hold
+// no directives - to remove them from a configuration that is not merged onto anything, merge it onto a nil base.
 // This function does not mutate its inputs, but returns a `map[string]any` instead of `types.Configuration`, so
 // if your consumer is sensitive to the distinction, remember to cast the output.
 func MergeConfiguration(base, override Configuration) map[string]any {
-	if base == nil {
-		base = Configuration{}
-	}
-	if override == nil {
-		override = Configuration{}
+	if directive, ok := Directive(map[string]any(override)); ok && directive == PatchReplace {
+		base = nil
 	}
 	output := make(Configuration, len(base))
 	for k, v := range base {
 		output[k] = v
 	}
 	for k, newValue := range override {
-		if baseValue, exists := output[k]; exists {
-			srcMap, srcMapOk := newValue.(map[string]any)
-			dstMap, dstMapOk := baseValue.(map[string]any)
-			if srcMapOk && dstMapOk {
-				newValue = MergeConfiguration(dstMap, srcMap)
-			}
+		if k == PatchDirectiveKey {
+			continue
 		}
-		output[k] = newValue
+		baseValue, exists := output[k]
+		merged, keep := mergeValue(baseValue, exists, newValue)
+		if !keep {
+			delete(output, k)
+			continue
+		}
+		output[k] = merged
 	}
 
 	return output
 }
 
+// mergeValue merges the override onto the base value, if there is one, honoring any directive the override holds.
+// The value is removed when keep is false.
+func mergeValue(base any, exists bool, override any) (merged any, keep bool) {
+	directive, ok := Directive(override)
+	if !ok {
+		overrideMap, ok := override.(map[string]any)
+		if !ok {
+			return override, true
+		}
+		baseMap, _ := base.(map[string]any)
+		return MergeConfiguration(baseMap, overrideMap), true
+	}
+
+	directiveMap := override.(map[string]any)
+	items, isList := directiveMap[PatchItemsKey].([]any)
+	var baseList []any
+	if exists {
+		baseList, _ = base.([]any)
+	}
+	switch directive {
+	case PatchDelete:
+		return nil, false
+	case PatchReplace:
+		if isList {
+			return normalizeItems(items), true
+		}
+		return MergeConfiguration(nil, directiveMap), true
+	case PatchAppend:
+		return append(slices.Clone(baseList), normalizeItems(items)...), true
+	case PatchMerge:
+		mergeKey, _ := directiveMap[PatchMergeKeyKey].(string)
+		return mergeItemsByKey(baseList, mergeKey, items), true
+	default:
+		// ValidateMergeDirectives rejects unknown directives, so we only get here with unvalidated input
+		return override, true
+	}
+}
+
+// mergeItemsByKey merges each item into the base item with the same value for the merge key, removes the base item
+// when the item is a delete directive, and appends items that match no base item.
+func mergeItemsByKey(base []any, mergeKey string, items []any) []any {
+	merged := slices.Clone(base)
+	for _, item := range items {
+		itemMap, isMap := item.(map[string]any)
+		index := -1
+		if key, hasKey := itemMap[mergeKey]; isMap && hasKey {
+			index = slices.IndexFunc(merged, func(candidate any) bool {
+				candidateMap, ok := candidate.(map[string]any)
+				if !ok {
+					return false
+				}
+				candidateKey, ok := candidateMap[mergeKey]
+				return ok && reflect.DeepEqual(candidateKey, key)
+			})
+		}
+		if directive, ok := Directive(item); ok && directive == PatchDelete {
+			if index >= 0 {
+				merged = slices.Delete(merged, index, index+1)
+			}
+			continue
+		}
+		if index >= 0 {
+			merged[index] = MergeConfiguration(merged[index].(map[string]any), itemMap)
+			continue
+		}
+		merged = append(merged, normalizeItems([]any{item})...)
+	}
+	return merged
+}
+
+// normalizeItems removes merge directives from maps in the list, as there is nothing beneath them to merge onto.
+func normalizeItems(items []any) []any {
+	normalized := make([]any, 0, len(items))
+	for _, item := range items {
+		if itemMap, ok := item.(map[string]any); ok {
+			item = MergeConfiguration(nil, itemMap)
+		}
+		normalized = append(normalized, item)
+	}
+	return normalized
+}
+
+// ValidateMergeDirectives checks that every merge directive in a layer of configuration is well-formed, so that a
+// mistake is reported rather than silently changing how the configuration resolves.
+func ValidateMergeDirectives(cfg Configuration) error {
+	return errors.Join(validateDirectives(cfg, "", directiveAtTopLevel, "")...)
+}
+
+// directiveContext records where a map holding a directive is found, as that determines which directives make sense.
+type directiveContext int
+
+const (
+	directiveAtTopLevel directiveContext = iota
+	directiveAtValue
+	directiveAtItem
+	directiveAtMergeItem
+)
+
+func validateDirectives(m map[string]any, path string, context directiveContext, mergeKey string) []error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("configuration%s: %s", path, fmt.Sprintf(format, args...))
+	}
+	var errs []error
+	rawDirective, hasDirective := m[PatchDirectiveKey]
+	_, hasItems := m[PatchItemsKey]
+	_, hasMergeKey := m[PatchMergeKeyKey]
+	nestedFrom := m
+	if hasDirective {
+		directive, ok := rawDirective.(string)
+		if !ok {
+			return []error{invalid("%s must be a string, found %T", PatchDirectiveKey, rawDirective)}
+		}
+		onlyKeys := func(allowed ...string) {
+			for _, key := range sets.List(sets.KeySet(m).Delete(PatchDirectiveKey).Delete(allowed...)) {
+				errs = append(errs, invalid("%s: %s must not be combined with %s", directive, PatchDirectiveKey, key))
+			}
+		}
+		items := func() []any {
+			items, ok := m[PatchItemsKey].([]any)
+			if !ok {
+				errs = append(errs, invalid("%s: %s must be a list", directive, PatchItemsKey))
+			}
+			return items
+		}
+		switch PatchDirective(directive) {
+		case PatchDelete:
+			switch context {
+			case directiveAtValue:
+				onlyKeys()
+			case directiveAtMergeItem:
+				onlyKeys(mergeKey)
+				if _, ok := m[mergeKey]; !ok {
+					errs = append(errs, invalid("delete: item must hold the merge key %s", mergeKey))
+				}
+			default:
+				errs = append(errs, invalid("delete: there is nothing to delete here"))
+			}
+			return errs
+		case PatchReplace:
+			if !hasItems {
+				if hasMergeKey {
+					errs = append(errs, invalid("replace: %s is only used with %s", PatchMergeKeyKey, PatchMerge))
+				}
+				nestedFrom = make(map[string]any, len(m))
+				for key, value := range m {
+					if key != PatchDirectiveKey && key != PatchMergeKeyKey {
+						nestedFrom[key] = value
+					}
+				}
+				break
+			}
+			if context != directiveAtValue {
+				return append(errs, invalid("replace: lists may only be replaced for a key"))
+			}
+			onlyKeys(PatchItemsKey)
+			for i, item := range items() {
+				errs = append(errs, validateItem(item, fmt.Sprintf("%s[%s][%d]", path, PatchItemsKey, i), directiveAtItem, "")...)
+			}
+			return errs
+		case PatchAppend, PatchMerge:
+			if context != directiveAtValue {
+				return append(errs, invalid("%s: lists may only be merged for a key", directive))
+			}
+			if PatchDirective(directive) == PatchAppend {
+				onlyKeys(PatchItemsKey)
+			} else {
+				onlyKeys(PatchItemsKey, PatchMergeKeyKey)
+				key, ok := m[PatchMergeKeyKey].(string)
+				if !ok || key == "" {
+					errs = append(errs, invalid("merge: %s must be a non-empty string", PatchMergeKeyKey))
+				}
+				mergeKey = key
+			}
+			itemContext := directiveAtItem
+			if PatchDirective(directive) == PatchMerge {
+				itemContext = directiveAtMergeItem
+			}
+			for i, item := range items() {
+				errs = append(errs, validateItem(item, fmt.Sprintf("%s[%s][%d]", path, PatchItemsKey, i), itemContext, mergeKey)...)
+			}
+			return errs
+		default:
+			return []error{invalid("unknown merge directive %q, expected one of %s, %s, %s or %s", directive, PatchDelete, PatchReplace, PatchAppend, PatchMerge)}
+		}
+	} else {
+		if hasItems {
+			errs = append(errs, invalid("%s must be used with %s", PatchItemsKey, PatchDirectiveKey))
+		}
+		if hasMergeKey {
+			errs = append(errs, invalid("%s must be used with %s", PatchMergeKeyKey, PatchDirectiveKey))
+		}
+	}
+
+	for _, key := range sets.List(sets.KeySet(nestedFrom)) {
+		if nested, ok := nestedFrom[key].(map[string]any); ok {
+			errs = append(errs, validateDirectives(nested, path+"["+key+"]", directiveAtValue, "")...)
+		}
+	}
+	return errs
+}
+
+func validateItem(item any, path string, context directiveContext, mergeKey string) []error {
+	itemMap, ok := item.(map[string]any)
+	if !ok {
+		return nil
+	}
+	return validateDirectives(itemMap, path, context, mergeKey)
+}
+
 // resolveSchemaPath resolves a schema path for a new file location while preserving whether it's relative or absolute.
 // - if the schema path is already absolute, it returns it as is
 // - if the schema path is relative, it computes a new relative path from the target file to the schema
@@ -183,7 +465,7 @@ func MergeRawConfigurationFiles(schemaLocationRebaseReference string, configFile
 				return nil, fmt.Errorf("$schema in configuration file %q is not a string", configFile)
 			}
 		}
-		rawMerged = MergeConfiguration(rawMerged, rawConfig)
+		rawMerged = overlayConfiguration(rawMerged, rawConfig)
 	}
 	if targetFileSchemaPath != "" {
 		rawMerged["$schema"] = targetFileSchemaPath
@@ -202,6 +484,27 @@ func MergeRawConfigurationFiles(schemaLocationRebaseReference string, configFile
 	return unwrappedYaml, nil
 }
 
+// overlayConfiguration merges configuration files like MergeConfiguration, but keeps merge directives for the
+// resolver to honor, as they describe how a layer merges with the layers beneath it rather than with earlier files.
+// A directive replaces whatever earlier files set for the same key.
+func overlayConfiguration(base, override Configuration) map[string]any {
+	output := make(Configuration, len(base))
+	for k, v := range base {
+		output[k] = v
+	}
+	for k, newValue := range override {
+		if _, isDirective := Directive(newValue); !isDirective {
+			srcMap, srcMapOk := newValue.(map[string]any)
+			dstMap, dstMapOk := output[k].(map[string]any)
+			if srcMapOk && dstMapOk {
+				newValue = overlayConfiguration(dstMap, srcMap)
+			}
+		}
+		output[k] = newValue
+	}
+	return output
+}
+
 // readAndWrapRawConfig reads a YAML file with Go template placeholders by wrapping it
 // with yamlwrapper to make template syntax valid YAML, then parses it into a Configuration.
 func readAndWrapRawConfig(filePath string) (Configuration, error) {
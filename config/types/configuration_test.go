@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"text/template"
 
@@ -180,3 +181,97 @@ func TestResolveSchemaPath(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateMergeDirectives(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		cfg      Configuration
+		expected string
+	}{
+		{
+			name: "valid directives",
+			cfg: Configuration{
+				"$patch":  "replace",
+				"deleted": map[string]any{"$patch": "delete"},
+				"replaced": map[string]any{
+					"$patch": "replace",
+					"nested": map[string]any{"$patch": "append", "$items": []any{1}},
+				},
+				"list": map[string]any{"$patch": "replace", "$items": []any{map[string]any{"key": "value"}}},
+				"merged": map[string]any{"$patch": "merge", "$mergeKey": "name", "$items": []any{
+					map[string]any{"name": "a", "$patch": "delete"},
+					map[string]any{"name": "b", "value": map[string]any{"$patch": "replace"}},
+				}},
+			},
+		},
+		{
+			name:     "unknown directive",
+			cfg:      Configuration{"key": map[string]any{"nested": map[string]any{"$patch": "prepend"}}},
+			expected: `configuration[key][nested]: unknown merge directive "prepend", expected one of delete, replace, append or merge`,
+		},
+		{
+			name:     "directive must be a string",
+			cfg:      Configuration{"key": map[string]any{"$patch": true}},
+			expected: "configuration[key]: $patch must be a string, found bool",
+		},
+		{
+			name:     "delete combined with other keys",
+			cfg:      Configuration{"key": map[string]any{"$patch": "delete", "value": 1}},
+			expected: "configuration[key]: delete: $patch must not be combined with value",
+		},
+		{
+			name:     "delete at the top level",
+			cfg:      Configuration{"$patch": "delete"},
+			expected: "configuration: delete: there is nothing to delete here",
+		},
+		{
+			name:     "append without items",
+			cfg:      Configuration{"key": map[string]any{"$patch": "append"}},
+			expected: "configuration[key]: append: $items must be a list",
+		},
+		{
+			name:     "merge without merge key",
+			cfg:      Configuration{"key": map[string]any{"$patch": "merge", "$items": []any{}}},
+			expected: "configuration[key]: merge: $mergeKey must be a non-empty string",
+		},
+		{
+			name: "merge item deleted without merge key",
+			cfg: Configuration{"key": map[string]any{"$patch": "merge", "$mergeKey": "name", "$items": []any{
+				map[string]any{"$patch": "delete"},
+			}}},
+			expected: "configuration[key][$items][0]: delete: item must hold the merge key name",
+		},
+		{
+			name:     "items without directive",
+			cfg:      Configuration{"key": map[string]any{"$items": []any{}}},
+			expected: "configuration[key]: $items must be used with $patch",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := ValidateMergeDirectives(testCase.cfg)
+			if testCase.expected == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != testCase.expected {
+				t.Errorf("expected error %q, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+func TestOverlayConfigurationKeepsDirectives(t *testing.T) {
+	output := overlayConfiguration(
+		Configuration{"tags": map[string]any{"owner": "aro"}, "zones": []any{1}},
+		Configuration{"tags": map[string]any{"$patch": "delete"}, "zones": map[string]any{"$patch": "append", "$items": []any{2}}},
+	)
+	expected := map[string]any{
+		"tags":  map[string]any{"$patch": "delete"},
+		"zones": map[string]any{"$patch": "append", "$items": []any{2}},
+	}
+	if !reflect.DeepEqual(expected, output) {
+		t.Errorf("expected %v, got %v", expected, output)
+	}
+}
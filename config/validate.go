@@ -17,6 +17,7 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strings"
 	"text/template"
 	"text/template/parse"
@@ -154,3 +155,96 @@ func posToLine(content []byte, pos parse.Pos) int {
 	offset := max(0, min(int(pos), len(content)))
 	return 1 + bytes.Count(content[:offset], []byte{'\n'})
 }
+
+// TemplateFieldReferences parses content as a Go template and lists the fields it accesses from the data it is
+// executed with, as dot-separated paths ({{ .foo.bar }} and {{ $.foo.bar }} access foo.bar), in sorted order without
+// duplicates. Fields are found in any action, including in conditionals and function arguments. Inside range and with
+// blocks, dot is rebound to something other than the data the template is executed with, so only fields accessed
+// through $ are found there.
+func TemplateFieldReferences(content []byte) ([]string, error) {
+	tmpl, err := template.New("").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	references := map[string]struct{}{}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && t.Root != nil {
+			collectFieldReferences(t.Root, true, references)
+		}
+	}
+	paths := make([]string, 0, len(references))
+	for path := range references {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// collectFieldReferences records the fields accessed by node. When rooted, dot refers to the data the template is
+// executed with; otherwise only fields accessed through $ are recorded.
+func collectFieldReferences(node parse.Node, rooted bool, references map[string]struct{}) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectFieldReferences(child, rooted, references)
+		}
+	case *parse.ActionNode:
+		collectFieldReferences(n.Pipe, rooted, references)
+	case *parse.IfNode:
+		collectFieldReferences(n.Pipe, rooted, references)
+		collectFieldReferences(n.List, rooted, references)
+		collectFieldReferences(n.ElseList, rooted, references)
+	case *parse.RangeNode:
+		collectFieldReferences(n.Pipe, rooted, references)
+		collectFieldReferences(n.List, false, references)
+		collectFieldReferences(n.ElseList, rooted, references)
+	case *parse.WithNode:
+		collectFieldReferences(n.Pipe, rooted, references)
+		collectFieldReferences(n.List, false, references)
+		collectFieldReferences(n.ElseList, rooted, references)
+	case *parse.TemplateNode:
+		collectFieldReferences(n.Pipe, rooted, references)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectFieldReferences(cmd, rooted, references)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectFieldReferences(arg, rooted, references)
+		}
+	case *parse.FieldNode, *parse.VariableNode:
+		if path, ok := fieldPath(n, rooted); ok {
+			references[strings.Join(path, ".")] = struct{}{}
+		}
+	case *parse.ChainNode:
+		if path, ok := fieldPath(n.Node, rooted); ok {
+			references[strings.Join(append(path, n.Field...), ".")] = struct{}{}
+		} else {
+			collectFieldReferences(n.Node, rooted, references)
+		}
+	}
+}
+
+// fieldPath determines the path of the field that node accesses from the data the template is executed with, if any.
+func fieldPath(node parse.Node, rooted bool) ([]string, bool) {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		return slices.Clone(n.Ident), rooted
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			return slices.Clone(n.Ident[1:]), true
+		}
+	case *parse.PipeNode:
+		if len(n.Decl) == 0 && len(n.Cmds) == 1 && len(n.Cmds[0].Args) == 1 {
+			return fieldPath(n.Cmds[0].Args[0], rooted)
+		}
+	}
+	return nil, false
+}
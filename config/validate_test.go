@@ -201,3 +201,26 @@ func TestValidateSimpleFieldAccess(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateFieldReferences(t *testing.T) {
+	references, err := config.TemplateFieldReferences([]byte(`name: {{ .svc.name }}
+{{- if .svc.enabled }}
+replicas: {{ printf "%d" .svc.replicas }}
+{{- end }}
+{{- range .svc.zones }}
+zone: {{ .name }}
+region: {{ $.region.name }}
+{{- end }}
+{{- with .svc.owner }}
+owner: {{ .email }} in {{ $.tenant }}
+{{- end }}
+again: {{ .svc.name }}
+chained: {{ (.svc.image).digest }}
+rooted: {{ $.svc.port }}
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"region.name", "svc.enabled", "svc.image.digest", "svc.name", "svc.owner", "svc.port", "svc.replicas", "svc.zones", "tenant"}, references)
+
+	_, err = config.TemplateFieldReferences([]byte(`{{ .unterminated`))
+	require.Error(t, err)
+}
@@ -0,0 +1,368 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configlint
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/config/ev2config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/verify"
+	"github.com/Azure/ARO-Tools/tools/yamlwrap"
+)
+
+// FindingKind names the kind of problem a Finding records.
+type FindingKind string
+
+const (
+	// FindingKindUnusedKey records a key in the configuration that no pipeline or template references.
+	FindingKindUnusedKey FindingKind = "UnusedKey"
+	// FindingKindUndeclaredKey records a reference to a key that the configuration does not declare in any context.
+	FindingKindUndeclaredKey FindingKind = "UndeclaredKey"
+	// FindingKindRedundantOverride records an override that sets the value the layers beneath it already resolve to.
+	FindingKindRedundantOverride FindingKind = "RedundantOverride"
+	// FindingKindUnknownRegion records region overrides for a region that Ev2 has no record of.
+	FindingKindUnknownRegion FindingKind = "UnknownRegion"
+)
+
+// Finding records one problem found while linting the configuration.
+type Finding struct {
+	Kind FindingKind `json:"kind"`
+	// Location is the dot-separated path to the key in the configuration that the finding concerns, or the file with
+	// the reference for undeclared keys.
+	Location string `json:"location"`
+	// Position is where the key is set in the configuration file, when it is known.
+	Position *config.Position `json:"position,omitempty"`
+	Message  string           `json:"message"`
+}
+
+func (f Finding) String() string {
+	location := f.Location
+	if f.Position != nil {
+		location += " (" + f.Position.String() + ")"
+	}
+	return fmt.Sprintf("%s: %s [%s]", location, f.Message, f.Kind)
+}
+
+// Report records every finding, ordered by kind and location.
+type Report struct {
+	Findings []Finding `json:"findings"`
+}
+
+// String renders the report for humans, one finding per line.
+func (r *Report) String() string {
+	var out strings.Builder
+	for _, finding := range r.Findings {
+		fmt.Fprintf(&out, "%s\n", finding)
+	}
+	return out.String()
+}
+
+// LintOptions configure how configuration is linted.
+type LintOptions struct {
+	// Contexts limits linting to these contexts. If unset, every context the provider has records for is linted.
+	Contexts []verify.Context
+	// Replacements determines how configuration is resolved for each context. If unset, verify.DefaultReplacements
+	// is used.
+	Replacements verify.Replacements
+	// TemplatePaths lists other files that are templated with the configuration, whose field accesses are references
+	// to the configuration just like those in pipelines.
+	TemplatePaths []string
+}
+
+// Lint finds problems in the configuration by comparing it to the pipelines in the topology and to itself:
+//   - keys that no pipeline references, either with a configRef or by accessing them from the pipeline template;
+//   - references in pipelines to keys that the configuration declares in no context;
+//   - overrides that set a key to the value the layers beneath already resolve to, as found by the provenance of the
+//     configuration in each context;
+//   - region overrides for regions that the embedded Ev2 configuration has no record of.
+//
+// A key is only unused if it is unused in every context, and a reference is only undeclared if the key is missing
+// from every context. An error is returned if a pipeline can't be read, or configuration can't be resolved.
+func Lint(topo *topology.CombinedTopology, provider config.ConfigProvider, opts LintOptions) (*Report, error) {
+	references, err := topologyReferences(topo, opts.TemplatePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := opts.Contexts
+	if len(contexts) == 0 {
+		contexts = verify.Contexts(provider)
+	}
+	replacements := opts.Replacements
+	if replacements == nil {
+		replacements = verify.DefaultReplacements
+	}
+	ev2Contexts, err := ev2config.AllContexts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine Ev2 contexts: %w", err)
+	}
+
+	findings := map[string]Finding{}
+	add := func(finding Finding) {
+		findings[strings.Join([]string{string(finding.Kind), finding.Location, finding.Message}, "\x00")] = finding
+	}
+	declared := types2.Configuration{}
+	for _, context := range contexts {
+		regions, knownCloud := ev2Contexts[context.Cloud]
+		if !slices.Contains(regions, context.Region) {
+			finding := Finding{Kind: FindingKindUnknownRegion, Location: regionLocation(context)}
+			if knownCloud {
+				finding.Message = fmt.Sprintf("region %s is not a region of cloud %s in the Ev2 configuration", context.Region, context.Cloud)
+			} else {
+				finding.Message = fmt.Sprintf("cloud %s is not in the Ev2 configuration", context.Cloud)
+			}
+			add(finding)
+		}
+
+		resolved, report, err := resolve(provider, context, replacements)
+		if err != nil {
+			return nil, err
+		}
+		for _, finding := range redundantOverrides(report, context) {
+			add(finding)
+		}
+		declared = types2.MergeConfiguration(declared, resolved)
+	}
+
+	for _, finding := range unusedKeys(declared, "", references) {
+		add(finding)
+	}
+	for _, reference := range sortedKeys(references) {
+		if _, err := declared.GetByPath(reference); err == nil {
+			continue
+		}
+		for _, file := range references[reference] {
+			add(Finding{
+				Kind:     FindingKindUndeclaredKey,
+				Location: file,
+				Message:  fmt.Sprintf("references %s, which the configuration does not declare in any context", reference),
+			})
+		}
+	}
+
+	report := &Report{}
+	for _, key := range sortedKeys(findings) {
+		report.Findings = append(report.Findings, findings[key])
+	}
+	return report, nil
+}
+
+// resolve resolves the configuration for the context, along with its provenance.
+func resolve(provider config.ConfigProvider, context verify.Context, replacements verify.Replacements) (types2.Configuration, *config.ProvenanceReport, error) {
+	configReplacements, err := replacements(context)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to determine configuration replacements: %w", context, err)
+	}
+	resolver, err := provider.GetResolver(configReplacements)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to get configuration resolver: %w", context, err)
+	}
+	resolved, err := resolver.GetRegionConfiguration(context.Region)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to resolve region configuration: %w", context, err)
+	}
+	report, err := resolver.ProvenanceReport(context.Region)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to determine configuration provenance: %w", context, err)
+	}
+	return resolved, report, nil
+}
+
+// topologyReferences finds the configuration keys that every pipeline in the topology and every other template
+// references, recording the files that reference each key.
+func topologyReferences(topo *topology.CombinedTopology, templatePaths []string) (map[string][]string, error) {
+	var paths []string
+	if err := topo.Walk(func(service *topology.Service, _ bool) error {
+		path, err := verify.PipelinePath(topo, service)
+		if err != nil {
+			return fmt.Errorf("service group %s: %w", service.ServiceGroup, err)
+		}
+		paths = append(paths, path)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	references := map[string][]string{}
+	for _, path := range append(paths, templatePaths...) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		fields, err := config.TemplateFieldReferences(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find references in %s: %w", path, err)
+		}
+		if slices.Contains(paths, path) {
+			configRefs, err := configReferences(content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find references in %s: %w", path, err)
+			}
+			fields = append(fields, configRefs...)
+		}
+		for _, field := range fields {
+			if !slices.Contains(references[field], path) {
+				references[field] = append(references[field], path)
+			}
+		}
+	}
+	return references, nil
+}
+
+// configReferences finds the keys that a pipeline references with configRef. The pipeline is parsed without being
+// templated, so that references are found regardless of the context.
+func configReferences(content []byte) ([]string, error) {
+	wrapped, err := yamlwrap.WrapYAML(content, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap templates: %w", err)
+	}
+	var document any
+	if err := yaml.Unmarshal(wrapped, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pipeline: %w", err)
+	}
+	var references []string
+	var find func(node any)
+	find = func(node any) {
+		switch n := node.(type) {
+		case map[string]any:
+			if ref, ok := n["configRef"].(string); ok && ref != "" {
+				references = append(references, ref)
+			}
+			for _, value := range n {
+				find(value)
+			}
+		case []any:
+			for _, value := range n {
+				find(value)
+			}
+		}
+	}
+	find(document)
+	return references, nil
+}
+
+// unusedKeys finds the keys in the configuration that nothing references. Only the outermost unused key is reported,
+// rather than every key beneath it.
+func unusedKeys(cfg map[string]any, parent string, references map[string][]string) []Finding {
+	var findings []Finding
+	for _, key := range sortedKeys(cfg) {
+		path := config.JoinLocation(parent, key)
+		if referenced(path, references) {
+			continue
+		}
+		if nested, ok := cfg[key].(map[string]any); ok && referencedBeneath(path, references) {
+			findings = append(findings, unusedKeys(nested, path, references)...)
+			continue
+		}
+		findings = append(findings, Finding{
+			Kind:     FindingKindUnusedKey,
+			Location: path,
+			Message:  "no pipeline or template references this key",
+		})
+	}
+	return findings
+}
+
+// referenced determines whether the path, or any of its ancestors, is referenced.
+func referenced(path string, references map[string][]string) bool {
+	for {
+		if _, ok := references[path]; ok {
+			return true
+		}
+		index := strings.LastIndex(path, ".")
+		if index < 0 {
+			return false
+		}
+		path = path[:index]
+	}
+}
+
+// referencedBeneath determines whether any key beneath the path is referenced.
+func referencedBeneath(path string, references map[string][]string) bool {
+	for reference := range references {
+		if strings.HasPrefix(reference, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// redundantOverrides finds the layers that set a leaf to the value that the next less specific layer set it to.
+// Directives and values set on an ancestor of the leaf are not compared, as they don't set the leaf itself.
+func redundantOverrides(report *config.ProvenanceReport, context verify.Context) []Finding {
+	var findings []Finding
+	for _, leaf := range report.Leaves {
+		values := append([]config.ProvenanceValue{{Layer: leaf.Layer, Value: leaf.Value, Directive: leaf.Directive, Position: leaf.Position}}, leaf.Shadowed...)
+		for i := 0; i+1 < len(values); i++ {
+			override, beneath := values[i], values[i+1]
+			if override.Path != "" || beneath.Path != "" || override.Directive != "" || beneath.Directive != "" {
+				continue
+			}
+			if !reflect.DeepEqual(override.Value, beneath.Value) {
+				continue
+			}
+			message := fmt.Sprintf("sets %s to the value already set by the %s layer", leaf.Path, beneath.Layer)
+			if beneath.Position != nil {
+				message += " at " + beneath.Position.String()
+			}
+			findings = append(findings, Finding{
+				Kind:     FindingKindRedundantOverride,
+				Location: config.JoinLocation(layerLocation(override.Layer, context), leaf.Path),
+				Position: override.Position,
+				Message:  message,
+			})
+		}
+	}
+	return findings
+}
+
+// layerLocation determines where a layer of the configuration is in the file for the context.
+func layerLocation(layer config.ProvenanceLayer, context verify.Context) string {
+	cloud := config.JoinLocation("clouds", context.Cloud)
+	environment := config.JoinLocation(cloud, config.JoinLocation("environments", context.Environment))
+	switch layer {
+	case config.ProvenanceLayerCloud:
+		return config.JoinLocation(cloud, "defaults")
+	case config.ProvenanceLayerEnvironment:
+		return config.JoinLocation(environment, "defaults")
+	case config.ProvenanceLayerRegion:
+		return regionLocation(context)
+	default:
+		return "defaults"
+	}
+}
+
+func regionLocation(context verify.Context) string {
+	environment := config.JoinLocation(config.JoinLocation("clouds", context.Cloud), config.JoinLocation("environments", context.Environment))
+	return config.JoinLocation(config.JoinLocation(environment, "regions"), context.Region)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configlint
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/verify"
+)
+
+func TestLint(t *testing.T) {
+	topo, err := topology.LoadCombined([]string{"testdata/topology.yaml"})
+	require.NoError(t, err)
+	provider, err := config.NewConfigProvider("testdata/config.yaml")
+	require.NoError(t, err)
+
+	report, err := Lint(topo, provider, LintOptions{TemplatePaths: []string{"testdata/values.yaml"}})
+	require.NoError(t, err)
+	assert.Equal(t, `clouds.public.defaults.svc.replicas (testdata/config.yaml:17:9): sets svc.replicas to the value already set by the default layer at testdata/config.yaml:10:5 [RedundantOverride]
+clouds.public.environments.int.regions.uksouth.svc.subscription (testdata/config.yaml:26:15): sets svc.subscription to the value already set by the environment layer at testdata/config.yaml:22:13 [RedundantOverride]
+testdata/pipeline.yaml: references svc.missing, which the configuration does not declare in any context [UndeclaredKey]
+clouds.public.environments.int.regions.moon: region moon is not a region of cloud public in the Ev2 configuration [UnknownRegion]
+image.digest: no pipeline or template references this key [UnusedKey]
+unused: no pipeline or template references this key [UnusedKey]
+`, report.String())
+
+	// without the values template, nothing references the image
+	report, err = Lint(topo, provider, LintOptions{Contexts: []verify.Context{{Cloud: "public", Environment: "int", Region: "uksouth"}}})
+	require.NoError(t, err)
+	var unused []string
+	for _, finding := range report.Findings {
+		if finding.Kind == FindingKindUnusedKey {
+			unused = append(unused, finding.Location)
+		}
+		assert.NotEqual(t, FindingKindUnknownRegion, finding.Kind, "regions outside the contexts linted must not be reported")
+	}
+	assert.Equal(t, []string{"image", "unused"}, unused)
+}
+
+func TestLintCommand(t *testing.T) {
+	opts := DefaultOptions()
+	opts.TopologyPaths = []string{"testdata/topology.yaml"}
+	opts.ConfigPath = "testdata/config.yaml"
+	opts.Region = "uksouth"
+	opts.Format = FormatJSON
+	validated, err := opts.Validate()
+	require.NoError(t, err)
+	completed, err := validated.Complete()
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.EqualError(t, completed.Lint(&out), "found 5 configuration lint findings")
+	var report Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Len(t, report.Findings, 5)
+
+	opts.Format = "yaml"
+	_, err = opts.Validate()
+	assert.ErrorContains(t, err, `invalid --format "yaml"`)
+}
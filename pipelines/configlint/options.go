@@ -0,0 +1,181 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/verify"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var formats = []string{FormatText, FormatJSON}
+
+func DefaultOptions() *RawOptions {
+	return &RawOptions{
+		Format: FormatText,
+	}
+}
+
+func BindOptions(opts *RawOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringSliceVar(&opts.TopologyPaths, "topology", opts.TopologyPaths, "Path to a topology file. May be provided more than once to combine topologies.")
+	cmd.Flags().StringVar(&opts.ConfigPath, "config", opts.ConfigPath, "Path to the service configuration file.")
+	cmd.Flags().StringSliceVar(&opts.TemplatePaths, "template", opts.TemplatePaths, "Path to another file templated with the configuration, whose references count as uses. May be provided more than once.")
+	cmd.Flags().StringVar(&opts.Cloud, "cloud", opts.Cloud, "Only lint contexts in this cloud.")
+	cmd.Flags().StringVar(&opts.Environment, "environment", opts.Environment, "Only lint contexts in this environment.")
+	cmd.Flags().StringVar(&opts.Region, "region", opts.Region, "Only lint contexts in this region.")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, fmt.Sprintf("Output format, one of %v.", formats))
+
+	for _, flag := range []string{
+		"topology",
+		"config",
+		"template",
+	} {
+		if err := cmd.MarkFlagFilename(flag); err != nil {
+			return fmt.Errorf("failed to mark flag %q as a file: %w", flag, err)
+		}
+	}
+	return nil
+}
+
+// RawOptions holds input values.
+type RawOptions struct {
+	TopologyPaths []string
+	ConfigPath    string
+	TemplatePaths []string
+	Cloud         string
+	Environment   string
+	Region        string
+	Format        string
+}
+
+// validatedOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedOptions struct {
+	TopologyPaths []string
+	ConfigPath    string
+	TemplatePaths []string
+	Cloud         string
+	Environment   string
+	Region        string
+	Format        string
+}
+
+type ValidatedOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedOptions
+}
+
+// completedOptions is a private wrapper that enforces a call of Complete() before linting can be invoked.
+type completedOptions struct {
+	Topology    *topology.CombinedTopology
+	Provider    config.ConfigProvider
+	LintOptions LintOptions
+	Format      string
+}
+
+type Options struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedOptions
+}
+
+func (o *RawOptions) Validate() (*ValidatedOptions, error) {
+	if len(o.TopologyPaths) == 0 {
+		return nil, fmt.Errorf("at least one topology file must be provided with --topology")
+	}
+	if o.ConfigPath == "" {
+		return nil, fmt.Errorf("the service configuration file must be provided with --config")
+	}
+	if !slices.Contains(formats, o.Format) {
+		return nil, fmt.Errorf("invalid --format %q, must be one of %v", o.Format, formats)
+	}
+
+	return &ValidatedOptions{
+		validatedOptions: &validatedOptions{
+			TopologyPaths: o.TopologyPaths,
+			ConfigPath:    o.ConfigPath,
+			TemplatePaths: o.TemplatePaths,
+			Cloud:         o.Cloud,
+			Environment:   o.Environment,
+			Region:        o.Region,
+			Format:        o.Format,
+		},
+	}, nil
+}
+
+func (o *ValidatedOptions) Complete() (*Options, error) {
+	topo, err := topology.LoadCombined(o.TopologyPaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load topology: %w", err)
+	}
+	provider, err := config.NewConfigProvider(o.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service configuration: %w", err)
+	}
+
+	contexts := slices.DeleteFunc(verify.Contexts(provider), func(c verify.Context) bool {
+		return o.Cloud != "" && c.Cloud != o.Cloud ||
+			o.Environment != "" && c.Environment != o.Environment ||
+			o.Region != "" && c.Region != o.Region
+	})
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("no contexts in %s match the --cloud, --environment and --region provided", o.ConfigPath)
+	}
+
+	return &Options{
+		completedOptions: &completedOptions{
+			Topology:    topo,
+			Provider:    provider,
+			LintOptions: LintOptions{Contexts: contexts, TemplatePaths: o.TemplatePaths},
+			Format:      o.Format,
+		},
+	}, nil
+}
+
+// Lint lints the configuration, writing the findings to out in the chosen format. An error is returned if anything
+// was found.
+func (opts *Options) Lint(out io.Writer) error {
+	report, err := Lint(opts.Topology, opts.Provider, opts.LintOptions)
+	if err != nil {
+		return err
+	}
+
+	var encoded []byte
+	switch opts.Format {
+	case FormatJSON:
+		if encoded, err = json.MarshalIndent(report, "", "  "); err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+	default:
+		encoded = []byte(report.String())
+	}
+	if _, err := out.Write(encoded); err != nil {
+		return fmt.Errorf("failed to write lint results: %w", err)
+	}
+	if len(report.Findings) > 0 {
+		return fmt.Errorf("found %d configuration lint findings", len(report.Findings))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// FakeARMRunner stands in for ARM and ARMStack deployments without deploying anything. It resolves the variables of
+// each step, so that missing inputs and configuration are caught, and returns canned outputs.
+type FakeARMRunner struct {
+	// Outputs holds the outputs returned for steps, by step. Steps without recorded outputs return a placeholder for
+	// every output they declare: the step and output name for strings, and the zero value for other types.
+	Outputs map[types.StepDependency]Outputs
+}
+
+func (r *FakeARMRunner) Run(_ context.Context, step types.Step, stepCtx *StepContext) (Outputs, error) {
+	var variables []types.Variable
+	switch s := step.(type) {
+	case *types.ARMStep:
+		variables = s.Variables
+	case *types.ARMStackStep:
+		variables = s.Variables
+	default:
+		return nil, fmt.Errorf("fake ARM runner can't run %T", step)
+	}
+	if _, err := stepCtx.ResolveVariables(variables); err != nil {
+		return nil, err
+	}
+
+	if outputs, recorded := r.Outputs[stepCtx.Identifier.StepDependency]; recorded {
+		return maps.Clone(outputs), nil
+	}
+	outputs := Outputs{}
+	if declarer, ok := step.(types.OutputDeclarer); ok {
+		for _, output := range declarer.DeclaredOutputs() {
+			outputs[output.Name] = placeholder(stepCtx.Identifier.StepDependency, output)
+		}
+	}
+	return outputs, nil
+}
+
+// placeholder fabricates a value for an output, of the kind the output holds.
+func placeholder(step types.StepDependency, output types.Output) any {
+	switch output.Type.Kind() {
+	case types.ValueKindNumber:
+		return 0
+	case types.ValueKindBool:
+		return false
+	case types.ValueKindList:
+		return []any{}
+	case types.ValueKindMap:
+		return map[string]any{}
+	default:
+		return fmt.Sprintf("%s/%s.%s", step.ResourceGroup, step.Step, output.Name)
+	}
+}
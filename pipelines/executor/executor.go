@@ -0,0 +1,252 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	types2 "github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/pipelines/graph"
+	"github.com/Azure/ARO-Tools/pipelines/retry"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// Option customizes an Executor.
+type Option func(*Executor)
+
+// WithRunner registers the runner for steps with the action, replacing any runner registered before. A nil runner
+// unregisters the action, so that graphs with steps of that action are rejected.
+func WithRunner(action string, runner StepRunner) Option {
+	return func(e *Executor) {
+		e.runners[action] = runner
+	}
+}
+
+// WithParallelism bounds the number of steps that run at once. Values below one are ignored.
+func WithParallelism(parallelism int) Option {
+	return func(e *Executor) {
+		if parallelism > 0 {
+			e.parallelism = parallelism
+		}
+	}
+}
+
+// WithConfiguration sets the configuration from which configuration references in steps are resolved.
+func WithConfiguration(cfg types2.Configuration) Option {
+	return func(e *Executor) {
+		e.config = cfg
+	}
+}
+
+// WithRetrySleep replaces how the executor waits between automated retries of a step.
+func WithRetrySleep(sleep func(ctx context.Context, d time.Duration) error) Option {
+	return func(e *Executor) {
+		e.retrySleep = sleep
+	}
+}
+
+// Executor runs the steps of a graph locally, dispatching each one to the runner registered for its action.
+type Executor struct {
+	runners     map[string]StepRunner
+	parallelism int
+	config      types2.Configuration
+	retrySleep  func(ctx context.Context, d time.Duration) error
+}
+
+// New creates an executor. Shell steps run with a ShellRunner rooted in the working directory and ARM and ARMStack
+// steps run with a FakeARMRunner, unless other runners are registered for them.
+func New(opts ...Option) *Executor {
+	fakeARM := &FakeARMRunner{}
+	e := &Executor{
+		runners: map[string]StepRunner{
+			types.StepActionShell:    &ShellRunner{},
+			types.StepActionARM:      fakeARM,
+			types.StepActionARMStack: fakeARM,
+		},
+		parallelism: runtime.GOMAXPROCS(0),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// StepResult records the outcome of running one step.
+type StepResult struct {
+	// Outputs holds the outputs the step produced.
+	Outputs Outputs
+	// Attempts counts the times the step ran, including automated retries.
+	Attempts int
+	// Duration is the time taken to run the step, including automated retries.
+	Duration time.Duration
+	// Err holds the error from the last attempt, if the step failed.
+	Err error
+}
+
+// Result records the outcome of every step that ran. Steps that never started, because a step they depend on failed
+// or the context was cancelled, are not recorded.
+type Result struct {
+	Steps map[graph.Identifier]*StepResult
+}
+
+// completion records a step that finished running.
+type completion struct {
+	id     graph.Identifier
+	result *StepResult
+}
+
+// Run executes the steps of the graph in dependency order, starting each step once all of its parents succeed and
+// running independent steps in parallel. Once a step fails, no more steps are started; steps already running are
+// allowed to finish. The error returned joins the errors of all steps that failed.
+func (e *Executor) Run(ctx context.Context, g *graph.Graph) (*Result, error) {
+	for _, node := range g.Nodes {
+		step, exists := g.GetStep(node.Identifier)
+		if !exists {
+			return nil, fmt.Errorf("step %s not found", node.Identifier)
+		}
+		if e.runners[step.ActionType()] == nil {
+			return nil, fmt.Errorf("step %s: no runner registered for action %q", node.Identifier, step.ActionType())
+		}
+	}
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[graph.Identifier]int, len(g.Nodes))
+	children := make(map[graph.Identifier][]graph.Identifier, len(g.Nodes))
+	var ready []graph.Identifier
+	for _, node := range g.Nodes {
+		remaining[node.Identifier] = len(node.Parents)
+		children[node.Identifier] = node.Children
+	}
+	for _, id := range order {
+		if remaining[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	result := &Result{Steps: map[graph.Identifier]*StepResult{}}
+	completions := make(chan completion)
+	var wg sync.WaitGroup
+	running := 0
+	var errs []error
+	for len(ready) > 0 || running > 0 {
+		for len(ready) > 0 && running < e.parallelism && len(errs) == 0 && ctx.Err() == nil {
+			id := ready[0]
+			ready = ready[1:]
+			stepCtx, err := e.stepContext(g, id, result)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("step %s: %w", id, err))
+				break
+			}
+			running++
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				completions <- completion{id: id, result: e.runStep(ctx, g.Steps[id], stepCtx)}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+		done := <-completions
+		running--
+		result.Steps[done.id] = done.result
+		if done.result.Err != nil {
+			errs = append(errs, fmt.Errorf("step %s: %w", done.id, done.result.Err))
+			continue
+		}
+		for _, child := range children[done.id] {
+			remaining[child]--
+			if remaining[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+		slices.SortFunc(ready, graph.CompareDependencies)
+	}
+	wg.Wait()
+
+	if len(errs) == 0 && ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+	return result, errors.Join(errs...)
+}
+
+// stepContext prepares the context for a step, holding the outputs of the steps it consumes inputs from.
+func (e *Executor) stepContext(g *graph.Graph, id graph.Identifier, result *Result) (*StepContext, error) {
+	resourceGroup, exists := g.ResourceGroups[graph.ResourceGroupKey{Stamp: id.Stamp, Name: id.ResourceGroup}]
+	if !exists {
+		return nil, fmt.Errorf("resource group %s not found", id.ResourceGroup)
+	}
+	stepCtx := &StepContext{
+		Identifier:    id,
+		Service:       g.Services[id.ServiceGroup],
+		ResourceGroup: resourceGroup,
+		config:        e.config,
+		inputs:        map[types.StepDependency]Outputs{},
+	}
+	for _, dep := range g.Steps[id].RequiredInputs() {
+		producer := graph.Identifier{ServiceGroup: id.ServiceGroup, StepDependency: dep}
+		if _, stamped := g.ResourceGroups[graph.ResourceGroupKey{Stamp: id.Stamp, Name: dep.ResourceGroup}]; stamped && id.Stamp.IsSet() {
+			producer.Stamp = id.Stamp
+		}
+		if produced, ran := result.Steps[producer]; ran {
+			stepCtx.inputs[dep] = produced.Outputs
+		}
+	}
+	return stepCtx, nil
+}
+
+// runStep runs a step to completion, retrying failed attempts as configured by the step's automated retries. Each
+// attempt is bounded by the step's timeout, if it declares one.
+func (e *Executor) runStep(ctx context.Context, step types.Step, stepCtx *StepContext) *StepResult {
+	start := time.Now()
+	result := &StepResult{}
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	timeout, hasTimeout, err := types.StepTimeout(step)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	policy := retry.ForStep(step)
+	if e.retrySleep != nil {
+		policy.Sleep = e.retrySleep
+	}
+	runner := e.runners[step.ActionType()]
+	result.Err = policy.Run(ctx, func(ctx context.Context, attempt int) (string, error) {
+		result.Attempts = attempt
+		attemptCtx := *stepCtx
+		attemptCtx.Attempt = attempt
+		if hasTimeout {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		var err error
+		result.Outputs, err = runner.Run(ctx, step, &attemptCtx)
+		return "", err
+	})
+	return result
+}
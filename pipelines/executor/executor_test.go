@@ -0,0 +1,294 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	types2 "github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/pipelines/graph"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+const endToEndPipeline = `serviceGroup: Microsoft.Azure.ARO.Test
+rolloutName: test
+resourceGroups:
+- name: regional
+  resourceGroup: regional
+  subscription: sub
+  steps:
+  - name: infra
+    action: ARM
+    template: main.bicep
+    parameters: main.bicepparam
+    deploymentLevel: ResourceGroup
+    outputs:
+    - name: clusterName
+      type: string
+    - name: nodeCount
+      type: int
+  - name: configure
+    action: Shell
+    command: |
+      echo "configuring ${CLUSTER} in ${REGION}"
+      echo "endpoint=https://${CLUSTER}.${REGION}.example.com" >> "${STEP_OUTPUTS}"
+      echo "replicas=$((NODES + 1))" >> "${STEP_OUTPUTS}"
+    shellIdentity:
+      value: test-msi
+    variables:
+    - name: CLUSTER
+      input:
+        resourceGroup: regional
+        step: infra
+        name: clusterName
+    - name: NODES
+      input:
+        resourceGroup: regional
+        step: infra
+        name: nodeCount
+    - name: REGION
+      configRef: region
+    outputs:
+    - name: endpoint
+      type: string
+    - name: replicas
+      type: int
+  - name: verify
+    action: Shell
+    command: test "$(cat marker)" = present && echo "checked ${ENDPOINT}"
+    workingDir: checks
+    shellIdentity:
+      value: test-msi
+    variables:
+    - name: ENDPOINT
+      input:
+        resourceGroup: regional
+        step: configure
+        name: endpoint
+`
+
+func buildGraph(t *testing.T, content string) *graph.Graph {
+	t.Helper()
+	pipeline, err := types.NewPipelineFromBytes([]byte(content), map[string]any{})
+	require.NoError(t, err)
+	g, err := graph.ForPipeline(&topology.Service{ServiceGroup: pipeline.ServiceGroup, PipelinePath: "test/pipeline.yaml"}, pipeline)
+	require.NoError(t, err)
+	return g
+}
+
+func id(resourceGroup, step string) graph.Identifier {
+	return graph.Identifier{ServiceGroup: "Microsoft.Azure.ARO.Test", StepDependency: types.StepDependency{ResourceGroup: resourceGroup, Step: step}}
+}
+
+func TestExecutorEndToEnd(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "test", "checks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "test", "checks", "marker"), []byte("present"), 0644))
+
+	var output bytes.Buffer
+	executor := New(
+		WithConfiguration(types2.Configuration{"region": "eastus"}),
+		WithRunner(types.StepActionShell, &ShellRunner{Root: root, Output: &output}),
+		WithRunner(types.StepActionARM, &FakeARMRunner{Outputs: map[types.StepDependency]Outputs{
+			{ResourceGroup: "regional", Step: "infra"}: {"clusterName": "hcp", "nodeCount": 2},
+		}}),
+	)
+	result, err := executor.Run(context.Background(), buildGraph(t, endToEndPipeline))
+	require.NoError(t, err)
+
+	require.Len(t, result.Steps, 3)
+	assert.Equal(t, Outputs{"endpoint": "https://hcp.eastus.example.com", "replicas": 3}, result.Steps[id("regional", "configure")].Outputs)
+	assert.Equal(t, 1, result.Steps[id("regional", "verify")].Attempts)
+	assert.Equal(t, "configuring hcp in eastus\nchecked https://hcp.eastus.example.com\n", output.String())
+}
+
+// resolvingRunner resolves the variables of shell steps, returning them as outputs.
+var resolvingRunner = StepRunnerFunc(func(_ context.Context, step types.Step, stepCtx *StepContext) (Outputs, error) {
+	return stepCtx.ResolveVariables(step.(*types.ShellStep).Variables)
+})
+
+func TestExecutorPlaceholderOutputs(t *testing.T) {
+	result, err := New(
+		WithConfiguration(types2.Configuration{"region": "eastus"}),
+		WithRunner(types.StepActionShell, resolvingRunner),
+	).Run(context.Background(), buildGraph(t, endToEndPipeline))
+	require.EqualError(t, err, "step Microsoft.Azure.ARO.Test/regional/verify: variable ENDPOINT: input regional/configure.endpoint is not available: step produced no such output")
+	assert.Equal(t, Outputs{"clusterName": "regional/infra.clusterName", "nodeCount": 0}, result.Steps[id("regional", "infra")].Outputs)
+	assert.Equal(t, Outputs{"CLUSTER": "regional/infra.clusterName", "NODES": 0, "REGION": "eastus"}, result.Steps[id("regional", "configure")].Outputs)
+}
+
+const retryPipeline = `serviceGroup: Microsoft.Azure.ARO.Test
+rolloutName: test
+resourceGroups:
+- name: rg
+  resourceGroup: rg
+  subscription: sub
+  steps:
+  - name: flaky
+    action: Shell
+    command: flaky
+    shellIdentity:
+      value: test-msi
+    automatedRetry:
+      errorContainsAny:
+      - Conflict
+      maximumRetryCount: 3
+      durationBetweenRetries: 5m
+  - name: after
+    action: Shell
+    command: after
+    shellIdentity:
+      value: test-msi
+    dependsOn:
+    - resourceGroup: rg
+      step: flaky
+  - name: independent
+    action: Shell
+    command: independent
+    shellIdentity:
+      value: test-msi
+`
+
+// scriptedRunner fails each command with the errors scripted for it, in order, before succeeding.
+type scriptedRunner struct {
+	lock   sync.Mutex
+	errors map[string][]error
+	ran    []string
+}
+
+func (r *scriptedRunner) Run(_ context.Context, step types.Step, _ *StepContext) (Outputs, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	command := step.(*types.ShellStep).Command
+	r.ran = append(r.ran, command)
+	if scripted := r.errors[command]; len(scripted) > 0 {
+		r.errors[command] = scripted[1:]
+		return nil, scripted[0]
+	}
+	return Outputs{}, nil
+}
+
+func TestExecutorRetries(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		errors   []error
+		attempts int
+		err      string
+		ran      []string
+	}{
+		{
+			name:     "succeeds after retries",
+			errors:   []error{errors.New("409 conflict"), errors.New("CONFLICT again")},
+			attempts: 3,
+			ran:      []string{"flaky", "flaky", "flaky", "after", "independent"},
+		},
+		{
+			name:     "retries exhausted",
+			errors:   []error{errors.New("conflict"), errors.New("conflict"), errors.New("conflict"), errors.New("conflict")},
+			attempts: 4,
+			err:      "step Microsoft.Azure.ARO.Test/rg/flaky: conflict",
+			ran:      []string{"flaky", "flaky", "flaky", "flaky"},
+		},
+		{
+			name:     "unmatched errors are not retried",
+			errors:   []error{errors.New("quota exceeded")},
+			attempts: 1,
+			err:      "step Microsoft.Azure.ARO.Test/rg/flaky: quota exceeded",
+			ran:      []string{"flaky"},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			runner := &scriptedRunner{errors: map[string][]error{"flaky": testCase.errors}}
+			var waited []time.Duration
+			sleep := func(_ context.Context, d time.Duration) error {
+				waited = append(waited, d)
+				return nil
+			}
+			result, err := New(WithRunner(types.StepActionShell, runner), WithParallelism(1), WithRetrySleep(sleep)).Run(context.Background(), buildGraph(t, retryPipeline))
+			if testCase.err != "" {
+				require.EqualError(t, err, testCase.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, testCase.attempts, result.Steps[id("rg", "flaky")].Attempts)
+			assert.Equal(t, testCase.ran, runner.ran)
+			assert.Len(t, waited, testCase.attempts-1)
+		})
+	}
+}
+
+func TestExecutorParallelism(t *testing.T) {
+	pipeline := `serviceGroup: Microsoft.Azure.ARO.Test
+rolloutName: test
+resourceGroups:
+- name: rg
+  resourceGroup: rg
+  subscription: sub
+  steps:
+`
+	for i := range 8 {
+		pipeline += fmt.Sprintf(`  - name: step%d
+    action: Shell
+    command: sleep
+    shellIdentity:
+      value: test-msi
+`, i)
+	}
+
+	var current, peak atomic.Int32
+	runner := StepRunnerFunc(func(context.Context, types.Step, *StepContext) (Outputs, error) {
+		running := current.Add(1)
+		defer current.Add(-1)
+		for {
+			previous := peak.Load()
+			if running <= previous || peak.CompareAndSwap(previous, running) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return Outputs{}, nil
+	})
+	result, err := New(WithRunner(types.StepActionShell, runner), WithParallelism(3)).Run(context.Background(), buildGraph(t, pipeline))
+	require.NoError(t, err)
+	assert.Len(t, result.Steps, 8)
+	assert.Equal(t, int32(3), peak.Load())
+}
+
+func TestExecutorErrors(t *testing.T) {
+	g := buildGraph(t, endToEndPipeline)
+
+	_, err := New(WithRunner(types.StepActionARM, nil)).Run(context.Background(), g)
+	require.EqualError(t, err, `step Microsoft.Azure.ARO.Test/regional/infra: no runner registered for action "ARM"`)
+
+	result, err := New(WithRunner(types.StepActionShell, resolvingRunner)).Run(context.Background(), g)
+	require.EqualError(t, err, `step Microsoft.Azure.ARO.Test/regional/configure: variable REGION: configRef "region" can't be resolved without a configuration`)
+	assert.Len(t, result.Steps, 2)
+
+	_, err = New(WithConfiguration(types2.Configuration{}), WithRunner(types.StepActionShell, resolvingRunner)).Run(context.Background(), g)
+	require.EqualError(t, err, `step Microsoft.Azure.ARO.Test/regional/configure: variable REGION: configRef "region" not found in configuration`)
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	types2 "github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/pipelines/graph"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// Outputs holds the output variables produced by a step, by name.
+type Outputs map[string]any
+
+// StepRunner executes steps of one action.
+type StepRunner interface {
+	// Run executes the step, returning the outputs it produced. Errors are subject to the step's automated retries.
+	Run(ctx context.Context, step types.Step, stepCtx *StepContext) (Outputs, error)
+}
+
+// StepRunnerFunc adapts a function to a StepRunner.
+type StepRunnerFunc func(ctx context.Context, step types.Step, stepCtx *StepContext) (Outputs, error)
+
+func (f StepRunnerFunc) Run(ctx context.Context, step types.Step, stepCtx *StepContext) (Outputs, error) {
+	return f(ctx, step, stepCtx)
+}
+
+// StepContext describes where a step runs, and resolves the values it consumes.
+type StepContext struct {
+	// Identifier identifies the step in the graph.
+	Identifier graph.Identifier
+	// Service is the service that the step belongs to.
+	Service *topology.Service
+	// ResourceGroup is the resource group that the step deploys to.
+	ResourceGroup *types.ResourceGroupMeta
+	// Attempt counts the attempts to run the step, starting at 1.
+	Attempt int
+
+	config types2.Configuration
+	inputs map[types.StepDependency]Outputs
+}
+
+// Resolve determines the data held by a value: values set in the pipeline are used as-is, configuration references
+// are looked up in the configuration and inputs are read from the outputs of the steps that produced them.
+func (c *StepContext) Resolve(value types.Value) (any, error) {
+	switch {
+	case value.Input != nil:
+		return c.Input(*value.Input)
+	case value.ConfigRef != "":
+		if c.config == nil {
+			return nil, fmt.Errorf("configRef %q can't be resolved without a configuration", value.ConfigRef)
+		}
+		resolved, err := c.config.GetByPath(value.ConfigRef)
+		if err != nil {
+			var missing *types2.MissingKeyError
+			if errors.As(err, &missing) {
+				return nil, fmt.Errorf("configRef %q not found in configuration", value.ConfigRef)
+			}
+			return nil, fmt.Errorf("failed to resolve configRef %q: %w", value.ConfigRef, err)
+		}
+		return resolved, nil
+	default:
+		return value.Value, nil
+	}
+}
+
+// Input reads an output of a step that this step depends on.
+func (c *StepContext) Input(input types.Input) (any, error) {
+	outputs, ran := c.inputs[input.StepDependency]
+	if !ran {
+		return nil, fmt.Errorf("input %s/%s.%s is not available: step %s/%s is not a dependency", input.ResourceGroup, input.Step, input.Name, input.ResourceGroup, input.Step)
+	}
+	value, produced := outputs[input.Name]
+	if !produced {
+		return nil, fmt.Errorf("input %s/%s.%s is not available: step produced no such output", input.ResourceGroup, input.Step, input.Name)
+	}
+	return value, nil
+}
+
+// ResolveVariables resolves the value of every variable, by name.
+func (c *StepContext) ResolveVariables(variables []types.Variable) (map[string]any, error) {
+	resolved := make(map[string]any, len(variables))
+	for _, variable := range variables {
+		value, err := c.Resolve(variable.Value)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %w", variable.Name, err)
+		}
+		resolved[variable.Name] = value
+	}
+	return resolved, nil
+}
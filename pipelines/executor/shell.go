@@ -0,0 +1,167 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// OutputsFileEnv names the environment variable holding the path to the file in which shell steps record their
+// outputs, one name=value pair per line.
+const OutputsFileEnv = "STEP_OUTPUTS"
+
+// ShellRunner runs Shell steps with bash on the local machine. Variables are exposed to the command as environment
+// variables, on top of the environment of the current process.
+//
+// Outputs are read from the file named by $STEP_OUTPUTS once the command exits. Values of outputs the step declares
+// with a type are parsed accordingly: int and bool outputs as numbers and booleans, array and object outputs as JSON.
+// A step that declares outputs fails if the command does not record all of them.
+type ShellRunner struct {
+	// Root is the directory that the pipeline paths of services are relative to. Commands run in the directory holding
+	// the pipeline, or in the step's working directory when it declares one. Defaults to the current directory.
+	Root string
+	// Output receives the combined output of every command, when set.
+	Output io.Writer
+}
+
+func (r *ShellRunner) Run(ctx context.Context, step types.Step, stepCtx *StepContext) (Outputs, error) {
+	shell, ok := step.(*types.ShellStep)
+	if !ok {
+		return nil, fmt.Errorf("shell runner can't run %T", step)
+	}
+	variables, err := stepCtx.ResolveVariables(shell.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	outputsFile, err := os.CreateTemp("", "step-outputs-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create outputs file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(outputsFile.Name())
+	}()
+	if err := outputsFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close outputs file: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", shell.Command)
+	cmd.Dir = r.Root
+	if stepCtx.Service != nil && stepCtx.Service.PipelinePath != "" {
+		cmd.Dir = filepath.Join(r.Root, filepath.Dir(stepCtx.Service.PipelinePath))
+	}
+	if shell.WorkingDir != "" {
+		cmd.Dir = filepath.Join(cmd.Dir, shell.WorkingDir)
+	}
+	cmd.Env = os.Environ()
+	for name, value := range variables {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", name, formatVariable(value)))
+	}
+	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", OutputsFileEnv, outputsFile.Name()))
+
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if r.Output != nil {
+		cmd.Stdout = io.MultiWriter(&output, r.Output)
+		cmd.Stderr = cmd.Stdout
+	}
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("command failed: %w\n%s", err, output.String())
+	}
+
+	raw, err := os.ReadFile(outputsFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outputs file: %w", err)
+	}
+	return parseShellOutputs(raw, shell.Outputs)
+}
+
+// formatVariable renders a variable for the environment: strings as-is, and anything else as JSON.
+func formatVariable(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
+
+// parseShellOutputs reads the name=value pairs recorded by a command, parsing the values of declared outputs.
+func parseShellOutputs(raw []byte, declared []types.Output) (Outputs, error) {
+	outputs := Outputs{}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid output %q: expected name=value", line)
+		}
+		outputs[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outputs: %w", err)
+	}
+
+	for _, output := range declared {
+		value, recorded := outputs[output.Name]
+		if !recorded {
+			return nil, fmt.Errorf("command did not record declared output %q", output.Name)
+		}
+		parsed, err := parseOutput(value.(string), output.Type)
+		if err != nil {
+			return nil, fmt.Errorf("output %q: %w", output.Name, err)
+		}
+		outputs[output.Name] = parsed
+	}
+	return outputs, nil
+}
+
+func parseOutput(value string, outputType types.OutputType) (any, error) {
+	switch outputType.Kind() {
+	case types.ValueKindNumber:
+		return strconv.Atoi(value)
+	case types.ValueKindBool:
+		return strconv.ParseBool(value)
+	case types.ValueKindList, types.ValueKindMap:
+		var parsed any
+		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse %s output as JSON: %w", outputType, err)
+		}
+		return parsed, nil
+	default:
+		return value, nil
+	}
+}
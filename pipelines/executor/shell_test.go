@@ -0,0 +1,44 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+func TestParseShellOutputs(t *testing.T) {
+	declared := []types.Output{
+		{Name: "ready", Type: types.OutputTypeBool},
+		{Name: "zones", Type: types.OutputTypeArray},
+		{Name: "untyped"},
+	}
+	outputs, err := parseShellOutputs([]byte("ready=true\nzones=[\"1\",\"2\"]\n\nuntyped=a=b\nextra=value\n"), declared)
+	require.NoError(t, err)
+	assert.Equal(t, Outputs{"ready": true, "zones": []any{"1", "2"}, "untyped": "a=b", "extra": "value"}, outputs)
+
+	_, err = parseShellOutputs([]byte("ready=true\nuntyped=value\n"), declared)
+	require.EqualError(t, err, `command did not record declared output "zones"`)
+
+	_, err = parseShellOutputs([]byte("ready=yes\nzones=[]\nuntyped=value\n"), declared)
+	require.ErrorContains(t, err, `output "ready": strconv.ParseBool: parsing "yes": invalid syntax`)
+
+	_, err = parseShellOutputs([]byte("ready\n"), declared)
+	require.EqualError(t, err, `invalid output "ready": expected name=value`)
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	unformatted = "serviceGroup: Microsoft.Azure.ARO.HCP.Test\n$schema: pipeline.schema.v1\n"
+	formatted   = "$schema: pipeline.schema.v1\nserviceGroup: Microsoft.Azure.ARO.HCP.Test\n"
+)
+
+func TestFormatCommand(t *testing.T) {
+	dir := t.TempDir()
+	dirty := filepath.Join(dir, "dirty", "pipeline.yaml")
+	clean := filepath.Join(dir, "clean", "pipeline.yaml")
+	for path, content := range map[string]string{dirty: unformatted, clean: formatted} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	cmd, err := NewFormatCommand()
+	require.NoError(t, err)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--dir", dir, "--check"})
+	assert.EqualError(t, cmd.Execute(), "1 pipeline files are not formatted")
+	assert.Equal(t, dirty+"\n", out.String())
+	content, err := os.ReadFile(dirty)
+	require.NoError(t, err)
+	assert.Equal(t, unformatted, string(content), "check mode must not write files")
+
+	cmd, err = NewFormatCommand()
+	require.NoError(t, err)
+	cmd.SetArgs([]string{"--input", dirty})
+	require.NoError(t, cmd.Execute())
+	content, err = os.ReadFile(dirty)
+	require.NoError(t, err)
+	assert.Equal(t, formatted, string(content))
+
+	cmd, err = NewFormatCommand()
+	require.NoError(t, err)
+	cmd.SetArgs([]string{"--dir", dir, "--check"})
+	assert.NoError(t, cmd.Execute())
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+func DefaultOptions() *RawOptions {
+	return &RawOptions{
+		Name: "pipeline.yaml",
+	}
+}
+
+func BindOptions(opts *RawOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringVar(&opts.InputPath, "input", opts.InputPath, "Path to the pipeline file to format.")
+	cmd.Flags().StringVar(&opts.OutputPath, "output", opts.OutputPath, "Path to the output file (defaults to input file).")
+	cmd.Flags().StringVar(&opts.Directory, "dir", opts.Directory, "Directory to walk for pipeline files. Ignored if --input is provided.")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "Glob matching the names of pipeline files when walking a directory.")
+	cmd.Flags().BoolVar(&opts.Check, "check", opts.Check, "List the pipeline files that are not formatted, and fail if there are any, without writing them.")
+
+	for _, flag := range []string{
+		"input",
+		"output",
+	} {
+		if err := cmd.MarkFlagFilename(flag); err != nil {
+			return fmt.Errorf("failed to mark flag %q as a file: %w", flag, err)
+		}
+	}
+	if err := cmd.MarkFlagDirname("dir"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a directory: %w", "dir", err)
+	}
+	return nil
+}
+
+// RawOptions holds input values.
+type RawOptions struct {
+	InputPath  string
+	OutputPath string
+	Directory  string
+	Name       string
+	Check      bool
+}
+
+// validatedOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedOptions struct {
+	InputPath  string
+	OutputPath string
+	Directory  string
+	Name       string
+	Check      bool
+}
+
+type ValidatedOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedOptions
+}
+
+// completedOptions is a private wrapper that enforces a call of Complete() before formatting can be invoked.
+type completedOptions struct {
+	Files []file
+	Check bool
+}
+
+// file is a pipeline file to format, and where to write the result.
+type file struct {
+	input, output string
+}
+
+type Options struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedOptions
+}
+
+func (o *RawOptions) Validate() (*ValidatedOptions, error) {
+	if o.InputPath == "" && o.Directory == "" {
+		return nil, fmt.Errorf("the pipeline to format must be provided with --input or --dir")
+	}
+	if o.Check && o.OutputPath != "" {
+		return nil, fmt.Errorf("--output may not be provided with --check, as nothing is written")
+	}
+	if o.InputPath != "" && o.OutputPath == "" {
+		o.OutputPath = o.InputPath
+	}
+	if o.InputPath == "" {
+		if _, err := filepath.Match(o.Name, ""); err != nil {
+			return nil, fmt.Errorf("invalid --name %q: %w", o.Name, err)
+		}
+	}
+
+	return &ValidatedOptions{
+		validatedOptions: &validatedOptions{
+			InputPath:  o.InputPath,
+			OutputPath: o.OutputPath,
+			Directory:  o.Directory,
+			Name:       o.Name,
+			Check:      o.Check,
+		},
+	}, nil
+}
+
+func (o *ValidatedOptions) Complete() (*Options, error) {
+	var files []file
+	if o.InputPath != "" {
+		files = append(files, file{input: o.InputPath, output: o.OutputPath})
+	} else {
+		if err := filepath.WalkDir(o.Directory, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if matches, _ := filepath.Match(o.Name, d.Name()); matches {
+				files = append(files, file{input: path, output: path})
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to find pipeline files in %s: %w", o.Directory, err)
+		}
+	}
+
+	return &Options{
+		completedOptions: &completedOptions{
+			Files: files,
+			Check: o.Check,
+		},
+	}, nil
+}
+
+// Format rewrites every pipeline file in canonical form, leaving files that already are untouched. In check mode,
+// nothing is written; the files that are not formatted are listed to out, and an error is returned if there are any.
+func (opts *Options) Format(ctx context.Context, out io.Writer) error {
+	var errs []error
+	var unformatted int
+	for _, f := range opts.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		changed, err := formatFile(f, opts.Check)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.input, err))
+			continue
+		}
+		if changed && opts.Check {
+			unformatted++
+			if _, err := fmt.Fprintln(out, f.input); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+	}
+	if unformatted > 0 {
+		errs = append(errs, fmt.Errorf("%d pipeline files are not formatted", unformatted))
+	}
+	return errors.Join(errs...)
+}
+
+// formatFile formats the file, determining whether formatting changed it. Nothing is written in check mode.
+func formatFile(f file, check bool) (bool, error) {
+	content, err := os.ReadFile(f.input)
+	if err != nil {
+		return false, fmt.Errorf("failed to read file: %w", err)
+	}
+	formatted, err := types.FormatPipeline(content)
+	if err != nil {
+		return false, err
+	}
+	changed := !bytes.Equal(formatted, content)
+	if check || f.output == f.input && !changed {
+		return changed, nil
+	}
+	if err := os.WriteFile(f.output, formatted, 0644); err != nil {
+		return false, fmt.Errorf("failed to write file %s: %w", f.output, err)
+	}
+	return changed, nil
+}
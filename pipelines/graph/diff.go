@@ -0,0 +1,350 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// Edge records that the step identified by To runs after the step identified by From.
+type Edge struct {
+	From, To Identifier
+}
+
+func (e Edge) String() string {
+	return fmt.Sprintf("%s -> %s", e.From, e.To)
+}
+
+// StepChange records a step present in both graphs whose definition differs.
+type StepChange struct {
+	Identifier
+	// Diff shows how the definition changed, in the format of cmp.Diff.
+	Diff string
+}
+
+// ResourceGroupChange records a resource group present in both graphs whose metadata differs.
+type ResourceGroupChange struct {
+	Key      ResourceGroupKey
+	Old, New *types.ResourceGroupMeta
+	// Diff shows how the metadata changed, in the format of cmp.Diff.
+	Diff string
+}
+
+// GraphDiff records how an execution graph changed between two revisions. Every list is sorted.
+type GraphDiff struct {
+	AddedNodes            []Identifier
+	RemovedNodes          []Identifier
+	AddedEdges            []Edge
+	RemovedEdges          []Edge
+	ChangedSteps          []StepChange
+	ChangedResourceGroups []ResourceGroupChange
+}
+
+// Empty determines whether the graphs are equivalent.
+func (d *GraphDiff) Empty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 &&
+		len(d.ChangedSteps) == 0 && len(d.ChangedResourceGroups) == 0
+}
+
+// Diff compares two revisions of an execution graph. Steps are compared by their serialized definitions, and
+// resource groups by their metadata.
+func Diff(old, new *Graph) (*GraphDiff, error) {
+	diff := &GraphDiff{}
+	oldNodes, newNodes := nodeSet(old), nodeSet(new)
+	diff.AddedNodes = sortedIdentifiers(newNodes.Difference(oldNodes))
+	diff.RemovedNodes = sortedIdentifiers(oldNodes.Difference(newNodes))
+
+	oldEdges, newEdges := edgeSet(old), edgeSet(new)
+	diff.AddedEdges = sortedEdges(newEdges.Difference(oldEdges))
+	diff.RemovedEdges = sortedEdges(oldEdges.Difference(newEdges))
+
+	for _, id := range sortedIdentifiers(oldNodes.Intersection(newNodes)) {
+		oldStep, err := stepDefinition(old, id)
+		if err != nil {
+			return nil, err
+		}
+		newStep, err := stepDefinition(new, id)
+		if err != nil {
+			return nil, err
+		}
+		if stepDiff := cmp.Diff(oldStep, newStep); stepDiff != "" {
+			diff.ChangedSteps = append(diff.ChangedSteps, StepChange{Identifier: id, Diff: stepDiff})
+		}
+	}
+
+	keys := slices.SortedFunc(maps.Keys(old.ResourceGroups), compareResourceGroupKeys)
+	for _, key := range keys {
+		oldGroup := old.ResourceGroups[key]
+		newGroup, exists := new.ResourceGroups[key]
+		if !exists || resourceGroupMetaEqual(oldGroup, newGroup) {
+			continue
+		}
+		diff.ChangedResourceGroups = append(diff.ChangedResourceGroups, ResourceGroupChange{
+			Key:  key,
+			Old:  oldGroup,
+			New:  newGroup,
+			Diff: cmp.Diff(oldGroup, newGroup),
+		})
+	}
+	return diff, nil
+}
+
+func nodeSet(g *Graph) sets.Set[Identifier] {
+	nodes := sets.New[Identifier]()
+	for _, node := range g.Nodes {
+		nodes.Insert(node.Identifier)
+	}
+	return nodes
+}
+
+func edgeSet(g *Graph) sets.Set[Edge] {
+	edges := sets.New[Edge]()
+	for _, node := range g.Nodes {
+		for _, child := range node.Children {
+			edges.Insert(Edge{From: node.Identifier, To: child})
+		}
+	}
+	return edges
+}
+
+func sortedIdentifiers(ids sets.Set[Identifier]) []Identifier {
+	sorted := ids.UnsortedList()
+	slices.SortFunc(sorted, CompareDependencies)
+	return sorted
+}
+
+func sortedEdges(edges sets.Set[Edge]) []Edge {
+	sorted := edges.UnsortedList()
+	slices.SortFunc(sorted, func(a, b Edge) int {
+		if comparison := CompareDependencies(a.From, b.From); comparison != 0 {
+			return comparison
+		}
+		return CompareDependencies(a.To, b.To)
+	})
+	return sorted
+}
+
+func compareResourceGroupKeys(a, b ResourceGroupKey) int {
+	if comparison := strings.Compare(a.Stamp.String(), b.Stamp.String()); comparison != 0 {
+		return comparison
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// stepDefinition serializes a step into a generic form, so that steps of any type can be compared.
+func stepDefinition(g *Graph, id Identifier) (map[string]any, error) {
+	step, exists := g.GetStep(id)
+	if !exists {
+		return nil, fmt.Errorf("step %s not found", id)
+	}
+	raw, err := json.Marshal(step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal step %s: %w", id, err)
+	}
+	var definition map[string]any
+	if err := json.Unmarshal(raw, &definition); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal step %s: %w", id, err)
+	}
+	return definition, nil
+}
+
+// MarshalDiffText renders the diff for humans, listing each kind of change in turn.
+func MarshalDiffText(d *GraphDiff) []byte {
+	if d.Empty() {
+		return []byte("No changes.\n")
+	}
+	var out strings.Builder
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Fprintf(&out, "%s:\n", title)
+		for _, line := range lines {
+			fmt.Fprintf(&out, "  %s\n", line)
+		}
+	}
+	prefixed := func(prefix string, items []fmt.Stringer) []string {
+		var lines []string
+		for _, item := range items {
+			lines = append(lines, prefix+" "+item.String())
+		}
+		return lines
+	}
+	indent := func(text string) string {
+		return strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n  ")
+	}
+
+	section("Added steps", prefixed("+", stringers(d.AddedNodes)))
+	section("Removed steps", prefixed("-", stringers(d.RemovedNodes)))
+	section("Added edges", prefixed("+", stringers(d.AddedEdges)))
+	section("Removed edges", prefixed("-", stringers(d.RemovedEdges)))
+	var changed []string
+	for _, change := range d.ChangedSteps {
+		changed = append(changed, fmt.Sprintf("~ %s\n%s", change.Identifier, indent(change.Diff)))
+	}
+	section("Changed steps", changed)
+	changed = nil
+	for _, change := range d.ChangedResourceGroups {
+		name := change.Key.Name
+		if change.Key.Stamp.IsSet() {
+			name = fmt.Sprintf("%s (stamp=%s)", name, change.Key.Stamp)
+		}
+		changed = append(changed, fmt.Sprintf("~ %s\n%s", name, indent(change.Diff)))
+	}
+	section("Changed resource groups", changed)
+	return []byte(out.String())
+}
+
+func stringers[T fmt.Stringer](items []T) []fmt.Stringer {
+	converted := make([]fmt.Stringer, 0, len(items))
+	for _, item := range items {
+		converted = append(converted, item)
+	}
+	return converted
+}
+
+// diffIdentifier is the JSON representation of an Identifier.
+type diffIdentifier struct {
+	Stamp         string `json:"stamp,omitempty"`
+	ServiceGroup  string `json:"serviceGroup"`
+	ResourceGroup string `json:"resourceGroup"`
+	Step          string `json:"step"`
+}
+
+type diffEdge struct {
+	From diffIdentifier `json:"from"`
+	To   diffIdentifier `json:"to"`
+}
+
+type diffStepChange struct {
+	diffIdentifier `json:",inline"`
+	Diff           string `json:"diff"`
+}
+
+type diffResourceGroupChange struct {
+	Stamp string                   `json:"stamp,omitempty"`
+	Name  string                   `json:"name"`
+	Old   *types.ResourceGroupMeta `json:"old"`
+	New   *types.ResourceGroupMeta `json:"new"`
+	Diff  string                   `json:"diff"`
+}
+
+type diffDocument struct {
+	AddedNodes            []diffIdentifier          `json:"addedNodes"`
+	RemovedNodes          []diffIdentifier          `json:"removedNodes"`
+	AddedEdges            []diffEdge                `json:"addedEdges"`
+	RemovedEdges          []diffEdge                `json:"removedEdges"`
+	ChangedSteps          []diffStepChange          `json:"changedSteps"`
+	ChangedResourceGroups []diffResourceGroupChange `json:"changedResourceGroups"`
+}
+
+// MarshalDiffJSON renders the diff as JSON for tools. Every list is present, even when empty.
+func MarshalDiffJSON(d *GraphDiff) ([]byte, error) {
+	identifier := func(id Identifier) diffIdentifier {
+		return diffIdentifier{Stamp: id.Stamp.String(), ServiceGroup: id.ServiceGroup, ResourceGroup: id.ResourceGroup, Step: id.Step}
+	}
+	identifiers := func(ids []Identifier) []diffIdentifier {
+		converted := []diffIdentifier{}
+		for _, id := range ids {
+			converted = append(converted, identifier(id))
+		}
+		return converted
+	}
+	edges := func(edges []Edge) []diffEdge {
+		converted := []diffEdge{}
+		for _, e := range edges {
+			converted = append(converted, diffEdge{From: identifier(e.From), To: identifier(e.To)})
+		}
+		return converted
+	}
+	document := diffDocument{
+		AddedNodes:            identifiers(d.AddedNodes),
+		RemovedNodes:          identifiers(d.RemovedNodes),
+		AddedEdges:            edges(d.AddedEdges),
+		RemovedEdges:          edges(d.RemovedEdges),
+		ChangedSteps:          []diffStepChange{},
+		ChangedResourceGroups: []diffResourceGroupChange{},
+	}
+	for _, change := range d.ChangedSteps {
+		document.ChangedSteps = append(document.ChangedSteps, diffStepChange{diffIdentifier: identifier(change.Identifier), Diff: change.Diff})
+	}
+	for _, change := range d.ChangedResourceGroups {
+		document.ChangedResourceGroups = append(document.ChangedResourceGroups, diffResourceGroupChange{
+			Stamp: change.Key.Stamp.String(),
+			Name:  change.Key.Name,
+			Old:   change.Old,
+			New:   change.New,
+			Diff:  change.Diff,
+		})
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+const (
+	dotAddedColor   = "#2DA44E"
+	dotRemovedColor = "#CF222E"
+	dotChangedColor = "#BF8700"
+)
+
+// MarshalDiffDOT renders both revisions of the graph as one DOT graph, in the style of MarshalDOT. Added steps and
+// edges are drawn in green, removed ones in red, and steps whose definition changed in amber.
+func MarshalDiffDOT(old, new *Graph, d *GraphDiff) ([]byte, error) {
+	union := &Graph{
+		ResourceGroups: maps.Clone(old.ResourceGroups),
+		Nodes:          slices.Clone(new.Nodes),
+	}
+	maps.Copy(union.ResourceGroups, new.ResourceGroups)
+	removedNodes := sets.New(d.RemovedNodes...)
+	for _, node := range old.Nodes {
+		if removedNodes.Has(node.Identifier) {
+			union.Nodes = append(union.Nodes, node)
+		}
+	}
+	for _, e := range d.RemovedEdges {
+		if removedNodes.Has(e.From) {
+			continue
+		}
+		index := slices.IndexFunc(union.Nodes, func(node Node) bool { return node.Identifier == e.From })
+		union.Nodes[index].Children = append(slices.Clone(union.Nodes[index].Children), e.To)
+	}
+	slices.SortFunc(union.Nodes, func(a, b Node) int {
+		return CompareDependencies(a.Identifier, b.Identifier)
+	})
+
+	addedNodes, changedNodes := sets.New(d.AddedNodes...), sets.New[Identifier]()
+	for _, change := range d.ChangedSteps {
+		changedNodes.Insert(change.Identifier)
+	}
+	addedEdges, removedEdges := sets.New(d.AddedEdges...), sets.New(d.RemovedEdges...)
+	return marshalDOT(union, dotStyle{
+		node: func(id Identifier) string {
+			switch {
+			case addedNodes.Has(id):
+				return fmt.Sprintf("color=\"%s\" penwidth=2", dotAddedColor)
+			case removedNodes.Has(id):
+				return fmt.Sprintf("color=\"%s\" fontcolor=\"%s\" penwidth=2", dotRemovedColor, dotRemovedColor)
+			case changedNodes.Has(id):
+				return fmt.Sprintf("color=\"%s\" penwidth=2", dotChangedColor)
+			}
+			return ""
+		},
+		edge: func(from, to Identifier) string {
+			switch e := (Edge{From: from, To: to}); {
+			case addedEdges.Has(e):
+				return fmt.Sprintf("color=\"%s\"", dotAddedColor)
+			case removedEdges.Has(e):
+				return fmt.Sprintf("color=\"%s\" style=dashed", dotRemovedColor)
+			}
+			return ""
+		},
+	})
+}
@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+const diffServiceGroup = "Microsoft.Azure.ARO.HCP.Test"
+
+func diffGraph(t *testing.T, subscription string, steps ...types.Step) *Graph {
+	t.Helper()
+	pipeline := makePipeline(diffServiceGroup, "rg", false, steps...)
+	pipeline.ResourceGroups[0].Subscription = subscription
+	g, err := ForPipeline(&topology.Service{ServiceGroup: diffServiceGroup, PipelinePath: "pipeline.yaml"}, pipeline)
+	require.NoError(t, err)
+	return g
+}
+
+func diffStep(name string) Identifier {
+	return identifiers(diffServiceGroup, Unstamped(), name)[0]
+}
+
+func TestDiff(t *testing.T) {
+	commanded := func(step *types.ShellStep, command string) *types.ShellStep {
+		step.Command = command
+		return step
+	}
+	old := diffGraph(t, "sub",
+		commanded(conditionalStep("setup", ""), "make setup"),
+		commanded(conditionalStep("deploy", "", "setup"), "make deploy"),
+		conditionalStep("legacy", "", "setup"),
+	)
+	updated := diffGraph(t, "other-sub",
+		commanded(conditionalStep("setup", ""), "make setup"),
+		commanded(conditionalStep("deploy", ""), "make deploy-all"),
+		conditionalStep("verify", "", "deploy"),
+	)
+
+	t.Run("identical graphs", func(t *testing.T) {
+		diff, err := Diff(old, old)
+		require.NoError(t, err)
+		assert.True(t, diff.Empty())
+		assert.Equal(t, "No changes.\n", string(MarshalDiffText(diff)))
+	})
+
+	diff, err := Diff(old, updated)
+	require.NoError(t, err)
+	require.False(t, diff.Empty())
+
+	t.Run("structure", func(t *testing.T) {
+		assert.Equal(t, []Identifier{diffStep("verify")}, diff.AddedNodes)
+		assert.Equal(t, []Identifier{diffStep("legacy")}, diff.RemovedNodes)
+		assert.Equal(t, []Edge{{From: diffStep("deploy"), To: diffStep("verify")}}, diff.AddedEdges)
+		assert.Equal(t, []Edge{
+			{From: diffStep("setup"), To: diffStep("deploy")},
+			{From: diffStep("setup"), To: diffStep("legacy")},
+		}, diff.RemovedEdges)
+
+		require.Len(t, diff.ChangedSteps, 1)
+		assert.Equal(t, diffStep("deploy"), diff.ChangedSteps[0].Identifier)
+		assert.Contains(t, diff.ChangedSteps[0].Diff, "make deploy-all")
+
+		require.Len(t, diff.ChangedResourceGroups, 1)
+		assert.Equal(t, ResourceGroupKey{Stamp: Unstamped(), Name: "rg"}, diff.ChangedResourceGroups[0].Key)
+		assert.Equal(t, "sub", diff.ChangedResourceGroups[0].Old.Subscription)
+		assert.Equal(t, "other-sub", diff.ChangedResourceGroups[0].New.Subscription)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		text := string(MarshalDiffText(diff))
+		for _, expected := range []string{
+			"Added steps:\n  + Microsoft.Azure.ARO.HCP.Test/rg/verify\n",
+			"Removed steps:\n  - Microsoft.Azure.ARO.HCP.Test/rg/legacy\n",
+			"Added edges:\n  + Microsoft.Azure.ARO.HCP.Test/rg/deploy -> Microsoft.Azure.ARO.HCP.Test/rg/verify\n",
+			"Changed steps:\n  ~ Microsoft.Azure.ARO.HCP.Test/rg/deploy\n",
+			"Changed resource groups:\n  ~ rg\n",
+		} {
+			assert.Contains(t, text, expected)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		raw, err := MarshalDiffJSON(diff)
+		require.NoError(t, err)
+		var document map[string]any
+		require.NoError(t, json.Unmarshal(raw, &document))
+		assert.Equal(t, []any{map[string]any{
+			"serviceGroup": diffServiceGroup, "resourceGroup": "rg", "step": "verify",
+		}}, document["addedNodes"])
+		assert.Len(t, document["removedEdges"], 2)
+		changed := document["changedResourceGroups"].([]any)[0].(map[string]any)
+		assert.Equal(t, "rg", changed["name"])
+		assert.Equal(t, "other-sub", changed["new"].(map[string]any)["subscription"])
+
+		empty, err := MarshalDiffJSON(&GraphDiff{})
+		require.NoError(t, err)
+		assert.Contains(t, string(empty), `"addedNodes": []`)
+	})
+
+	t.Run("DOT", func(t *testing.T) {
+		raw, err := MarshalDiffDOT(old, updated, diff)
+		require.NoError(t, err)
+		dot := string(raw)
+		lineFor := func(prefix string) string {
+			for _, line := range strings.Split(dot, "\n") {
+				if strings.HasPrefix(line, prefix) {
+					return line
+				}
+			}
+			t.Fatalf("no line starting with %q in:\n%s", prefix, dot)
+			return ""
+		}
+		assert.Contains(t, lineFor(` "Test_rg_verify" [`), dotAddedColor)
+		assert.Contains(t, lineFor(` "Test_rg_legacy" [`), dotRemovedColor)
+		assert.Contains(t, lineFor(` "Test_rg_deploy" [`), dotChangedColor)
+		assert.NotContains(t, lineFor(` "Test_rg_setup" [`), "color=")
+		assert.Contains(t, lineFor(` "Test_rg_deploy" -> "Test_rg_verify"`), dotAddedColor)
+		assert.Contains(t, lineFor(` "Test_rg_setup" -> "Test_rg_deploy"`), "style=dashed")
+		assert.Contains(t, lineFor(` "Test_rg_setup" -> "Test_rg_legacy"`), "style=dashed")
+	})
+}
@@ -0,0 +1,297 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// cluster holds the steps and validation steps of one resource group, within one service group.
+type cluster struct {
+	serviceGroup    string
+	key             ResourceGroupKey
+	steps           []Identifier
+	validationSteps []Identifier
+}
+
+// clustersFor groups the nodes and validation steps of the graph by service group and resource group. Clusters are
+// sorted by service group, then resource group and stamp; steps within each cluster keep the order of the graph.
+func clustersFor(g *Graph) []*cluster {
+	type clusterKey struct {
+		serviceGroup string
+		key          ResourceGroupKey
+	}
+	clusters := map[clusterKey]*cluster{}
+	clusterFor := func(id Identifier) *cluster {
+		key := clusterKey{serviceGroup: id.ServiceGroup, key: id.ResourceGroupKey()}
+		if _, exists := clusters[key]; !exists {
+			clusters[key] = &cluster{serviceGroup: id.ServiceGroup, key: key.key}
+		}
+		return clusters[key]
+	}
+	for _, node := range g.Nodes {
+		c := clusterFor(node.Identifier)
+		c.steps = append(c.steps, node.Identifier)
+	}
+	for _, id := range slices.SortedFunc(maps.Keys(g.ServiceValidationSteps), CompareDependencies) {
+		c := clusterFor(id)
+		c.validationSteps = append(c.validationSteps, id)
+	}
+	return slices.SortedFunc(maps.Values(clusters), func(a, b *cluster) int {
+		if comparison := strings.Compare(a.serviceGroup, b.serviceGroup); comparison != 0 {
+			return comparison
+		}
+		if comparison := strings.Compare(a.key.Name, b.key.Name); comparison != 0 {
+			return comparison
+		}
+		return strings.Compare(a.key.Stamp.String(), b.key.Stamp.String())
+	})
+}
+
+// clusterLabel names the Azure resource group a cluster targets, along with the stamp, if any.
+func clusterLabel(g *Graph, c *cluster) string {
+	label := c.key.Name
+	if rg, ok := g.ResourceGroups[c.key]; ok {
+		label = rg.ResourceGroup
+	}
+	if c.key.Stamp.IsSet() {
+		label = fmt.Sprintf("%s (stamp=%s)", label, c.key.Stamp)
+	}
+	return label
+}
+
+// MarshalClusteredDOT marshals the graph into the DOT notation used by the graphviz library, like MarshalDOT, but
+// draws each service group and each of its resource groups as a cluster. Validation steps are drawn in the cluster
+// for the resource group they validate.
+func MarshalClusteredDOT(g *Graph) ([]byte, error) {
+	out := bytes.Buffer{}
+	out.WriteString(graphPrefix)
+	out.WriteString(" compound=true\n")
+
+	stampColors := buildStampColorMap(g.Nodes)
+	clusters := clustersFor(g)
+	for i, c := range clusters {
+		serviceGroup, err := shortenServiceGroup(c.serviceGroup)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 || clusters[i-1].serviceGroup != c.serviceGroup {
+			fmt.Fprintf(&out, " subgraph \"cluster_%s\" {\n  label=\"%s\"\n", serviceGroup, serviceGroup)
+		}
+		clusterID := fmt.Sprintf("cluster_%s_%s", serviceGroup, c.key.Name)
+		if c.key.Stamp.IsSet() {
+			clusterID += "_" + c.key.Stamp.String()
+		}
+		fmt.Fprintf(&out, "  subgraph \"%s\" {\n   label=\"%s\"\n", clusterID, clusterLabel(g, c))
+		for _, id := range c.steps {
+			attrs := fmt.Sprintf("label=\"%s\"", id.Step)
+			if color, ok := stampColors[id.Stamp]; ok {
+				attrs += fmt.Sprintf(" style=filled fillcolor=\"%s\"", color)
+			}
+			fmt.Fprintf(&out, "   \"%s\" [%s];\n", dotID(serviceGroup, id), attrs)
+		}
+		for _, id := range c.validationSteps {
+			fmt.Fprintf(&out, "   \"%s_validation\" [label=\"%s\" shape=box style=dashed];\n", dotID(serviceGroup, id), id.Step)
+		}
+		out.WriteString("  }\n")
+		if i == len(clusters)-1 || clusters[i+1].serviceGroup != c.serviceGroup {
+			out.WriteString(" }\n")
+		}
+	}
+
+	for _, node := range g.Nodes {
+		serviceGroup, err := shortenServiceGroup(node.ServiceGroup)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range node.Children {
+			childServiceGroup, err := shortenServiceGroup(child.ServiceGroup)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&out, " \"%s\" -> \"%s\";\n", dotID(serviceGroup, node.Identifier), dotID(childServiceGroup, child))
+		}
+	}
+
+	out.WriteString(graphSuffix)
+	return out.Bytes(), nil
+}
+
+// MarshalMermaid marshals the graph into a Mermaid flowchart, which renders directly in GitHub markdown. Like
+// MarshalClusteredDOT, each service group and each of its resource groups are drawn as nested subgraphs, with
+// validation steps drawn in the subgraph for the resource group they validate.
+// See documentation here: https://mermaid.js.org/syntax/flowchart.html
+func MarshalMermaid(g *Graph) ([]byte, error) {
+	out := bytes.Buffer{}
+	out.WriteString("flowchart TD\n")
+
+	// Mermaid identifiers are restricted to a small alphabet, so nodes are numbered in the order they are drawn.
+	ids := map[Identifier]string{}
+	stampColors := buildStampColorMap(g.Nodes)
+	stampClasses := map[Stamp][]string{}
+	clusters := clustersFor(g)
+	for i, c := range clusters {
+		serviceGroup, err := shortenServiceGroup(c.serviceGroup)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 || clusters[i-1].serviceGroup != c.serviceGroup {
+			fmt.Fprintf(&out, "  subgraph sg%d[\"%s\"]\n", i, mermaidText(serviceGroup))
+		}
+		fmt.Fprintf(&out, "    subgraph rg%d[\"%s\"]\n", i, mermaidText(clusterLabel(g, c)))
+		for _, id := range c.steps {
+			ids[id] = fmt.Sprintf("n%d", len(ids))
+			fmt.Fprintf(&out, "      %s[\"%s\"]\n", ids[id], mermaidText(id.Step))
+			if id.Stamp.IsSet() {
+				stampClasses[id.Stamp] = append(stampClasses[id.Stamp], ids[id])
+			}
+		}
+		for j, id := range c.validationSteps {
+			fmt.Fprintf(&out, "      v%d_%d{{\"%s\"}}\n", i, j, mermaidText(id.Step))
+		}
+		out.WriteString("    end\n")
+		if i == len(clusters)-1 || clusters[i+1].serviceGroup != c.serviceGroup {
+			out.WriteString("  end\n")
+		}
+	}
+
+	for _, node := range g.Nodes {
+		for _, child := range node.Children {
+			childID, ok := ids[child]
+			if !ok {
+				return nil, fmt.Errorf("node %s references %s, which is not found in graph", node.Identifier, child)
+			}
+			fmt.Fprintf(&out, "  %s --> %s\n", ids[node.Identifier], childID)
+		}
+	}
+
+	for _, stamp := range slices.SortedFunc(maps.Keys(stampClasses), func(a, b Stamp) int {
+		return strings.Compare(a.String(), b.String())
+	}) {
+		class := "stamp_" + mermaidClass(stamp.String())
+		fmt.Fprintf(&out, "  classDef %s fill:%s\n", class, stampColors[stamp])
+		fmt.Fprintf(&out, "  class %s %s\n", strings.Join(stampClasses[stamp], ","), class)
+	}
+	return out.Bytes(), nil
+}
+
+// mermaidText escapes text for use in a quoted Mermaid label.
+func mermaidText(text string) string {
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
+
+// mermaidClass replaces characters that may not appear in Mermaid class names.
+func mermaidClass(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// JSONGraphVersion identifies the schema of the document produced by MarshalJSON. It changes only when the schema
+// changes in a way that is not backwards compatible.
+const JSONGraphVersion = "v1"
+
+// JSONGraph is the document produced by MarshalJSON. Every list is sorted and present, even when empty.
+type JSONGraph struct {
+	Version         string              `json:"version"`
+	Stamps          []string            `json:"stamps"`
+	ResourceGroups  []JSONResourceGroup `json:"resourceGroups"`
+	Nodes           []JSONNode          `json:"nodes"`
+	Edges           []JSONEdge          `json:"edges"`
+	ValidationSteps []JSONNode          `json:"validationSteps"`
+}
+
+// JSONResourceGroup records the metadata for a resource group in one stamp, if it is stamped.
+type JSONResourceGroup struct {
+	Stamp         string `json:"stamp,omitempty"`
+	Name          string `json:"name"`
+	ResourceGroup string `json:"resourceGroup"`
+	Subscription  string `json:"subscription"`
+	Stamped       bool   `json:"stamped,omitempty"`
+}
+
+// JSONNode records a step. ID is unique within the document and is used to refer to the step from edges.
+type JSONNode struct {
+	ID            string `json:"id"`
+	Stamp         string `json:"stamp,omitempty"`
+	ServiceGroup  string `json:"serviceGroup"`
+	ResourceGroup string `json:"resourceGroup"`
+	Step          string `json:"step"`
+	Action        string `json:"action,omitempty"`
+}
+
+// JSONEdge records that the step identified by To runs after the step identified by From.
+type JSONEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MarshalJSON marshals the graph into the stable JSON document described by JSONGraph.
+func MarshalJSON(g *Graph) ([]byte, error) {
+	document := JSONGraph{
+		Version:         JSONGraphVersion,
+		Stamps:          []string{},
+		ResourceGroups:  []JSONResourceGroup{},
+		Nodes:           []JSONNode{},
+		Edges:           []JSONEdge{},
+		ValidationSteps: []JSONNode{},
+	}
+	node := func(id Identifier, action string) JSONNode {
+		return JSONNode{
+			ID:            id.String(),
+			Stamp:         id.Stamp.String(),
+			ServiceGroup:  id.ServiceGroup,
+			ResourceGroup: id.ResourceGroup,
+			Step:          id.Step,
+			Action:        action,
+		}
+	}
+
+	stamps := sets.New[string]()
+	for _, key := range slices.SortedFunc(maps.Keys(g.ResourceGroups), compareResourceGroupKeys) {
+		rg := g.ResourceGroups[key]
+		if key.Stamp.IsSet() {
+			stamps.Insert(key.Stamp.String())
+		}
+		document.ResourceGroups = append(document.ResourceGroups, JSONResourceGroup{
+			Stamp:         key.Stamp.String(),
+			Name:          key.Name,
+			ResourceGroup: rg.ResourceGroup,
+			Subscription:  rg.Subscription,
+			Stamped:       rg.Stamped,
+		})
+	}
+
+	nodes := slices.Clone(g.Nodes)
+	slices.SortFunc(nodes, func(a, b Node) int {
+		return CompareDependencies(a.Identifier, b.Identifier)
+	})
+	for _, n := range nodes {
+		var action string
+		if step, exists := g.Steps[n.Identifier]; exists {
+			action = step.ActionType()
+		}
+		if n.Stamp.IsSet() {
+			stamps.Insert(n.Stamp.String())
+		}
+		document.Nodes = append(document.Nodes, node(n.Identifier, action))
+		children := slices.Clone(n.Children)
+		slices.SortFunc(children, CompareDependencies)
+		for _, child := range children {
+			document.Edges = append(document.Edges, JSONEdge{From: n.String(), To: child.String()})
+		}
+	}
+	for _, id := range slices.SortedFunc(maps.Keys(g.ServiceValidationSteps), CompareDependencies) {
+		document.ValidationSteps = append(document.ValidationSteps, node(id, g.ServiceValidationSteps[id].ActionType()))
+	}
+	document.Stamps = append(document.Stamps, sets.List(stamps)...)
+	return json.MarshalIndent(document, "", "  ")
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+	"github.com/Azure/ARO-Tools/testutil"
+)
+
+func TestStampedEntrypointClusteredDOT(t *testing.T) {
+	encoded, err := MarshalClusteredDOT(stampedEntrypointGraph(t))
+	require.NoError(t, err)
+
+	testutil.CompareWithFixture(t, encoded, testutil.WithExtension(".dot"))
+}
+
+func TestStampedEntrypointMermaid(t *testing.T) {
+	encoded, err := MarshalMermaid(stampedEntrypointGraph(t))
+	require.NoError(t, err)
+
+	testutil.CompareWithFixture(t, encoded, testutil.WithExtension(".mmd"))
+}
+
+func TestStampedEntrypointJSON(t *testing.T) {
+	encoded, err := MarshalJSON(stampedEntrypointGraph(t))
+	require.NoError(t, err)
+
+	testutil.CompareWithFixture(t, encoded, testutil.WithExtension(".json"))
+
+	var document JSONGraph
+	require.NoError(t, json.Unmarshal(encoded, &document))
+	assert.Equal(t, JSONGraphVersion, document.Version)
+	assert.Equal(t, []string{"1", "2"}, document.Stamps)
+	ids := map[string]bool{}
+	for _, node := range document.Nodes {
+		ids[node.ID] = true
+	}
+	for _, edge := range document.Edges {
+		assert.True(t, ids[edge.From], "edge from unknown node %s", edge.From)
+		assert.True(t, ids[edge.To], "edge to unknown node %s", edge.To)
+	}
+}
+
+func TestExportValidationSteps(t *testing.T) {
+	serviceGroup := "Microsoft.Azure.ARO.HCP.Test"
+	pipeline := makePipelineWithValidation(serviceGroup,
+		&types.ResourceGroupMeta{Name: "rg", ResourceGroup: "test-rg", Subscription: "sub"},
+		[]types.ValidationStep{&types.GenericValidationStep{StepMeta: types.StepMeta{Name: "validate", Action: "Validation"}}},
+		&types.ShellStep{StepMeta: types.StepMeta{Name: "deploy", Action: "Shell"}},
+	)
+	g, err := ForPipeline(&topology.Service{ServiceGroup: serviceGroup, PipelinePath: "pipeline.yaml"}, pipeline)
+	require.NoError(t, err)
+
+	dot, err := MarshalClusteredDOT(g)
+	require.NoError(t, err)
+	assert.Contains(t, string(dot), `  subgraph "cluster_Test_rg" {
+   label="test-rg"
+   "Test_rg_deploy" [label="deploy"];
+   "Test_rg_validate_validation" [label="validate" shape=box style=dashed];
+  }
+`)
+
+	mermaid, err := MarshalMermaid(g)
+	require.NoError(t, err)
+	assert.Equal(t, `flowchart TD
+  subgraph sg0["Test"]
+    subgraph rg0["test-rg"]
+      n0["deploy"]
+      v0_0{{"validate"}}
+    end
+  end
+`, string(mermaid))
+
+	encoded, err := MarshalJSON(g)
+	require.NoError(t, err)
+	var document JSONGraph
+	require.NoError(t, json.Unmarshal(encoded, &document))
+	assert.Equal(t, []JSONNode{{
+		ID: serviceGroup + "/rg/validate", ServiceGroup: serviceGroup, ResourceGroup: "rg", Step: "validate", Action: "Validation",
+	}}, document.ValidationSteps)
+	assert.Equal(t, []string{}, document.Stamps)
+	assert.Equal(t, []JSONResourceGroup{{Name: "rg", ResourceGroup: "test-rg", Subscription: "sub"}}, document.ResourceGroups)
+}
@@ -88,7 +88,7 @@ type ResourceGroupKey struct {
 	Name  string
 }
 
-func newGraphBuilder() *graphBuilder {
+func newGraphBuilder(opts []Option) *graphBuilder {
 	return &graphBuilder{
 		Graph: &Graph{
 			Services:               map[string]*topology.Service{},
@@ -100,6 +100,8 @@ func newGraphBuilder() *graphBuilder {
 		},
 		nodeIndex:            map[Identifier]int{},
 		resourceGroupStamped: map[string]stampedRecord{},
+		disabled:             map[Identifier][]Identifier{},
+		options:              newOptions(opts),
 	}
 }
 
@@ -116,6 +118,9 @@ type graphBuilder struct {
 	*Graph
 	nodeIndex            map[Identifier]int
 	resourceGroupStamped map[string]stampedRecord
+	// disabled records the steps omitted by the build options, along with the steps their dependents depend on instead.
+	disabled map[Identifier][]Identifier
+	options  *options
 }
 
 // Graph holds a set of nodes, recording parent/child relationships for each, along with a set of lookup tables for
@@ -178,7 +183,7 @@ type stampIteration struct {
 }
 
 // ForPipeline generates a graph for one pipeline, processing all steps therein to determine dependencies between them.
-func ForPipeline(service *topology.Service, pipeline *types.Pipeline) (*Graph, error) {
+func ForPipeline(service *topology.Service, pipeline *types.Pipeline, opts ...Option) (*Graph, error) {
 	withoutChildren := &topology.Service{
 		ServiceGroup: service.ServiceGroup,
 		Purpose:      service.Purpose,
@@ -188,7 +193,7 @@ func ForPipeline(service *topology.Service, pipeline *types.Pipeline) (*Graph, e
 		Stamped:      service.Stamped,
 	}
 
-	graphBuilder := newGraphBuilder()
+	graphBuilder := newGraphBuilder(opts)
 
 	stampPipelines := map[Stamp]map[string]*types.Pipeline{
 		Unstamped(): {pipeline.ServiceGroup: pipeline},
@@ -204,7 +209,7 @@ func ForPipeline(service *topology.Service, pipeline *types.Pipeline) (*Graph, e
 // Like ForPipeline it strips children so the graph covers exactly one pipeline, but unlike ForPipeline it
 // preserves the service's Stamped flag and accepts per-stamp pipelines. Unstamped services produce a single
 // set of nodes with Unstamped; stamped services produce N copies — one per stamp with IsSet() == true.
-func ForStampedPipeline(service *topology.Service, stampPipelines map[Stamp]map[string]*types.Pipeline) (*Graph, error) {
+func ForStampedPipeline(service *topology.Service, stampPipelines map[Stamp]map[string]*types.Pipeline, opts ...Option) (*Graph, error) {
 	withoutChildren := &topology.Service{
 		ServiceGroup: service.ServiceGroup,
 		Purpose:      service.Purpose,
@@ -214,7 +219,7 @@ func ForStampedPipeline(service *topology.Service, stampPipelines map[Stamp]map[
 		Stamped:      service.Stamped,
 	}
 
-	graphBuilder := newGraphBuilder()
+	graphBuilder := newGraphBuilder(opts)
 
 	if err := graphBuilder.accumulate(withoutChildren, stampPipelines); err != nil {
 		return nil, err
@@ -225,16 +230,16 @@ func ForStampedPipeline(service *topology.Service, stampPipelines map[Stamp]map[
 
 // ForEntrypoint generates a graph for all pipelines in the sub-tree of the topology identified by the entrypoint.
 // Convenience wrapper around ForEntrypoints for a single entrypoint.
-func ForEntrypoint(topo *topology.Topology, entrypoint *topology.Entrypoint, pipelines map[string]*types.Pipeline) (*Graph, error) {
-	return ForEntrypoints(topo, []*topology.Entrypoint{entrypoint}, pipelines)
+func ForEntrypoint(topo *topology.Topology, entrypoint *topology.Entrypoint, pipelines map[string]*types.Pipeline, opts ...Option) (*Graph, error) {
+	return ForEntrypoints(topo, []*topology.Entrypoint{entrypoint}, pipelines, opts...)
 }
 
 // ForEntrypoints generates a graph for all pipelines in the sub-trees of the topology identified by the entrypoints.
 // Stamped services in the topology are not expanded — each appears exactly once with Unstamped on its
 // nodes. The resulting graph has one set of nodes per service, making it suitable for contexts where stamp expansion
 // is handled by the runtime (e.g. EV2 rollout specs) rather than the graph itself.
-func ForEntrypoints(topo *topology.Topology, entrypoints []*topology.Entrypoint, pipelines map[string]*types.Pipeline) (*Graph, error) {
-	return forEntrypoints(topo, entrypoints, map[Stamp]map[string]*types.Pipeline{Unstamped(): pipelines})
+func ForEntrypoints(topo *topology.Topology, entrypoints []*topology.Entrypoint, pipelines map[string]*types.Pipeline, opts ...Option) (*Graph, error) {
+	return forEntrypoints(topo, entrypoints, map[Stamp]map[string]*types.Pipeline{Unstamped(): pipelines}, opts)
 }
 
 // ForStampedEntrypoints generates a graph for all pipelines in the sub-trees of the topology identified by the
@@ -242,11 +247,11 @@ func ForEntrypoints(topo *topology.Topology, entrypoints []*topology.Entrypoint,
 // one per stamp with IsSet() == true in stampPipelines — with each copy carrying a distinct Stamp on its identifiers.
 // Unstamped services appear once with Unstamped. The resulting graph is suitable for contexts where the graph
 // itself drives per-stamp execution (e.g. templatize concurrent stamp rollouts).
-func ForStampedEntrypoints(topo *topology.Topology, entrypoints []*topology.Entrypoint, stampPipelines map[Stamp]map[string]*types.Pipeline) (*Graph, error) {
-	return forEntrypoints(topo, entrypoints, stampPipelines)
+func ForStampedEntrypoints(topo *topology.Topology, entrypoints []*topology.Entrypoint, stampPipelines map[Stamp]map[string]*types.Pipeline, opts ...Option) (*Graph, error) {
+	return forEntrypoints(topo, entrypoints, stampPipelines, opts)
 }
 
-func forEntrypoints(topo *topology.Topology, entrypoints []*topology.Entrypoint, stampPipelines map[Stamp]map[string]*types.Pipeline) (*Graph, error) {
+func forEntrypoints(topo *topology.Topology, entrypoints []*topology.Entrypoint, stampPipelines map[Stamp]map[string]*types.Pipeline, opts []Option) (*Graph, error) {
 	var roots []*topology.Service
 	for _, entrypoint := range entrypoints {
 		root, err := topo.Lookup(entrypoint.Identifier)
@@ -256,7 +261,7 @@ func forEntrypoints(topo *topology.Topology, entrypoints []*topology.Entrypoint,
 		roots = append(roots, root)
 	}
 
-	graphBuilder := newGraphBuilder()
+	graphBuilder := newGraphBuilder(opts)
 
 	for _, root := range roots {
 		if err := graphBuilder.accumulate(root, stampPipelines); err != nil {
@@ -366,7 +371,7 @@ func resolveIterations(service *topology.Service, stampPipelines map[Stamp]map[s
 func (c *graphBuilder) accumulateIteration(serviceGroup string, iter stampIteration) ([]Identifier, error) {
 	service := c.Services[serviceGroup]
 
-	resourceGroups, subscription, steps, serviceValidationSteps, nodes, err := nodesFor(iter.pipeline, iter.stamp)
+	resourceGroups, subscription, steps, serviceValidationSteps, nodes, disabled, err := nodesFor(iter.pipeline, iter.stamp, c.options.filterFor(iter.stamp))
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate graph for pipeline %s: %v", serviceGroup, err)
 	}
@@ -402,6 +407,7 @@ func (c *graphBuilder) accumulateIteration(serviceGroup string, iter stampIterat
 		}
 	}
 	maps.Copy(c.ServiceValidationSteps, serviceValidationSteps)
+	maps.Copy(c.disabled, disabled)
 	for _, n := range nodes {
 		c.registerNode(n)
 	}
@@ -615,15 +621,21 @@ func (c *graphBuilder) addExternalDependencyEdges() error {
 			if targetRGStamped {
 				parent.Stamp = node.Stamp
 			}
-			parentNodeIdx, err := c.node(parent)
-			if err != nil {
-				return err
+			parents := []Identifier{parent}
+			if replacements, disabled := c.disabled[parent]; disabled {
+				parents = replacements
+			}
+			for _, parent := range parents {
+				parentNodeIdx, err := c.node(parent)
+				if err != nil {
+					return err
+				}
+				parentNode := c.Nodes[parentNodeIdx]
+				parentNode.Children = deduplicateIdentifiers(append(parentNode.Children, node.Identifier))
+				c.Nodes[parentNodeIdx] = parentNode
+
+				node.Parents = append(node.Parents, parent)
 			}
-			parentNode := c.Nodes[parentNodeIdx]
-			parentNode.Children = deduplicateIdentifiers(append(parentNode.Children, node.Identifier))
-			c.Nodes[parentNodeIdx] = parentNode
-
-			node.Parents = append(node.Parents, parent)
 		}
 		node.Parents = deduplicateIdentifiers(node.Parents)
 		c.Nodes[i] = node
@@ -631,25 +643,38 @@ func (c *graphBuilder) addExternalDependencyEdges() error {
 	return nil
 }
 
-// nodesFor transforms a pipeline to the list of nodes and lookup tables required in a graph
-func nodesFor(pipeline *types.Pipeline, stamp Stamp) (
+// nodesFor transforms a pipeline to the list of nodes and lookup tables required in a graph. Steps rejected by the
+// filter, either by their condition or because their resource group does not apply, are omitted, and recorded along
+// with the steps that their dependents depend on instead.
+func nodesFor(pipeline *types.Pipeline, stamp Stamp, filter *stepFilter) (
 	map[string]*types.ResourceGroupMeta,
 	*Subscription,
 	map[string]map[string]types.Step,
 	map[Identifier]types.ValidationStep,
 	[]Node,
+	map[Identifier][]Identifier,
 	error,
 ) {
 	stepsByResourceGroupAndName := map[string]map[string]types.Step{}
+	disabledSteps := map[types.StepDependency]types.Step{}
 	serviceValidationSteps := map[Identifier]types.ValidationStep{}
 	resourceGroupsByName := map[string]*types.ResourceGroupMeta{}
 	rgStamped := map[string]bool{}
+	excludedGroups := sets.New[string]()
 	var subscription *Subscription
 	for _, rg := range pipeline.ResourceGroups {
 		resourceGroupsByName[rg.Name] = rg.ResourceGroupMeta
 		rgStamped[rg.Name] = rg.Stamped
+		stepsByResourceGroupAndName[rg.Name] = map[string]types.Step{}
+		if !filter.appliesTo(rg.ResourceGroupMeta) {
+			excludedGroups.Insert(rg.Name)
+			for _, step := range rg.Steps {
+				disabledSteps[types.StepDependency{ResourceGroup: rg.Name, Step: step.StepName()}] = step
+			}
+			continue
+		}
 		if rg.SubscriptionProvisioning != nil && subscription != nil {
-			return nil, nil, nil, nil, nil, fmt.Errorf("multiple subscriptions found for pipeline %s", pipeline.ServiceGroup)
+			return nil, nil, nil, nil, nil, nil, fmt.Errorf("multiple subscriptions found for pipeline %s", pipeline.ServiceGroup)
 		}
 		if rg.SubscriptionProvisioning != nil {
 			subscription = &Subscription{
@@ -658,11 +683,25 @@ func nodesFor(pipeline *types.Pipeline, stamp Stamp) (
 				Config:        *rg.SubscriptionProvisioning,
 			}
 		}
-		stepsByResourceGroupAndName[rg.Name] = map[string]types.Step{}
 		for _, step := range rg.Steps {
+			enabled, err := filter.enabled(step)
+			if err != nil {
+				return nil, nil, nil, nil, nil, nil, fmt.Errorf("resource group %s: %w", rg.Name, err)
+			}
+			if !enabled {
+				disabledSteps[types.StepDependency{ResourceGroup: rg.Name, Step: step.StepName()}] = step
+				continue
+			}
 			stepsByResourceGroupAndName[rg.Name][step.StepName()] = step
 		}
 		for _, step := range rg.ValidationSteps {
+			enabled, err := filter.enabled(step)
+			if err != nil {
+				return nil, nil, nil, nil, nil, nil, fmt.Errorf("resource group %s: %w", rg.Name, err)
+			}
+			if !enabled {
+				continue
+			}
 			serviceValidationSteps[Identifier{
 				Stamp:        stamp.If(rg.Stamped),
 				ServiceGroup: pipeline.ServiceGroup,
@@ -674,10 +713,52 @@ func nodesFor(pipeline *types.Pipeline, stamp Stamp) (
 		}
 	}
 
+	// dependents of a disabled step depend on the steps it depended on instead, transitively
+	var resolve func(deps []types.StepDependency, visited sets.Set[types.StepDependency]) []types.StepDependency
+	resolve = func(deps []types.StepDependency, visited sets.Set[types.StepDependency]) []types.StepDependency {
+		var resolved []types.StepDependency
+		for _, dep := range deps {
+			disabledStep, isDisabled := disabledSteps[dep]
+			if !isDisabled {
+				resolved = append(resolved, dep)
+				continue
+			}
+			if visited.Has(dep) {
+				continue
+			}
+			visited.Insert(dep)
+			resolved = append(resolved, resolve(slices.Concat(disabledStep.Dependencies(), disabledStep.RequiredInputs()), visited)...)
+		}
+		return resolved
+	}
+	identifier := func(dep types.StepDependency) Identifier {
+		return Identifier{Stamp: stamp.If(rgStamped[dep.ResourceGroup]), ServiceGroup: pipeline.ServiceGroup, StepDependency: dep}
+	}
+	disabled := map[Identifier][]Identifier{}
+	for dep := range disabledSteps {
+		var replacements []Identifier
+		for _, replacement := range resolve([]types.StepDependency{dep}, sets.New[types.StepDependency]()) {
+			replacements = append(replacements, identifier(replacement))
+		}
+		disabled[identifier(dep)] = deduplicateIdentifiers(replacements)
+	}
+
 	var stepDependencies []edge
 	for _, rg := range pipeline.ResourceGroups {
 		for _, step := range rg.Steps {
-			dependsOn := append(step.Dependencies(), step.RequiredInputs()...)
+			if _, isDisabled := disabledSteps[types.StepDependency{ResourceGroup: rg.Name, Step: step.StepName()}]; isDisabled {
+				continue
+			}
+			for _, input := range step.RequiredInputs() {
+				if _, isDisabled := disabledSteps[input]; !isDisabled {
+					continue
+				}
+				if excludedGroups.Has(input.ResourceGroup) {
+					return nil, nil, nil, nil, nil, nil, fmt.Errorf("step %s/%s consumes outputs of step %s/%s, whose resource group does not apply to this context", rg.Name, step.StepName(), input.ResourceGroup, input.Step)
+				}
+				return nil, nil, nil, nil, nil, nil, fmt.Errorf("step %s/%s consumes outputs of step %s/%s, which is disabled by its condition", rg.Name, step.StepName(), input.ResourceGroup, input.Step)
+			}
+			dependsOn := resolve(append(step.Dependencies(), step.RequiredInputs()...), sets.New[types.StepDependency]())
 			slices.SortFunc(dependsOn, CompareStepDependencies)
 			dependsOn = slices.Compact(dependsOn)
 
@@ -741,7 +822,7 @@ func nodesFor(pipeline *types.Pipeline, stamp Stamp) (
 		return CompareDependencies(a.Identifier, b.Identifier)
 	})
 
-	return resourceGroupsByName, subscription, stepsByResourceGroupAndName, serviceValidationSteps, nodes, nil
+	return resourceGroupsByName, subscription, stepsByResourceGroupAndName, serviceValidationSteps, nodes, disabled, nil
 }
 
 func deduplicateIdentifiers(ids []Identifier) []Identifier {
@@ -796,6 +877,9 @@ func resourceGroupMetaEqual(a, b *types.ResourceGroupMeta) bool {
 		if !sets.New(ac.Regions...).Equal(sets.New(bc.Regions...)) {
 			return false
 		}
+		if !sets.New(ac.Stamps...).Equal(sets.New(bc.Stamps...)) {
+			return false
+		}
 	}
 
 	return true
@@ -867,6 +951,16 @@ const graphSuffix = `}`
 // MarshalDOT marshals the graph into the DOT notation used by the graphviz library.
 // See documentation here: https://graphviz.gitlab.io/doc/info/lang.html
 func MarshalDOT(g *Graph) ([]byte, error) {
+	return marshalDOT(g, dotStyle{})
+}
+
+// dotStyle customizes how nodes and edges are drawn, returning extra DOT attributes for each, if any.
+type dotStyle struct {
+	node func(id Identifier) string
+	edge func(from, to Identifier) string
+}
+
+func marshalDOT(g *Graph, style dotStyle) ([]byte, error) {
 	out := bytes.Buffer{}
 	if n, err := out.WriteString(graphPrefix); err != nil || n != len(graphPrefix) {
 		return nil, fmt.Errorf("failed to write graph prefix: wrote %d/%d bytes: %w", n, len(graphPrefix), err)
@@ -886,6 +980,11 @@ func MarshalDOT(g *Graph) ([]byte, error) {
 		if color, ok := stampColors[node.Stamp]; ok {
 			attrs += fmt.Sprintf(" style=filled fillcolor=\"%s\"", color)
 		}
+		if style.node != nil {
+			if extra := style.node(node.Identifier); extra != "" {
+				attrs += " " + extra
+			}
+		}
 		if _, err := fmt.Fprintf(&out, " \"%s\" [%s];\n", nodeID, attrs); err != nil {
 			return nil, err
 		}
@@ -897,7 +996,13 @@ func MarshalDOT(g *Graph) ([]byte, error) {
 			}
 
 			childID := dotID(childServiceGroup, child)
-			if _, err := fmt.Fprintf(&out, " \"%s\" -> \"%s\";\n", nodeID, childID); err != nil {
+			var edgeAttrs string
+			if style.edge != nil {
+				if extra := style.edge(node.Identifier, child); extra != "" {
+					edgeAttrs = fmt.Sprintf(" [%s]", extra)
+				}
+			}
+			if _, err := fmt.Fprintf(&out, " \"%s\" -> \"%s\"%s;\n", nodeID, childID, edgeAttrs); err != nil {
 				return nil, err
 			}
 		}
@@ -918,6 +918,15 @@ func TestForPipelineStampedService(t *testing.T) {
 //   - stamped child with mixed RGs: ACM has unstamped root (global/output) + stamped steps (deploy-mce);
 //     both stamp leaves of Management.Infra must wire to the single unstamped ACM root
 func TestStampedEntrypointDOT(t *testing.T) {
+	encoded, err := MarshalDOT(stampedEntrypointGraph(t))
+	assert.NoError(t, err)
+
+	testutil.CompareWithFixture(t, encoded, testutil.WithExtension(".dot"))
+}
+
+// stampedEntrypointGraph builds the graph described for TestStampedEntrypointDOT.
+func stampedEntrypointGraph(t *testing.T) *Graph {
+	t.Helper()
 	deploy := &types.ShellStep{StepMeta: types.StepMeta{Name: "deploy"}}
 
 	mgmtLookup := &types.ShellStep{StepMeta: types.StepMeta{Name: "lookup"}}
@@ -1023,12 +1032,8 @@ func TestStampedEntrypointDOT(t *testing.T) {
 
 	entrypoint := &topo.Entrypoints[0]
 	result, err := ForStampedEntrypoints(topo, []*topology.Entrypoint{entrypoint}, stampPipelines)
-	assert.NoError(t, err)
-
-	encoded, err := MarshalDOT(result)
-	assert.NoError(t, err)
-
-	testutil.CompareWithFixture(t, encoded, testutil.WithExtension(".dot"))
+	require.NoError(t, err)
+	return result
 }
 
 func TestForStampedPipeline(t *testing.T) {
@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// Option customizes how a graph is built.
+type Option func(*options)
+
+type options struct {
+	conditions *types.ConditionEvaluator
+	context    *executionContext
+}
+
+// executionContext identifies where a graph is deployed, for evaluating the execution constraints of resource groups.
+type executionContext struct {
+	cloud, environment, region string
+}
+
+// WithConditions omits steps whose when condition evaluates to false in the evaluator's context. Dependents of an
+// omitted step depend on the steps it depended on instead, so disabling a step never disconnects the graph. Stamped
+// services are evaluated once per stamp, with ctx.stamp set to the stamp being expanded.
+func WithConditions(evaluator *types.ConditionEvaluator) Option {
+	return func(o *options) {
+		o.conditions = evaluator
+	}
+}
+
+// WithExecutionContext omits resource groups whose execution constraints exclude the cloud, environment and region,
+// as determined by types.ResourceGroupMeta.AppliesTo. Steps in an omitted resource group are handled like steps
+// disabled by their condition. Stamped services are evaluated once per stamp, so that stamp constraints apply to
+// the stamp being expanded.
+func WithExecutionContext(cloud, environment, region string) Option {
+	return func(o *options) {
+		o.context = &executionContext{cloud: cloud, environment: environment, region: region}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// stepFilter determines which resource groups and steps are part of the graph.
+type stepFilter struct {
+	conditions *types.ConditionEvaluator
+	context    *executionContext
+	stamp      string
+}
+
+// filterFor creates the filter for steps expanded for the stamp.
+func (o *options) filterFor(stamp Stamp) *stepFilter {
+	filter := &stepFilter{conditions: o.conditions, context: o.context, stamp: stamp.String()}
+	if filter.conditions != nil && stamp.IsSet() {
+		filter.conditions = filter.conditions.ForStamp(stamp.String())
+	}
+	return filter
+}
+
+// appliesTo determines whether the resource group is deployed in the execution context.
+func (f *stepFilter) appliesTo(rg *types.ResourceGroupMeta) bool {
+	if f.context == nil {
+		return true
+	}
+	return rg.AppliesTo(f.context.cloud, f.context.environment, f.context.region, f.stamp)
+}
+
+// enabled determines whether the step's condition holds.
+func (f *stepFilter) enabled(step types.Step) (bool, error) {
+	if f.conditions == nil {
+		return true, nil
+	}
+	return f.conditions.Enabled(step)
+}
@@ -0,0 +1,197 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+func conditionalStep(name, when string, dependsOn ...string) *types.ShellStep {
+	step := &types.ShellStep{StepMeta: types.StepMeta{Name: name, When: when}}
+	for _, dep := range dependsOn {
+		step.DependsOn = append(step.DependsOn, types.StepDependency{ResourceGroup: "rg", Step: dep})
+	}
+	return step
+}
+
+func identifiers(serviceGroup string, stamp Stamp, steps ...string) []Identifier {
+	var ids []Identifier
+	for _, step := range steps {
+		ids = append(ids, Identifier{Stamp: stamp, ServiceGroup: serviceGroup, StepDependency: types.StepDependency{ResourceGroup: "rg", Step: step}})
+	}
+	return ids
+}
+
+func TestWithConditions(t *testing.T) {
+	evaluator := types.NewConditionEvaluator(
+		types2.Configuration{"feature": map[string]any{"enabled": false}},
+		&config.ConfigReplacements{CloudReplacement: "public", StampReplacement: "1"},
+	)
+	service := &topology.Service{ServiceGroup: "SG", PipelinePath: "pipeline.yaml"}
+
+	t.Run("disabled steps are removed and dependents rewired", func(t *testing.T) {
+		pipeline := makePipeline("SG", "rg", false,
+			conditionalStep("first", ""),
+			conditionalStep("second", `ctx.cloud == "public"`),
+			conditionalStep("feature", "config.feature.enabled", "first", "second"),
+			conditionalStep("nested", "config.feature.enabled", "feature"),
+			conditionalStep("last", "", "nested"),
+		)
+		result, err := ForPipeline(service, pipeline, WithConditions(evaluator))
+		require.NoError(t, err)
+
+		require.Len(t, result.Nodes, 3)
+		assert.Equal(t, identifiers("SG", Unstamped(), "first", "last", "second"), []Identifier{result.Nodes[0].Identifier, result.Nodes[1].Identifier, result.Nodes[2].Identifier})
+		assert.Equal(t, identifiers("SG", Unstamped(), "first", "second"), result.Nodes[1].Parents)
+		assert.Equal(t, identifiers("SG", Unstamped(), "last"), result.Nodes[0].Children)
+		_, exists := result.GetStep(identifiers("SG", Unstamped(), "feature")[0])
+		assert.False(t, exists)
+
+		// without conditions, every step is part of the graph
+		result, err = ForPipeline(service, pipeline)
+		require.NoError(t, err)
+		assert.Len(t, result.Nodes, 5)
+	})
+
+	t.Run("stamped services evaluate conditions per stamp", func(t *testing.T) {
+		stamped := &topology.Service{ServiceGroup: "SG", PipelinePath: "pipeline.yaml", Stamped: ptr(true)}
+		pipeline := makePipeline("SG", "rg", true,
+			conditionalStep("deploy", ""),
+			conditionalStep("canary", `ctx.stamp == "1"`, "deploy"),
+		)
+		result, err := ForStampedPipeline(stamped, map[Stamp]map[string]*types.Pipeline{
+			mustStamp("1"): {"SG": pipeline},
+			mustStamp("2"): {"SG": pipeline},
+		}, WithConditions(evaluator))
+		require.NoError(t, err)
+
+		var nodes []Identifier
+		for _, node := range result.Nodes {
+			nodes = append(nodes, node.Identifier)
+		}
+		assert.ElementsMatch(t, append(identifiers("SG", mustStamp("1"), "canary", "deploy"), identifiers("SG", mustStamp("2"), "deploy")...), nodes)
+	})
+
+	t.Run("consuming outputs of a disabled step fails", func(t *testing.T) {
+		consumer := conditionalStep("consumer", "")
+		consumer.Variables = []types.Variable{{Name: "OUTPUT", Value: types.Value{Input: &types.Input{
+			StepDependency: types.StepDependency{ResourceGroup: "rg", Step: "feature"},
+			Name:           "output",
+		}}}}
+		pipeline := makePipeline("SG", "rg", false, conditionalStep("feature", "config.feature.enabled"), consumer)
+		_, err := ForPipeline(service, pipeline, WithConditions(evaluator))
+		require.ErrorContains(t, err, "step rg/consumer consumes outputs of step rg/feature, which is disabled by its condition")
+	})
+
+	t.Run("invalid conditions fail", func(t *testing.T) {
+		pipeline := makePipeline("SG", "rg", false, conditionalStep("feature", "config.missing.key"))
+		_, err := ForPipeline(service, pipeline, WithConditions(evaluator))
+		require.ErrorContains(t, err, `step feature: failed to evaluate CEL expression "config.missing.key"`)
+	})
+
+	t.Run("external dependencies on disabled steps are rewired", func(t *testing.T) {
+		topo := makeTopology(topology.Service{
+			ServiceGroup: "SG.Parent", PipelinePath: "parent.yaml",
+			Children: []topology.Service{{ServiceGroup: "SG.Child", PipelinePath: "child.yaml"}},
+		})
+		child := &types.ShellStep{StepMeta: types.StepMeta{
+			Name: "deploy",
+			ExternalDependsOn: []types.ExternalStepDependency{
+				{ServiceGroup: "SG.Parent", StepDependency: types.StepDependency{ResourceGroup: "rg", Step: "feature"}},
+			},
+		}}
+		pipelines := map[string]*types.Pipeline{
+			"SG.Parent": makePipeline("SG.Parent", "rg", false,
+				conditionalStep("first", ""),
+				conditionalStep("feature", "config.feature.enabled", "first"),
+				conditionalStep("other", "", "first"),
+			),
+			"SG.Child": makePipeline("SG.Child", "child-rg", false, child),
+		}
+		result, err := ForEntrypoint(topo, &topo.Entrypoints[0], pipelines, WithConditions(evaluator))
+		require.NoError(t, err)
+
+		childNodes := nodesForSG(result, "SG.Child")
+		require.Len(t, childNodes, 1)
+		assert.Equal(t, identifiers("SG.Parent", Unstamped(), "first", "other"), childNodes[0].Parents)
+	})
+}
+
+func TestWithExecutionContext(t *testing.T) {
+	service := &topology.Service{ServiceGroup: "SG", PipelinePath: "pipeline.yaml", Stamped: ptr(true)}
+	last := conditionalStep("last", "", "first")
+	last.DependsOn = append(last.DependsOn, types.StepDependency{ResourceGroup: "public", Step: "deploy"})
+	pipeline := makePipeline("SG", "rg", false, conditionalStep("first", ""), last)
+	pipeline.ResourceGroups = append(pipeline.ResourceGroups,
+		&types.ResourceGroup{
+			ResourceGroupMeta: &types.ResourceGroupMeta{
+				Name: "public", ResourceGroup: "public", Subscription: "sub",
+				ExecutionConstraints: []types.ExecutionConstraint{{Clouds: []string{"public"}}},
+			},
+			Steps: []types.Step{conditionalStep("deploy", "", "first")},
+		},
+		&types.ResourceGroup{
+			ResourceGroupMeta: &types.ResourceGroupMeta{
+				Name: "canary", ResourceGroup: "canary", Subscription: "sub", Stamped: true,
+				ExecutionConstraints: []types.ExecutionConstraint{{Stamps: []string{"1"}}},
+			},
+			Steps: []types.Step{conditionalStep("canary", "", "first")},
+		},
+	)
+	stampPipelines := map[Stamp]map[string]*types.Pipeline{
+		mustStamp("1"): {"SG": pipeline},
+		mustStamp("2"): {"SG": pipeline},
+	}
+	nodeIDs := func(result *Graph) []string {
+		var ids []string
+		for _, node := range result.Nodes {
+			ids = append(ids, node.String())
+		}
+		return ids
+	}
+
+	t.Run("constraints matching the context keep resource groups", func(t *testing.T) {
+		result, err := ForStampedPipeline(service, stampPipelines, WithExecutionContext("public", "int", "eastus"))
+		require.NoError(t, err)
+		assert.Len(t, result.Nodes, 4)
+
+		lastNode := slices.IndexFunc(result.Nodes, func(node Node) bool { return node.Step == "last" })
+		require.NotEqual(t, -1, lastNode)
+		assert.Len(t, result.Nodes[lastNode].Parents, 2)
+	})
+
+	t.Run("constraints excluding the context omit resource groups", func(t *testing.T) {
+		result, err := ForStampedPipeline(service, stampPipelines, WithExecutionContext("ff", "int", "usgovvirginia"))
+		require.NoError(t, err)
+		_, exists := result.GetStep(Identifier{ServiceGroup: "SG", StepDependency: types.StepDependency{ResourceGroup: "public", Step: "deploy"}})
+		assert.False(t, exists)
+		_, exists = result.GetStep(Identifier{Stamp: mustStamp("1"), ServiceGroup: "SG", StepDependency: types.StepDependency{ResourceGroup: "canary", Step: "canary"}})
+		assert.True(t, exists)
+		_, exists = result.GetStep(Identifier{Stamp: mustStamp("2"), ServiceGroup: "SG", StepDependency: types.StepDependency{ResourceGroup: "canary", Step: "canary"}})
+		assert.False(t, exists)
+		assert.Len(t, result.Nodes, 3, nodeIDs(result))
+
+		lastNode := slices.IndexFunc(result.Nodes, func(node Node) bool { return node.Step == "last" })
+		require.NotEqual(t, -1, lastNode)
+		assert.Equal(t, identifiers("SG", Unstamped(), "first"), result.Nodes[lastNode].Parents)
+	})
+
+	t.Run("consuming outputs of an omitted resource group fails", func(t *testing.T) {
+		consumer := conditionalStep("consumer", "")
+		consumer.Variables = []types.Variable{{Name: "OUTPUT", Value: types.Value{Input: &types.Input{
+			StepDependency: types.StepDependency{ResourceGroup: "public", Step: "deploy"},
+			Name:           "output",
+		}}}}
+		consuming := makePipeline("SG", "rg", false, conditionalStep("first", ""), consumer)
+		consuming.ResourceGroups = append(consuming.ResourceGroups, pipeline.ResourceGroups[1])
+		_, err := ForPipeline(&topology.Service{ServiceGroup: "SG", PipelinePath: "pipeline.yaml"}, consuming, WithExecutionContext("ff", "int", "usgovvirginia"))
+		require.ErrorContains(t, err, "step rg/consumer consumes outputs of step public/deploy, whose resource group does not apply to this context")
+	})
+}
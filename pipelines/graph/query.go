@@ -0,0 +1,214 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// index creates a lookup table of nodes by identifier.
+func (c *Graph) index() map[Identifier]*Node {
+	nodesByID := make(map[Identifier]*Node, len(c.Nodes))
+	for i := range c.Nodes {
+		nodesByID[c.Nodes[i].Identifier] = &c.Nodes[i]
+	}
+	return nodesByID
+}
+
+// reachable collects every node reachable from the roots by repeatedly following next, excluding the roots themselves.
+func reachable(nodesByID map[Identifier]*Node, roots []Identifier, next func(*Node) []Identifier) ([]Identifier, error) {
+	visited := sets.New[Identifier]()
+	queue := slices.Clone(roots)
+	for _, root := range roots {
+		if _, exists := nodesByID[root]; !exists {
+			return nil, fmt.Errorf("node %s not found in graph", root)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, neighbour := range next(nodesByID[id]) {
+			if visited.Has(neighbour) {
+				continue
+			}
+			if _, exists := nodesByID[neighbour]; !exists {
+				return nil, fmt.Errorf("node %s references %s, which is not found in graph", id, neighbour)
+			}
+			visited.Insert(neighbour)
+			queue = append(queue, neighbour)
+		}
+	}
+	ids := visited.UnsortedList()
+	slices.SortFunc(ids, CompareDependencies)
+	return ids, nil
+}
+
+// Ancestors lists every step that must run before the node, directly or transitively, in sorted order.
+func (c *Graph) Ancestors(id Identifier) ([]Identifier, error) {
+	return reachable(c.index(), []Identifier{id}, func(n *Node) []Identifier { return n.Parents })
+}
+
+// Descendants lists every step that runs after the node, directly or transitively, in sorted order.
+func (c *Graph) Descendants(id Identifier) ([]Identifier, error) {
+	return reachable(c.index(), []Identifier{id}, func(n *Node) []Identifier { return n.Children })
+}
+
+// ImpactOf lists the steps that need to run again when the changed steps change: the changed steps themselves,
+// along with all of their descendants, in sorted order.
+func (c *Graph) ImpactOf(changed []Identifier) ([]Identifier, error) {
+	descendants, err := reachable(c.index(), changed, func(n *Node) []Identifier { return n.Children })
+	if err != nil {
+		return nil, err
+	}
+	return deduplicateIdentifiers(append(descendants, changed...)), nil
+}
+
+// Levels groups the nodes into levels, such that every node runs after all nodes in previous levels that it depends
+// on. Nodes in the same level do not depend on each other and may run in parallel. Each node is placed in the
+// earliest level possible, so the first level holds the roots of the graph; nodes within a level are sorted.
+func (c *Graph) Levels() ([][]Identifier, error) {
+	order, err := c.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+	nodesByID := c.index()
+	depth := make(map[Identifier]int, len(order))
+	var levels [][]Identifier
+	for _, id := range order {
+		level := 0
+		for _, parent := range nodesByID[id].Parents {
+			level = max(level, depth[parent]+1)
+		}
+		depth[id] = level
+		if level == len(levels) {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], id)
+	}
+	for _, level := range levels {
+		slices.SortFunc(level, CompareDependencies)
+	}
+	return levels, nil
+}
+
+// TopologicalOrder orders the nodes such that every node comes after all of its parents. Ties are broken by sorting
+// identifiers, so the order is stable for a given graph.
+func (c *Graph) TopologicalOrder() ([]Identifier, error) {
+	nodesByID := c.index()
+	inDegree := make(map[Identifier]int, len(c.Nodes))
+	var ready []Identifier
+	for _, node := range c.Nodes {
+		for _, parent := range node.Parents {
+			if _, exists := nodesByID[parent]; !exists {
+				return nil, fmt.Errorf("node %s references %s, which is not found in graph", node.Identifier, parent)
+			}
+		}
+		inDegree[node.Identifier] = len(node.Parents)
+		if len(node.Parents) == 0 {
+			ready = append(ready, node.Identifier)
+		}
+	}
+
+	order := make([]Identifier, 0, len(c.Nodes))
+	for len(ready) > 0 {
+		slices.SortFunc(ready, CompareDependencies)
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, child := range nodesByID[id].Children {
+			inDegree[child]--
+			if inDegree[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+	if len(order) != len(c.Nodes) {
+		return nil, c.detectCycles()
+	}
+	return order, nil
+}
+
+// Weight determines how long a step is expected to take, for use in critical path analysis.
+type Weight func(id Identifier, step types.Step) (time.Duration, error)
+
+// TimeoutWeight weighs steps by their timeout, which bounds how long they may take. Steps that do not declare a
+// timeout are weighed with the fallback.
+func TimeoutWeight(fallback time.Duration) Weight {
+	return func(_ Identifier, step types.Step) (time.Duration, error) {
+		timeout, declared, err := types.StepTimeout(step)
+		if err != nil {
+			return 0, err
+		}
+		if !declared {
+			return fallback, nil
+		}
+		return timeout, nil
+	}
+}
+
+// HistoricalWeight weighs steps by how long they took previously. Steps without a recorded duration are weighed
+// with the fallback.
+func HistoricalWeight(durations map[Identifier]time.Duration, fallback Weight) Weight {
+	return func(id Identifier, step types.Step) (time.Duration, error) {
+		if duration, recorded := durations[id]; recorded {
+			return duration, nil
+		}
+		return fallback(id, step)
+	}
+}
+
+// CriticalPath is the longest chain of dependent steps in a graph, which bounds how quickly the graph can execute.
+type CriticalPath struct {
+	// Steps lists the steps along the path, in execution order.
+	Steps []Identifier
+	// Duration is the sum of the weights of the steps along the path.
+	Duration time.Duration
+}
+
+// CriticalPath finds the chain of dependent steps with the largest total weight. Ties are broken deterministically,
+// preferring steps that sort first.
+func (c *Graph) CriticalPath(weight Weight) (*CriticalPath, error) {
+	order, err := c.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+	nodesByID := c.index()
+	finish := make(map[Identifier]time.Duration, len(order))
+	previous := map[Identifier]Identifier{}
+	path := &CriticalPath{}
+	var last Identifier
+	for i, id := range order {
+		step, exists := c.GetStep(id)
+		if !exists {
+			return nil, fmt.Errorf("step %s not found", id)
+		}
+		duration, err := weight(id, step)
+		if err != nil {
+			return nil, fmt.Errorf("failed to weigh step %s: %w", id, err)
+		}
+		var start time.Duration
+		for _, parent := range nodesByID[id].Parents {
+			if _, chosen := previous[id]; !chosen || finish[parent] > start {
+				start = finish[parent]
+				previous[id] = parent
+			}
+		}
+		finish[id] = start + duration
+		if i == 0 || finish[id] > path.Duration {
+			path.Duration = finish[id]
+			last = id
+		}
+	}
+	if len(order) == 0 {
+		return path, nil
+	}
+	for id, hasParent := last, true; hasParent; id, hasParent = previous[id] {
+		path.Steps = append(path.Steps, id)
+	}
+	slices.Reverse(path.Steps)
+	return path, nil
+}
@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// queryGraph builds a diamond, along with a step independent of it:
+//
+//	setup -> infra -> deploy
+//	setup -> image -> deploy
+//	docs
+func queryGraph(t *testing.T) *Graph {
+	t.Helper()
+	timed := func(step *types.ShellStep, timeout string) *types.ShellStep {
+		step.Timeout = timeout
+		return step
+	}
+	pipeline := makePipeline("SG", "rg", false,
+		timed(conditionalStep("setup", ""), "5m"),
+		timed(conditionalStep("infra", "", "setup"), "30m"),
+		timed(conditionalStep("image", "", "setup"), "10m"),
+		conditionalStep("deploy", "", "infra", "image"),
+		timed(conditionalStep("docs", ""), "20m"),
+	)
+	g, err := ForPipeline(&topology.Service{ServiceGroup: "SG", PipelinePath: "pipeline.yaml"}, pipeline)
+	require.NoError(t, err)
+	return g
+}
+
+func step(name string) Identifier {
+	return identifiers("SG", Unstamped(), name)[0]
+}
+
+func TestGraphQueries(t *testing.T) {
+	g := queryGraph(t)
+
+	ancestors, err := g.Ancestors(step("deploy"))
+	require.NoError(t, err)
+	assert.Equal(t, identifiers("SG", Unstamped(), "image", "infra", "setup"), ancestors)
+
+	descendants, err := g.Descendants(step("setup"))
+	require.NoError(t, err)
+	assert.Equal(t, identifiers("SG", Unstamped(), "deploy", "image", "infra"), descendants)
+
+	descendants, err = g.Descendants(step("deploy"))
+	require.NoError(t, err)
+	assert.Empty(t, descendants)
+
+	impact, err := g.ImpactOf([]Identifier{step("infra"), step("docs")})
+	require.NoError(t, err)
+	assert.Equal(t, identifiers("SG", Unstamped(), "deploy", "docs", "infra"), impact)
+
+	order, err := g.TopologicalOrder()
+	require.NoError(t, err)
+	assert.Equal(t, identifiers("SG", Unstamped(), "docs", "setup", "image", "infra", "deploy"), order)
+
+	levels, err := g.Levels()
+	require.NoError(t, err)
+	assert.Equal(t, [][]Identifier{
+		identifiers("SG", Unstamped(), "docs", "setup"),
+		identifiers("SG", Unstamped(), "image", "infra"),
+		identifiers("SG", Unstamped(), "deploy"),
+	}, levels)
+
+	_, err = g.Ancestors(step("missing"))
+	require.EqualError(t, err, "node SG/rg/missing not found in graph")
+}
+
+func TestCriticalPath(t *testing.T) {
+	g := queryGraph(t)
+
+	path, err := g.CriticalPath(TimeoutWeight(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, &CriticalPath{
+		Steps:    identifiers("SG", Unstamped(), "setup", "infra", "deploy"),
+		Duration: 36 * time.Minute,
+	}, path)
+
+	// historical durations take precedence over timeouts
+	path, err = g.CriticalPath(HistoricalWeight(map[Identifier]time.Duration{
+		step("docs"):  2 * time.Minute,
+		step("image"): 45 * time.Minute,
+	}, TimeoutWeight(time.Minute)))
+	require.NoError(t, err)
+	assert.Equal(t, &CriticalPath{
+		Steps:    identifiers("SG", Unstamped(), "setup", "image", "deploy"),
+		Duration: 51 * time.Minute,
+	}, path)
+
+	path, err = g.CriticalPath(TimeoutWeight(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, identifiers("SG", Unstamped(), "setup", "infra", "deploy"), path.Steps)
+	assert.Equal(t, 2*time.Hour+35*time.Minute, path.Duration)
+
+	g.Steps[step("docs")].(*types.ShellStep).Timeout = "forever"
+	_, err = g.CriticalPath(TimeoutWeight(time.Minute))
+	require.ErrorContains(t, err, `failed to weigh step SG/rg/docs: step docs: invalid timeout "forever"`)
+}
+
+func TestTopologicalOrderCycle(t *testing.T) {
+	a, b := step("a"), step("b")
+	g := &Graph{
+		Nodes: []Node{
+			{Identifier: a, Parents: []Identifier{b}, Children: []Identifier{b}},
+			{Identifier: b, Parents: []Identifier{a}, Children: []Identifier{a}},
+		},
+	}
+	_, err := g.TopologicalOrder()
+	require.ErrorContains(t, err, "cycle detected")
+}
@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// StampResolver creates the configuration resolver for a stamp. Resolvers render the stamp into the configuration
+// when they are created, so a resolver is needed for each stamp.
+type StampResolver func(stamp Stamp) (config.ConfigResolver, error)
+
+// ProviderStampResolver creates resolvers from the provider, using the replacements with the stamp replacement set
+// to each stamp in turn.
+func ProviderStampResolver(provider config.ConfigProvider, replacements config.ConfigReplacements) StampResolver {
+	return func(stamp Stamp) (config.ConfigResolver, error) {
+		stamped := replacements
+		stamped.StampReplacement = stamp.String()
+		return provider.GetResolver(&stamped)
+	}
+}
+
+// LoadStampedPipelines loads the pipeline once for each stamp, using the configuration for the region as resolved for
+// that stamp, and checks that the pipelines are consistent across stamps. The result is suitable for
+// ForStampedPipeline and ForStampedEntrypoints.
+func LoadStampedPipelines(pipelinePath string, resolve StampResolver, region string, stamps []Stamp, opts ...types.PipelineOption) (map[Stamp]map[string]*types.Pipeline, error) {
+	if len(stamps) == 0 {
+		return nil, fmt.Errorf("at least one stamp must be provided")
+	}
+	pipelines := map[Stamp]*types.Pipeline{}
+	for _, stamp := range stamps {
+		if !stamp.IsSet() {
+			return nil, fmt.Errorf("stamps must be set, use ForPipeline for unstamped pipelines")
+		}
+		if _, duplicate := pipelines[stamp]; duplicate {
+			return nil, fmt.Errorf("stamp %s provided more than once", stamp)
+		}
+		resolver, err := resolve(stamp)
+		if err != nil {
+			return nil, fmt.Errorf("stamp %s: failed to get configuration resolver: %w", stamp, err)
+		}
+		cfg, err := resolver.GetRegionConfiguration(region)
+		if err != nil {
+			return nil, fmt.Errorf("stamp %s: failed to resolve configuration for region %s: %w", stamp, region, err)
+		}
+		pipeline, err := types.NewPipelineFromFile(pipelinePath, cfg, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("stamp %s: %w", stamp, err)
+		}
+		pipelines[stamp] = pipeline
+	}
+	if err := validateStampConsistency(pipelines, stamps); err != nil {
+		return nil, err
+	}
+	stampPipelines := map[Stamp]map[string]*types.Pipeline{}
+	for stamp, pipeline := range pipelines {
+		stampPipelines[stamp] = map[string]*types.Pipeline{pipeline.ServiceGroup: pipeline}
+	}
+	return stampPipelines, nil
+}
+
+// ForStampedPipelineFile loads the service's pipeline once for each stamp with LoadStampedPipelines, passing on
+// pipelineOpts, and generates the graph for it with ForStampedPipeline.
+func ForStampedPipelineFile(service *topology.Service, pipelinePath string, resolve StampResolver, region string, stamps []Stamp, pipelineOpts []types.PipelineOption, opts ...Option) (*Graph, error) {
+	stampPipelines, err := LoadStampedPipelines(pipelinePath, resolve, region, stamps, pipelineOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := stampPipelines[stamps[0]][service.ServiceGroup]; !exists {
+		return nil, fmt.Errorf("pipeline %s does not define service group %s", pipelinePath, service.ServiceGroup)
+	}
+	return ForStampedPipeline(service, stampPipelines, opts...)
+}
+
+// validateStampConsistency checks that every stamp's pipeline is for the same service group and defines the same
+// resource groups, and that each resource group is stamped the same way and defines the same steps in every stamp.
+// Unstamped resource groups are shared by every stamp, so their metadata must also match exactly.
+func validateStampConsistency(pipelines map[Stamp]*types.Pipeline, stamps []Stamp) error {
+	first := stamps[0]
+	reference := pipelines[first]
+	referenceGroups := resourceGroupsByName(reference)
+
+	var errs []error
+	for _, stamp := range stamps[1:] {
+		pipeline := pipelines[stamp]
+		if pipeline.ServiceGroup != reference.ServiceGroup {
+			errs = append(errs, fmt.Errorf("stamp %s: pipeline is for service group %s, but for %s in stamp %s", stamp, pipeline.ServiceGroup, reference.ServiceGroup, first))
+			continue
+		}
+		groups := resourceGroupsByName(pipeline)
+		referenceNames, names := sets.KeySet(referenceGroups), sets.KeySet(groups)
+		if missing := referenceNames.Difference(names); missing.Len() > 0 {
+			errs = append(errs, fmt.Errorf("stamp %s: resource groups %s defined in stamp %s are missing", stamp, strings.Join(sets.List(missing), ", "), first))
+		}
+		if extra := names.Difference(referenceNames); extra.Len() > 0 {
+			errs = append(errs, fmt.Errorf("stamp %s: resource groups %s are not defined in stamp %s", stamp, strings.Join(sets.List(extra), ", "), first))
+		}
+		for _, name := range sets.List(referenceNames.Intersection(names)) {
+			expected, actual := referenceGroups[name], groups[name]
+			if expected.Stamped != actual.Stamped {
+				errs = append(errs, fmt.Errorf("stamp %s: resource group %s has stamped=%v, but stamped=%v in stamp %s", stamp, name, actual.Stamped, expected.Stamped, first))
+				continue
+			}
+			if !expected.Stamped && !resourceGroupMetaEqual(expected.ResourceGroupMeta, actual.ResourceGroupMeta) {
+				errs = append(errs, fmt.Errorf("stamp %s: unstamped resource group %s resolves differently than in stamp %s", stamp, name, first))
+			}
+			if expectedSteps, actualSteps := stepNames(expected), stepNames(actual); !slices.Equal(expectedSteps, actualSteps) {
+				errs = append(errs, fmt.Errorf("stamp %s: resource group %s defines steps %s, but %s in stamp %s", stamp, name, strings.Join(actualSteps, ", "), strings.Join(expectedSteps, ", "), first))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("pipelines for service group %s are inconsistent across stamps: %w", reference.ServiceGroup, err)
+	}
+	return nil
+}
+
+func resourceGroupsByName(pipeline *types.Pipeline) map[string]*types.ResourceGroup {
+	groups := map[string]*types.ResourceGroup{}
+	for _, rg := range pipeline.ResourceGroups {
+		groups[rg.Name] = rg
+	}
+	return groups
+}
+
+func stepNames(rg *types.ResourceGroup) []string {
+	var names []string
+	for _, step := range rg.Steps {
+		names = append(names, step.StepName())
+	}
+	for _, step := range rg.ValidationSteps {
+		names = append(names, step.StepName())
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/config/ev2config"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+func TestForStampedPipelineFile(t *testing.T) {
+	ev2, err := ev2config.ResolveConfig("public", "uksouth")
+	require.NoError(t, err)
+	provider, err := config.NewConfigProvider("../testdata/config.yaml")
+	require.NoError(t, err)
+	replacements := config.ConfigReplacements{
+		CloudReplacement:       "public",
+		EnvironmentReplacement: "int",
+		RegionReplacement:      "uksouth",
+		RegionShortReplacement: "uks",
+		Ev2Config:              ev2,
+	}
+	service := &topology.Service{ServiceGroup: "Microsoft.Azure.ARO.HCP.Management.Infra", PipelinePath: "pipeline.yaml", Stamped: ptr(true)}
+	stamps := []Stamp{mustStamp("1"), mustStamp("2")}
+
+	t.Run("stamps are rendered into each pipeline", func(t *testing.T) {
+		result, err := ForStampedPipelineFile(service, "testdata/stamped/pipeline.yaml", ProviderStampResolver(provider, replacements), "uksouth", stamps, nil)
+		require.NoError(t, err)
+
+		assert.Len(t, result.Nodes, 3)
+		for _, stamp := range stamps {
+			group, exists := result.ResourceGroups[ResourceGroupKey{Stamp: stamp, Name: "management"}]
+			require.True(t, exists)
+			assert.Equal(t, "hcp-underlay-uks-mgmt-"+stamp.String(), group.ResourceGroup)
+		}
+		lookup := Identifier{ServiceGroup: service.ServiceGroup, StepDependency: types.StepDependency{ResourceGroup: "regional", Step: "lookup"}}
+		lookupNode := slices.IndexFunc(result.Nodes, func(node Node) bool { return node.Identifier == lookup })
+		require.NotEqual(t, -1, lookupNode)
+		assert.Len(t, result.Nodes[lookupNode].Children, 2)
+	})
+
+	t.Run("unstamped resource groups must resolve the same way for every stamp", func(t *testing.T) {
+		resolve := func(stamp Stamp) (config.ConfigResolver, error) {
+			stamped := replacements
+			stamped.StampReplacement = stamp.String()
+			if stamp == mustStamp("2") {
+				stamped.RegionShortReplacement = "other"
+			}
+			return provider.GetResolver(&stamped)
+		}
+		_, err := ForStampedPipelineFile(service, "testdata/stamped/pipeline.yaml", resolve, "uksouth", stamps, nil)
+		require.EqualError(t, err, "pipelines for service group Microsoft.Azure.ARO.HCP.Management.Infra are inconsistent across stamps: stamp 2: unstamped resource group regional resolves differently than in stamp 1")
+	})
+
+	t.Run("pipeline options are passed on", func(t *testing.T) {
+		_, err := ForStampedPipelineFile(service, "testdata/stamped/unknown-action.pipeline.yaml", ProviderStampResolver(provider, replacements), "uksouth", stamps, nil)
+		require.NoError(t, err)
+		_, err = ForStampedPipelineFile(service, "testdata/stamped/unknown-action.pipeline.yaml", ProviderStampResolver(provider, replacements), "uksouth", stamps, []types.PipelineOption{types.WithStrictActions()})
+		require.ErrorContains(t, err, `unknown action "Lookup"`)
+	})
+
+	t.Run("stamps must be provided", func(t *testing.T) {
+		_, err := ForStampedPipelineFile(service, "testdata/stamped/pipeline.yaml", ProviderStampResolver(provider, replacements), "uksouth", nil, nil)
+		require.EqualError(t, err, "at least one stamp must be provided")
+	})
+}
+
+func TestValidateStampConsistency(t *testing.T) {
+	stamps := []Stamp{mustStamp("1"), mustStamp("2")}
+	group := func(name string, stamped bool, steps ...string) *types.ResourceGroup {
+		rg := &types.ResourceGroup{ResourceGroupMeta: &types.ResourceGroupMeta{Name: name, ResourceGroup: name, Subscription: "sub", Stamped: stamped}}
+		for _, step := range steps {
+			rg.Steps = append(rg.Steps, &types.ShellStep{StepMeta: types.StepMeta{Name: step}})
+		}
+		return rg
+	}
+	pipelines := func(first, second []*types.ResourceGroup) map[Stamp]*types.Pipeline {
+		return map[Stamp]*types.Pipeline{
+			stamps[0]: {ServiceGroup: "SG", ResourceGroups: first},
+			stamps[1]: {ServiceGroup: "SG", ResourceGroups: second},
+		}
+	}
+
+	require.NoError(t, validateStampConsistency(pipelines(
+		[]*types.ResourceGroup{group("shared", false, "a"), group("mgmt", true, "b")},
+		[]*types.ResourceGroup{group("mgmt", true, "b"), group("shared", false, "a")},
+	), stamps))
+
+	err := validateStampConsistency(pipelines(
+		[]*types.ResourceGroup{group("shared", false, "a"), group("mgmt", true, "b"), group("canary", true, "c")},
+		[]*types.ResourceGroup{group("shared", true, "a"), group("mgmt", true, "b", "extra"), group("other", true, "d")},
+	), stamps)
+	require.Error(t, err)
+	for _, expected := range []string{
+		"stamp 2: resource groups canary defined in stamp 1 are missing",
+		"stamp 2: resource groups other are not defined in stamp 1",
+		"stamp 2: resource group shared has stamped=true, but stamped=false in stamp 1",
+		"stamp 2: resource group mgmt defines steps b, extra, but b in stamp 1",
+	} {
+		assert.ErrorContains(t, err, expected)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/Azure/ARO-Tools/config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// Rule is an opinionated check over a pipeline. Unlike validation, rules flag pipelines that work but are likely to
+// misbehave or are harder to maintain than they need to be.
+type Rule struct {
+	// ID identifies the rule in directives and is recorded as the code of every issue it finds. IDs never change once
+	// published, so tooling may match on them.
+	ID types.IssueCode
+	// Description summarizes what the rule flags and why.
+	Description string
+	// Severity is recorded on every issue the rule finds.
+	Severity types.Severity
+	// Disabled rules only run for files that enable them with a directive.
+	Disabled bool
+	// Check finds every violation of the rule in the pipeline.
+	Check func(pipeline *types.Pipeline) []Finding
+}
+
+// Finding is one violation of a rule.
+type Finding struct {
+	// Location is a JSONPath-like reference to the offending element, like resourceGroups[2].steps[4].
+	Location string
+	// Message describes the violation.
+	Message string
+}
+
+// Pipeline runs every rule over the pipeline, recording one issue per finding.
+func Pipeline(pipeline *types.Pipeline, rules []Rule) *types.ValidationReport {
+	report := &types.ValidationReport{}
+	for _, rule := range rules {
+		for _, finding := range rule.Check(pipeline) {
+			report.Issues = append(report.Issues, types.ValidationIssue{
+				Location: finding.Location,
+				Severity: rule.Severity,
+				Code:     rule.ID,
+				Message:  finding.Message,
+			})
+		}
+	}
+	return report
+}
+
+// File lints the pipeline file at the given path with the rules that are enabled for it, and positions every issue
+// at the line and column of the pre-template document that it concerns. Rules are enabled unless they are disabled by
+// default; the file may change that with comment directives like:
+//
+//	# pipeline-lint: disable=HelmStepWithoutTimeout,EmptyResourceGroup
+//	# pipeline-lint: enable=SomeOptInRule
+//
+// Directives apply to the whole file, wherever they appear in it. The pipeline must be valid to be linted.
+func File(path string, cfg types2.Configuration, rules []Rule, opts ...types.PipelineOption) (*types.ValidationReport, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	enabled, err := EnabledRules(content, rules)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	pipeline, err := types.NewPipelineFromFile(path, cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	report := Pipeline(pipeline, enabled)
+	if len(report.Issues) > 0 {
+		rendered, err := config.PreprocessContent(content, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to preprocess pipeline file: %w", err)
+		}
+		// Positions are a diagnostic aid, so a document that can't be parsed for them is reported without them.
+		if positions, err := config.NewPositions(path, content, rendered); err == nil {
+			report.AttachPositions(positions)
+		}
+	}
+	return report, nil
+}
+
+var directive = regexp.MustCompile(`#\s*pipeline-lint:\s*(enable|disable)=(\S+)`)
+
+// EnabledRules selects the rules that run for a pipeline file, honouring the directives in its content. Directives
+// naming rules that don't exist are an error, so that a typo doesn't silently leave a rule running.
+func EnabledRules(content []byte, rules []Rule) ([]Rule, error) {
+	known := sets.New[types.IssueCode]()
+	for _, rule := range rules {
+		known.Insert(rule.ID)
+	}
+
+	enable, disable := sets.New[types.IssueCode](), sets.New[types.IssueCode]()
+	for i, line := range strings.Split(string(content), "\n") {
+		match := directive.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		for _, id := range strings.Split(match[2], ",") {
+			code := types.IssueCode(strings.TrimSpace(id))
+			if !known.Has(code) {
+				return nil, fmt.Errorf("line %d: unknown lint rule %q", i+1, code)
+			}
+			if match[1] == "enable" {
+				enable.Insert(code)
+			} else {
+				disable.Insert(code)
+			}
+		}
+	}
+	if both := enable.Intersection(disable); both.Len() > 0 {
+		return nil, fmt.Errorf("lint rules %v are both enabled and disabled", sets.List(both))
+	}
+
+	return slices.DeleteFunc(slices.Clone(rules), func(rule Rule) bool {
+		return disable.Has(rule.ID) || rule.Disabled && !enable.Has(rule.ID)
+	}), nil
+}
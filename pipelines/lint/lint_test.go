@@ -0,0 +1,130 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+	"github.com/Azure/ARO-Tools/pipelines/verify"
+	"github.com/Azure/ARO-Tools/testutil"
+)
+
+func resolveConfiguration(t *testing.T) types2.Configuration {
+	t.Helper()
+	provider, err := config.NewConfigProvider("../testdata/config.yaml")
+	require.NoError(t, err)
+	replacements, err := verify.DefaultReplacements(verify.Context{Cloud: "public", Environment: "int", Region: "uksouth"})
+	require.NoError(t, err)
+	resolver, err := provider.GetResolver(replacements)
+	require.NoError(t, err)
+	cfg, err := resolver.GetRegionConfiguration("uksouth")
+	require.NoError(t, err)
+	return cfg
+}
+
+type issue struct {
+	Code     types.IssueCode
+	Location string
+	Line     int
+}
+
+func issues(report *types.ValidationReport) []issue {
+	var out []issue
+	for _, i := range report.Issues {
+		summary := issue{Code: i.Code, Location: i.Location}
+		if i.Position != nil {
+			summary.Line = i.Position.Line
+		}
+		out = append(out, summary)
+	}
+	return out
+}
+
+func TestFile(t *testing.T) {
+	report, err := File("testdata/pipeline.yaml", resolveConfiguration(t), Rules())
+	require.NoError(t, err)
+	assert.Equal(t, []issue{
+		{Code: RuleHelmStepWithoutTimeout, Location: "resourceGroups[0].steps[1]", Line: 15},
+		{Code: RuleShellStepWithoutReferences, Location: "resourceGroups[0].steps[2]", Line: 40},
+		{Code: RuleShellStepWithoutReferences, Location: "resourceGroups[0].validationSteps[0]", Line: 47},
+		{Code: RuleLeafOmittedFromCompletion, Location: "resourceGroups[0].steps[2].omitFromServiceGroupCompletion", Line: 45},
+		{Code: RuleRedundantDependsOn, Location: "resourceGroups[0].steps[1].dependsOn[0]", Line: 33},
+		{Code: RuleRedundantDependsOn, Location: "resourceGroups[0].validationSteps[0].dependsOn[0]", Line: 53},
+		{Code: RuleEmptyResourceGroup, Location: "resourceGroups[1]", Line: 63},
+	}, issues(report))
+	assert.False(t, report.HasErrors())
+
+	encoded, err := MarshalSARIF(Rules(), map[string]*types.ValidationReport{"testdata/pipeline.yaml": report})
+	require.NoError(t, err)
+	testutil.CompareWithFixture(t, encoded, testutil.WithExtension(".sarif"))
+}
+
+func TestEnabledRules(t *testing.T) {
+	optIn := Rule{ID: "OptIn", Disabled: true}
+	rules := append(Rules(), optIn)
+	ids := func(rules []Rule) []types.IssueCode {
+		var out []types.IssueCode
+		for _, rule := range rules {
+			out = append(out, rule.ID)
+		}
+		return out
+	}
+
+	for _, testCase := range []struct {
+		name     string
+		content  string
+		expected []types.IssueCode
+		err      string
+	}{
+		{
+			name:     "no directives",
+			content:  "serviceGroup: Microsoft.Azure.ARO.HCP.Lint\n",
+			expected: ids(Rules()),
+		},
+		{
+			name: "disable and enable",
+			content: `# pipeline-lint: disable=HelmStepWithoutTimeout,EmptyResourceGroup
+serviceGroup: Microsoft.Azure.ARO.HCP.Lint # pipeline-lint: enable=OptIn
+`,
+			expected: []types.IssueCode{RuleShellStepWithoutReferences, RuleLeafOmittedFromCompletion, RuleRedundantDependsOn, "OptIn"},
+		},
+		{
+			name:    "unknown rule",
+			content: "serviceGroup: Microsoft.Azure.ARO.HCP.Lint\n# pipeline-lint: disable=HelmTimeout\n",
+			err:     `line 2: unknown lint rule "HelmTimeout"`,
+		},
+		{
+			name:    "conflicting directives",
+			content: "# pipeline-lint: disable=OptIn\n# pipeline-lint: enable=OptIn\n",
+			err:     "lint rules [OptIn] are both enabled and disabled",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			enabled, err := EnabledRules([]byte(testCase.content), rules)
+			if testCase.err != "" {
+				require.EqualError(t, err, testCase.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, ids(enabled))
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-Tools/config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+	"github.com/Azure/ARO-Tools/pipelines/verify"
+)
+
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+var formats = []string{FormatText, FormatJSON, FormatSARIF}
+
+func DefaultOptions() *RawOptions {
+	return &RawOptions{
+		Format: FormatText,
+	}
+}
+
+func BindOptions(opts *RawOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringSliceVar(&opts.PipelinePaths, "pipeline", opts.PipelinePaths, "Path to a pipeline file to lint. May be provided more than once.")
+	cmd.Flags().StringVar(&opts.ConfigPath, "config", opts.ConfigPath, "Path to the service configuration file.")
+	cmd.Flags().StringVar(&opts.Cloud, "cloud", opts.Cloud, "Cloud for which to resolve configuration.")
+	cmd.Flags().StringVar(&opts.Environment, "environment", opts.Environment, "Environment for which to resolve configuration.")
+	cmd.Flags().StringVar(&opts.Region, "region", opts.Region, "Region for which to resolve configuration.")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, fmt.Sprintf("Output format, one of %v.", formats))
+
+	for _, flag := range []string{
+		"pipeline",
+		"config",
+	} {
+		if err := cmd.MarkFlagFilename(flag); err != nil {
+			return fmt.Errorf("failed to mark flag %q as a file: %w", flag, err)
+		}
+	}
+	return nil
+}
+
+// RawOptions holds input values.
+type RawOptions struct {
+	PipelinePaths []string
+	ConfigPath    string
+	Cloud         string
+	Environment   string
+	Region        string
+	Format        string
+}
+
+// validatedOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedOptions struct {
+	PipelinePaths []string
+	ConfigPath    string
+	Context       verify.Context
+	Format        string
+}
+
+type ValidatedOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedOptions
+}
+
+// completedOptions is a private wrapper that enforces a call of Complete() before linting can be invoked.
+type completedOptions struct {
+	PipelinePaths []string
+	Config        types2.Configuration
+	Rules         []Rule
+	Format        string
+}
+
+type Options struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedOptions
+}
+
+func (o *RawOptions) Validate() (*ValidatedOptions, error) {
+	if len(o.PipelinePaths) == 0 {
+		return nil, fmt.Errorf("at least one pipeline file must be provided with --pipeline")
+	}
+	if o.ConfigPath == "" {
+		return nil, fmt.Errorf("the service configuration file must be provided with --config")
+	}
+	for _, flag := range []struct{ name, value string }{
+		{name: "cloud", value: o.Cloud},
+		{name: "environment", value: o.Environment},
+		{name: "region", value: o.Region},
+	} {
+		if flag.value == "" {
+			return nil, fmt.Errorf("the %s must be provided with --%s", flag.name, flag.name)
+		}
+	}
+	if !slices.Contains(formats, o.Format) {
+		return nil, fmt.Errorf("invalid --format %q, must be one of %v", o.Format, formats)
+	}
+
+	return &ValidatedOptions{
+		validatedOptions: &validatedOptions{
+			PipelinePaths: o.PipelinePaths,
+			ConfigPath:    o.ConfigPath,
+			Context:       verify.Context{Cloud: o.Cloud, Environment: o.Environment, Region: o.Region},
+			Format:        o.Format,
+		},
+	}, nil
+}
+
+func (o *ValidatedOptions) Complete() (*Options, error) {
+	provider, err := config.NewConfigProvider(o.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service configuration: %w", err)
+	}
+	replacements, err := verify.DefaultReplacements(o.Context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine configuration replacements for %s: %w", o.Context, err)
+	}
+	resolver, err := provider.GetResolver(replacements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration resolver for %s: %w", o.Context, err)
+	}
+	cfg, err := resolver.GetRegionConfiguration(o.Context.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve configuration for %s: %w", o.Context, err)
+	}
+
+	return &Options{
+		completedOptions: &completedOptions{
+			PipelinePaths: o.PipelinePaths,
+			Config:        cfg,
+			Rules:         Rules(),
+			Format:        o.Format,
+		},
+	}, nil
+}
+
+// Lint lints every pipeline file, writing the issues found to out in the chosen format. An error is returned if any
+// issue was found, so that callers uploading SARIF should not treat failure as fatal.
+func (opts *Options) Lint(out io.Writer) error {
+	reports := map[string]*types.ValidationReport{}
+	var issues int
+	for _, path := range opts.PipelinePaths {
+		report, err := File(path, opts.Config, opts.Rules)
+		if err != nil {
+			return fmt.Errorf("failed to lint %s: %w", path, err)
+		}
+		reports[path] = report
+		issues += len(report.Issues)
+	}
+
+	var encoded []byte
+	switch opts.Format {
+	case FormatSARIF:
+		var err error
+		if encoded, err = MarshalSARIF(opts.Rules, reports); err != nil {
+			return fmt.Errorf("failed to encode SARIF: %w", err)
+		}
+	case FormatJSON:
+		var err error
+		if encoded, err = json.MarshalIndent(reports, "", "  "); err != nil {
+			return fmt.Errorf("failed to encode reports: %w", err)
+		}
+	default:
+		for _, path := range opts.PipelinePaths {
+			for _, issue := range reports[path].Issues {
+				encoded = fmt.Appendf(encoded, "%s [%s]\n", issue.Error(), issue.Code)
+			}
+		}
+	}
+	if _, err := out.Write(encoded); err != nil {
+		return fmt.Errorf("failed to write lint results: %w", err)
+	}
+	if issues > 0 {
+		return fmt.Errorf("found %d lint issues", issues)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+const (
+	RuleHelmStepWithoutTimeout     types.IssueCode = "HelmStepWithoutTimeout"
+	RuleShellStepWithoutReferences types.IssueCode = "ShellStepWithoutReferences"
+	RuleLeafOmittedFromCompletion  types.IssueCode = "LeafOmittedFromCompletion"
+	RuleRedundantDependsOn         types.IssueCode = "RedundantDependsOn"
+	RuleEmptyResourceGroup         types.IssueCode = "EmptyResourceGroup"
+)
+
+// Rules lists the built-in rules.
+func Rules() []Rule {
+	return []Rule{
+		{
+			ID:          RuleHelmStepWithoutTimeout,
+			Description: "Helm steps should set a timeout, rather than relying on the default of whichever tool deploys them.",
+			Severity:    types.SeverityWarning,
+			Check:       helmStepWithoutTimeout,
+		},
+		{
+			ID:          RuleShellStepWithoutReferences,
+			Description: "Shell steps that capture the whole repository, as they set no workingDir, should declare the files they use with references.",
+			Severity:    types.SeverityWarning,
+			Check:       shellStepWithoutReferences,
+		},
+		{
+			ID:          RuleLeafOmittedFromCompletion,
+			Description: "Leaf steps omitted from service group completion let dependent service groups begin before they finish.",
+			Severity:    types.SeverityWarning,
+			Check:       leafOmittedFromCompletion,
+		},
+		{
+			ID:          RuleRedundantDependsOn,
+			Description: "Steps should not declare dependencies on steps whose outputs they already consume as inputs.",
+			Severity:    types.SeverityWarning,
+			Check:       redundantDependsOn,
+		},
+		{
+			ID:          RuleEmptyResourceGroup,
+			Description: "Resource groups should define at least one step.",
+			Severity:    types.SeverityWarning,
+			Check:       emptyResourceGroup,
+		},
+	}
+}
+
+// visitSteps calls visit for every step in the pipeline, validation steps included, along with its location and the
+// resource group holding it.
+func visitSteps(pipeline *types.Pipeline, visit func(location string, rg *types.ResourceGroup, step types.Step)) {
+	for i, rg := range pipeline.ResourceGroups {
+		for j, step := range rg.Steps {
+			visit(fmt.Sprintf("resourceGroups[%d].steps[%d]", i, j), rg, step)
+		}
+		for j, step := range rg.ValidationSteps {
+			visit(fmt.Sprintf("resourceGroups[%d].validationSteps[%d]", i, j), rg, step)
+		}
+	}
+}
+
+func helmStepWithoutTimeout(pipeline *types.Pipeline) []Finding {
+	var findings []Finding
+	visitSteps(pipeline, func(location string, _ *types.ResourceGroup, step types.Step) {
+		if helm, ok := step.(*types.HelmStep); ok && helm.Timeout == "" {
+			findings = append(findings, Finding{Location: location, Message: fmt.Sprintf("Helm step %s does not set a timeout", helm.Name)})
+		}
+	})
+	return findings
+}
+
+func shellStepWithoutReferences(pipeline *types.Pipeline) []Finding {
+	var findings []Finding
+	visitSteps(pipeline, func(location string, _ *types.ResourceGroup, step types.Step) {
+		var shell *types.ShellStep
+		var wellFormed bool
+		switch s := step.(type) {
+		case *types.ShellStep:
+			shell, wellFormed = s, s.IsWellFormedOverInputs()
+		case *types.ShellValidationStep:
+			shell, wellFormed = &s.ShellStep, s.IsWellFormedOverInputs()
+		default:
+			return
+		}
+		if !wellFormed && len(shell.References) == 0 {
+			findings = append(findings, Finding{Location: location, Message: fmt.Sprintf("shell step %s captures the whole repository but declares no references; set workingDir, or declare the files it uses with references", shell.Name)})
+		}
+	})
+	return findings
+}
+
+func leafOmittedFromCompletion(pipeline *types.Pipeline) []Finding {
+	dependedOn := sets.New[types.StepDependency]()
+	for _, rg := range pipeline.ResourceGroups {
+		for _, step := range rg.Steps {
+			dependedOn.Insert(step.Dependencies()...)
+			dependedOn.Insert(step.RequiredInputs()...)
+		}
+		for _, step := range rg.ValidationSteps {
+			dependedOn.Insert(step.Dependencies()...)
+			dependedOn.Insert(step.RequiredInputs()...)
+		}
+	}
+
+	var findings []Finding
+	visitSteps(pipeline, func(location string, rg *types.ResourceGroup, step types.Step) {
+		if step.ConsideredForServiceGroupCompletion() || dependedOn.Has(types.StepDependency{ResourceGroup: rg.Name, Step: step.StepName()}) {
+			return
+		}
+		findings = append(findings, Finding{
+			Location: location + ".omitFromServiceGroupCompletion",
+			Message:  fmt.Sprintf("step %s is a leaf of the service group, so omitting it from service group completion lets dependent service groups begin before it finishes", step.StepName()),
+		})
+	})
+	return findings
+}
+
+func redundantDependsOn(pipeline *types.Pipeline) []Finding {
+	var findings []Finding
+	visitSteps(pipeline, func(location string, _ *types.ResourceGroup, step types.Step) {
+		inputs := sets.New(step.RequiredInputs()...)
+		for k, dependency := range step.Dependencies() {
+			if inputs.Has(dependency) {
+				findings = append(findings, Finding{
+					Location: fmt.Sprintf("%s.dependsOn[%d]", location, k),
+					Message:  fmt.Sprintf("step %s already depends on %s/%s as it consumes its outputs", step.StepName(), dependency.ResourceGroup, dependency.Step),
+				})
+			}
+		}
+	})
+	return findings
+}
+
+func emptyResourceGroup(pipeline *types.Pipeline) []Finding {
+	var findings []Finding
+	for i, rg := range pipeline.ResourceGroups {
+		if len(rg.Steps) == 0 && len(rg.ValidationSteps) == 0 {
+			findings = append(findings, Finding{Location: fmt.Sprintf("resourceGroups[%d]", i), Message: fmt.Sprintf("resource group %s defines no steps", rg.Name)})
+		}
+	}
+	return findings
+}
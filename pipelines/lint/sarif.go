@@ -0,0 +1,160 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// The SARIF types cover the subset of the format that GitHub code scanning consumes.
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Enabled bool   `json:"enabled"`
+	Level   string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// MarshalSARIF encodes the reports for each pipeline file as a SARIF log, for upload to GitHub code scanning. Every
+// issue must have been found by one of the rules. Files are recorded by the path they are keyed by, which should be
+// relative to the root of the repository.
+func MarshalSARIF(rules []Rule, reports map[string]*types.ValidationReport) ([]byte, error) {
+	driver := sarifDriver{
+		Name:           "pipeline-lint",
+		InformationURI: "https://github.com/Azure/ARO-Tools",
+		Rules:          []sarifRule{},
+	}
+	indices := map[types.IssueCode]int{}
+	for i, rule := range rules {
+		indices[rule.ID] = i
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               string(rule.ID),
+			ShortDescription: sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfiguration{
+				Enabled: !rule.Disabled,
+				Level:   sarifLevel(rule.Severity),
+			},
+		})
+	}
+
+	files := make([]string, 0, len(reports))
+	for file := range reports {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+
+	results := []sarifResult{}
+	for _, file := range files {
+		for _, issue := range reports[file].Issues {
+			index, known := indices[issue.Code]
+			if !known {
+				return nil, fmt.Errorf("%s: issue %q was not found by any of the rules", file, issue.Code)
+			}
+			location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(file)}}
+			if issue.Position != nil {
+				location.Region = &sarifRegion{StartLine: issue.Position.Line, StartColumn: issue.Position.Column}
+			}
+			message := issue.Message
+			if issue.Location != "" {
+				message = fmt.Sprintf("%s: %s", issue.Location, message)
+			}
+			results = append(results, sarifResult{
+				RuleID:    string(issue.Code),
+				RuleIndex: index,
+				Level:     sarifLevel(issue.Severity),
+				Message:   sarifMessage{Text: message},
+				Locations: []sarifLocation{{PhysicalLocation: location}},
+			})
+		}
+	}
+
+	return json.MarshalIndent(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}, "", "  ")
+}
+
+func sarifLevel(severity types.Severity) string {
+	if severity == types.SeverityError {
+		return "error"
+	}
+	return "warning"
+}
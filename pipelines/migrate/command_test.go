@@ -0,0 +1,143 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	v1Pipeline = `$schema: pipeline.schema.v1
+serviceGroup: Microsoft.Azure.ARO.HCP.Test
+resourceGroups:
+- name: rg
+  steps:
+  - name: e2e
+    action: ProwJob
+    gatePromotion: "true" # gate the rollout
+`
+	v2Pipeline = `$schema: pipeline.schema.v2
+serviceGroup: Microsoft.Azure.ARO.HCP.Test
+resourceGroups:
+- name: rg
+  steps:
+  - name: e2e
+    action: ProwJob
+    gatePromotion: true # gate the rollout
+`
+	// already migrated, but formatted differently than a migration would leave it
+	currentPipeline = `$schema: pipeline.schema.v2
+serviceGroup: Microsoft.Azure.ARO.HCP.Test
+resourceGroups:
+- {name: rg, steps: [{name: e2e, action: ProwJob, gatePromotion: false}]}
+`
+	invalidPipeline = `serviceGroup: Microsoft.Azure.ARO.HCP.Test
+resourceGroups:
+- name: rg
+  steps:
+  - name: e2e
+    action: ProwJob
+    gatePromotion: maybe
+`
+)
+
+func runMigrate(t *testing.T, args ...string) error {
+	t.Helper()
+	cmd, err := NewMigrateCommand()
+	require.NoError(t, err)
+	cmd.SetArgs(args)
+	return cmd.Execute()
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestMigrateCommand(t *testing.T) {
+	t.Run("input migrated to output", func(t *testing.T) {
+		dir := t.TempDir()
+		input, output := filepath.Join(dir, "pipeline.yaml"), filepath.Join(dir, "migrated.yaml")
+		writeFile(t, input, v1Pipeline)
+
+		require.NoError(t, runMigrate(t, "--input", input, "--output", output))
+		assert.Equal(t, v1Pipeline, readFile(t, input), "the input must not change when an output is provided")
+		assert.Equal(t, v2Pipeline, readFile(t, output))
+	})
+
+	t.Run("input migrated in place", func(t *testing.T) {
+		input := filepath.Join(t.TempDir(), "pipeline.yaml")
+		writeFile(t, input, v1Pipeline)
+
+		require.NoError(t, runMigrate(t, "--input", input))
+		assert.Equal(t, v2Pipeline, readFile(t, input))
+	})
+
+	t.Run("directory walked for matching files", func(t *testing.T) {
+		dir := t.TempDir()
+		first, second := filepath.Join(dir, "a", "pipeline.yaml"), filepath.Join(dir, "b", "c", "pipeline.yaml")
+		other := filepath.Join(dir, "a", "values.yaml")
+		current := filepath.Join(dir, "d", "pipeline.yaml")
+		for _, path := range []string{first, second, other} {
+			writeFile(t, path, v1Pipeline)
+		}
+		writeFile(t, current, currentPipeline)
+		unchanged := time.Now().Add(-time.Hour).Truncate(time.Second)
+		require.NoError(t, os.Chtimes(current, unchanged, unchanged))
+
+		require.NoError(t, runMigrate(t, "--dir", dir))
+		assert.Equal(t, v2Pipeline, readFile(t, first))
+		assert.Equal(t, v2Pipeline, readFile(t, second))
+		assert.Equal(t, v1Pipeline, readFile(t, other), "files not matching --name must be skipped")
+		assert.Equal(t, currentPipeline, readFile(t, current))
+		info, err := os.Stat(current)
+		require.NoError(t, err)
+		assert.Equal(t, unchanged, info.ModTime(), "pipelines already on the latest schema must not be rewritten")
+
+		require.NoError(t, runMigrate(t, "--dir", dir, "--name", "values.yaml"))
+		assert.Equal(t, v2Pipeline, readFile(t, other))
+	})
+
+	t.Run("non-boolean gatePromotion", func(t *testing.T) {
+		dir := t.TempDir()
+		invalid, valid := filepath.Join(dir, "a", "pipeline.yaml"), filepath.Join(dir, "b", "pipeline.yaml")
+		writeFile(t, invalid, invalidPipeline)
+		writeFile(t, valid, v1Pipeline)
+
+		err := runMigrate(t, "--dir", dir)
+		assert.EqualError(t, err, fmt.Sprintf(`%s: failed to migrate pipeline to pipeline.schema.v2: resourceGroups[0].steps[0].gatePromotion: "maybe" is not a boolean`, invalid))
+		assert.Equal(t, invalidPipeline, readFile(t, invalid))
+		assert.Equal(t, v2Pipeline, readFile(t, valid), "other files must still be migrated")
+	})
+
+	t.Run("input or directory required", func(t *testing.T) {
+		assert.EqualError(t, runMigrate(t), "the pipeline to migrate must be provided with --input or --dir")
+	})
+}
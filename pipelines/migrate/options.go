@@ -0,0 +1,190 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+func DefaultOptions() *RawOptions {
+	return &RawOptions{
+		Name: "pipeline.yaml",
+		To:   types.LatestPipelineSchemaRef(),
+	}
+}
+
+func BindOptions(opts *RawOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringVar(&opts.InputPath, "input", opts.InputPath, "Path to the pipeline file to migrate.")
+	cmd.Flags().StringVar(&opts.OutputPath, "output", opts.OutputPath, "Path to the output file (defaults to input file).")
+	cmd.Flags().StringVar(&opts.Directory, "dir", opts.Directory, "Directory to walk for pipeline files. Ignored if --input is provided.")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "Glob matching the names of pipeline files when walking a directory.")
+	cmd.Flags().StringVar(&opts.To, "to", opts.To, fmt.Sprintf("Schema to migrate to, one of %s.", strings.Join(types.PipelineSchemaRefs(), ", ")))
+
+	for _, flag := range []string{
+		"input",
+		"output",
+	} {
+		if err := cmd.MarkFlagFilename(flag); err != nil {
+			return fmt.Errorf("failed to mark flag %q as a file: %w", flag, err)
+		}
+	}
+	if err := cmd.MarkFlagDirname("dir"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a directory: %w", "dir", err)
+	}
+	return nil
+}
+
+// RawOptions holds input values.
+type RawOptions struct {
+	InputPath  string
+	OutputPath string
+	Directory  string
+	Name       string
+	To         string
+}
+
+// validatedOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedOptions struct {
+	InputPath  string
+	OutputPath string
+	Directory  string
+	Name       string
+	To         string
+}
+
+type ValidatedOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedOptions
+}
+
+// completedOptions is a private wrapper that enforces a call of Complete() before Config generation can be invoked.
+type completedOptions struct {
+	Files []file
+	To    string
+}
+
+// file is a pipeline file to migrate, and where to write the result.
+type file struct {
+	input, output string
+}
+
+type Options struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedOptions
+}
+
+func (o *RawOptions) Validate() (*ValidatedOptions, error) {
+	if o.InputPath == "" && o.Directory == "" {
+		return nil, fmt.Errorf("the pipeline to migrate must be provided with --input or --dir")
+	}
+	if o.InputPath != "" && o.OutputPath == "" {
+		o.OutputPath = o.InputPath
+	}
+	if o.InputPath == "" {
+		if _, err := filepath.Match(o.Name, ""); err != nil {
+			return nil, fmt.Errorf("invalid --name %q: %w", o.Name, err)
+		}
+	}
+	if !slices.Contains(types.PipelineSchemaRefs(), o.To) {
+		return nil, fmt.Errorf("unsupported --to %q, must be one of %s", o.To, strings.Join(types.PipelineSchemaRefs(), ", "))
+	}
+
+	return &ValidatedOptions{
+		validatedOptions: &validatedOptions{
+			InputPath:  o.InputPath,
+			OutputPath: o.OutputPath,
+			Directory:  o.Directory,
+			Name:       o.Name,
+			To:         o.To,
+		},
+	}, nil
+}
+
+func (o *ValidatedOptions) Complete() (*Options, error) {
+	var files []file
+	if o.InputPath != "" {
+		files = append(files, file{input: o.InputPath, output: o.OutputPath})
+	} else {
+		if err := filepath.WalkDir(o.Directory, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if matches, _ := filepath.Match(o.Name, d.Name()); matches {
+				files = append(files, file{input: path, output: path})
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to find pipeline files in %s: %w", o.Directory, err)
+		}
+	}
+
+	return &Options{
+		completedOptions: &completedOptions{
+			Files: files,
+			To:    o.To,
+		},
+	}, nil
+}
+
+// Migrate upgrades every pipeline file, leaving files that already use the target schema untouched.
+func (opts *Options) Migrate(ctx context.Context) error {
+	var errs []error
+	for _, f := range opts.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := migrateFile(f, opts.To); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.input, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func migrateFile(f file, to string) error {
+	content, err := os.ReadFile(f.input)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	from, err := types.PipelineSchemaRef(content)
+	if err != nil {
+		return err
+	}
+	migrated, err := types.MigratePipeline(content, from, to)
+	if err != nil {
+		return err
+	}
+	if f.output == f.input && bytes.Equal(migrated, content) {
+		return nil
+	}
+	if err := os.WriteFile(f.output, migrated, 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", f.output, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retry implements the automated retry semantics that Ev2 applies to pipeline steps, so that tools running
+// steps outside of Ev2 retry them in exactly the same situations.
+package retry
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// Func runs one attempt of a step, returning the output it captured. Attempts are numbered from 1.
+type Func func(ctx context.Context, attempt int) (output string, err error)
+
+// Policy decides whether failed attempts of a step are retried, and how long to wait in between.
+type Policy struct {
+	// Retries is the maximum number of retries after the first attempt.
+	Retries int
+	// ErrorContainsAny holds the strings of which a failed attempt's output must contain one, ignoring case, for it
+	// to be retried. When empty, every failure is retried.
+	ErrorContainsAny []string
+	// DurationBetweenRetries is the time to wait before each retry.
+	DurationBetweenRetries time.Duration
+	// Sleep waits between retries, returning early with an error when the context is done. Defaults to a timer.
+	Sleep func(ctx context.Context, d time.Duration) error
+
+	err error
+}
+
+// ForStep creates the policy configured by the step's automated retries. Steps without automated retries are
+// attempted once. An invalid configuration is reported when the policy is run.
+func ForStep(step types.Step) *Policy {
+	return New(step.AutomatedRetries())
+}
+
+// New creates the policy configured by an automated retry, which may be nil to attempt steps once.
+func New(retry *types.AutomatedRetry) *Policy {
+	if retry == nil {
+		return &Policy{}
+	}
+	policy := &Policy{Retries: retry.RetryCount(), ErrorContainsAny: retry.ErrorContainsAny}
+	policy.DurationBetweenRetries, policy.err = retry.RetryDelay()
+	return policy
+}
+
+// ShouldRetry determines whether to retry after the numbered attempt failed, producing output. Matching is
+// case-insensitive.
+func (p *Policy) ShouldRetry(attempt int, output string) bool {
+	if attempt > p.Retries {
+		return false
+	}
+	if len(p.ErrorContainsAny) == 0 {
+		return true
+	}
+	output = strings.ToLower(output)
+	return slices.ContainsFunc(p.ErrorContainsAny, func(contains string) bool {
+		return strings.Contains(output, strings.ToLower(contains))
+	})
+}
+
+// Run calls fn until an attempt succeeds or the policy gives up, returning the error from the last attempt. The
+// output of a failed attempt is matched along with the message of its error.
+func (p *Policy) Run(ctx context.Context, fn Func) error {
+	if p.err != nil {
+		return p.err
+	}
+	sleep := p.Sleep
+	if sleep == nil {
+		sleep = wait
+	}
+	for attempt := 1; ; attempt++ {
+		output, err := fn(ctx, attempt)
+		if err == nil {
+			return nil
+		}
+		if !p.ShouldRetry(attempt, output+"\n"+err.Error()) {
+			return err
+		}
+		if sleepErr := sleep(ctx, p.DurationBetweenRetries); sleepErr != nil {
+			return errors.Join(err, sleepErr)
+		}
+	}
+}
+
+// wait sleeps for the duration, or until the context is done.
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+func TestPolicyRun(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		retry    *types.AutomatedRetry
+		outputs  []string
+		attempts int
+		waited   []time.Duration
+		err      string
+	}{
+		{
+			name:     "no automated retry",
+			outputs:  []string{"Conflict"},
+			attempts: 1,
+			err:      "attempt 1 failed",
+		},
+		{
+			name:     "default retry count",
+			retry:    &types.AutomatedRetry{ErrorContainsAny: []string{"conflict"}, DurationBetweenRetries: "2m"},
+			outputs:  []string{"409 CONFLICT", "409 Conflict"},
+			attempts: 2,
+			waited:   []time.Duration{2 * time.Minute},
+			err:      "attempt 2 failed",
+		},
+		{
+			name:     "succeeds on retry",
+			retry:    &types.AutomatedRetry{ErrorContainsAny: []string{"throttled", "Conflict"}, MaximumRetryCount: 5, DurationBetweenRetries: "1m"},
+			outputs:  []string{"request was THROTTLED", "resource conflict"},
+			attempts: 3,
+			waited:   []time.Duration{time.Minute, time.Minute},
+		},
+		{
+			name:     "output does not match",
+			retry:    &types.AutomatedRetry{ErrorContainsAny: []string{"throttled"}, MaximumRetryCount: 5},
+			outputs:  []string{"quota exceeded"},
+			attempts: 1,
+			err:      "attempt 1 failed",
+		},
+		{
+			name:     "any failure without strings to match",
+			retry:    &types.AutomatedRetry{MaximumRetryCount: 2},
+			outputs:  []string{"one", "two", "three"},
+			attempts: 3,
+			waited:   []time.Duration{0, 0},
+			err:      "attempt 3 failed",
+		},
+		{
+			name:  "invalid duration",
+			retry: &types.AutomatedRetry{DurationBetweenRetries: "often"},
+			err:   `invalid durationBetweenRetries "often": time: invalid duration "often"`,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			step := &types.ShellStep{StepMeta: types.StepMeta{Name: "step", AutomatedRetry: testCase.retry}}
+			policy := ForStep(step)
+			var waited []time.Duration
+			policy.Sleep = func(_ context.Context, d time.Duration) error {
+				waited = append(waited, d)
+				return nil
+			}
+			attempts := 0
+			err := policy.Run(context.Background(), func(_ context.Context, attempt int) (string, error) {
+				attempts = attempt
+				if attempt > len(testCase.outputs) {
+					return "done", nil
+				}
+				return testCase.outputs[attempt-1], fmt.Errorf("attempt %d failed", attempt)
+			})
+			if testCase.err != "" {
+				require.EqualError(t, err, testCase.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, testCase.attempts, attempts)
+			assert.Equal(t, testCase.waited, waited)
+		})
+	}
+}
+
+func TestPolicyRunCancelled(t *testing.T) {
+	policy := New(&types.AutomatedRetry{MaximumRetryCount: 3, DurationBetweenRetries: "1h"})
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := policy.Run(ctx, func(context.Context, int) (string, error) {
+		attempts++
+		cancel()
+		return "", errors.New("failed")
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorContains(t, err, "failed")
+	assert.Equal(t, 1, attempts)
+}
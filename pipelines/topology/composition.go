@@ -0,0 +1,152 @@
+package topology
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CompositionIssue records a problem found while composing topology files, attributed to the file it came from.
+type CompositionIssue struct {
+	// File is the topology file in which the problem was found.
+	File string
+	// ServiceGroup is the service or entrypoint identifier the problem concerns.
+	ServiceGroup string
+	// Message describes the problem.
+	Message string
+}
+
+func (i CompositionIssue) Error() string {
+	return fmt.Sprintf("%s: %s: %s", i.File, i.ServiceGroup, i.Message)
+}
+
+// CompositionReport records how topology files compose into one topology, as LoadCombined would combine them.
+type CompositionReport struct {
+	// Files lists the topology files in the order they were composed.
+	Files []string
+	// Sources records the files that define each service group. A well-formed composition defines every service
+	// group in exactly one file.
+	Sources map[string][]string
+	// ExternalParents records the service group that each service with an external parent is attached to.
+	// External parents are only resolved when composing more than one file, as LoadCombined ignores them otherwise.
+	ExternalParents map[string]string
+	// Issues lists every problem found, in the order of the files they were found in.
+	Issues []CompositionIssue
+}
+
+// Err combines every issue into one error, or returns nil if there were none.
+func (r *CompositionReport) Err() error {
+	var errs []error
+	for _, issue := range r.Issues {
+		errs = append(errs, issue)
+	}
+	return errors.Join(errs...)
+}
+
+// String renders the report for humans, listing the file that contributes each service group, followed by any issues.
+func (r *CompositionReport) String() string {
+	var out strings.Builder
+	for _, file := range r.Files {
+		fmt.Fprintf(&out, "%s:\n", file)
+		for _, serviceGroup := range r.serviceGroupsFrom(file) {
+			if parent, ok := r.ExternalParents[serviceGroup]; ok {
+				fmt.Fprintf(&out, "  %s (external parent: %s)\n", serviceGroup, parent)
+				continue
+			}
+			fmt.Fprintf(&out, "  %s\n", serviceGroup)
+		}
+	}
+	if len(r.Issues) > 0 {
+		fmt.Fprintf(&out, "%d issues:\n", len(r.Issues))
+		for _, issue := range r.Issues {
+			fmt.Fprintf(&out, "  %s\n", issue.Error())
+		}
+	}
+	return out.String()
+}
+
+func (r *CompositionReport) serviceGroupsFrom(file string) []string {
+	var serviceGroups []string
+	for serviceGroup, files := range r.Sources {
+		if slices.Contains(files, file) {
+			serviceGroups = append(serviceGroups, serviceGroup)
+		}
+	}
+	slices.Sort(serviceGroups)
+	return serviceGroups
+}
+
+// Compose reports how the topology files compose: which file contributes each service, whether every external
+// parent resolves to a service defined in exactly one file, and whether entrypoints select services defined in the
+// same file. Unlike LoadCombined, every problem is reported, attributed to the file it came from. An error is only
+// returned when a file can't be loaded.
+func Compose(paths []string) (*CompositionReport, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("at least one topology file must be provided")
+	}
+	report := &CompositionReport{
+		Files:           paths,
+		Sources:         map[string][]string{},
+		ExternalParents: map[string]string{},
+	}
+	topologies := make([]*Topology, 0, len(paths))
+	for _, path := range paths {
+		topo, err := Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load topology %s: %w", path, err)
+		}
+		topologies = append(topologies, topo)
+
+		_ = topo.Walk(func(service *Service, _ bool) error {
+			if files := report.Sources[service.ServiceGroup]; len(files) > 0 {
+				report.addIssue(path, service.ServiceGroup, "service group is already defined in %s; service groups must be unique across topologies", strings.Join(files, ", "))
+			}
+			report.Sources[service.ServiceGroup] = append(report.Sources[service.ServiceGroup], path)
+			return nil
+		})
+		for i := range topo.Services {
+			if err := validateNoExternalParentInChildren(&topo.Services[i]); err != nil {
+				report.addIssue(path, topo.Services[i].ServiceGroup, "%v", err)
+			}
+		}
+	}
+
+	for i, topo := range topologies {
+		path := paths[i]
+		if len(paths) > 1 {
+			for _, service := range topo.Services {
+				if service.ExternalParent == nil {
+					continue
+				}
+				parent := *service.ExternalParent
+				switch files := report.Sources[parent]; len(files) {
+				case 0:
+					report.addIssue(path, service.ServiceGroup, "external parent %s is not defined in any topology file", parent)
+				case 1:
+					report.ExternalParents[service.ServiceGroup] = parent
+				default:
+					report.addIssue(path, service.ServiceGroup, "external parent %s must be defined exactly once, but is defined in %s", parent, strings.Join(files, ", "))
+				}
+			}
+		}
+
+		for _, entrypoint := range topo.Entrypoints {
+			files := report.Sources[entrypoint.Identifier]
+			switch {
+			case len(files) == 0:
+				report.addIssue(path, entrypoint.Identifier, "entrypoint references a service group that is not defined in any topology file")
+			case !slices.Contains(files, path):
+				report.addIssue(path, entrypoint.Identifier, "entrypoint references a service group that is only defined in %s", strings.Join(files, ", "))
+			}
+		}
+	}
+	slices.SortStableFunc(report.Issues, func(a, b CompositionIssue) int {
+		return slices.Index(paths, a.File) - slices.Index(paths, b.File)
+	})
+	return report, nil
+}
+
+func (r *CompositionReport) addIssue(file, serviceGroup, format string, args ...any) {
+	r.Issues = append(r.Issues, CompositionIssue{File: file, ServiceGroup: serviceGroup, Message: fmt.Sprintf(format, args...)})
+}
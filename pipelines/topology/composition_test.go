@@ -0,0 +1,99 @@
+package topology
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompose(t *testing.T) {
+	t.Run("well-formed composition", func(t *testing.T) {
+		teamAPath := filepath.Join("testdata", "team-a", "topology.yaml")
+		teamBPath := filepath.Join("testdata", "team-b", "topology.yaml")
+		report, err := Compose([]string{teamAPath, teamBPath})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := report.Err(); err != nil {
+			t.Errorf("unexpected issues: %v", err)
+		}
+		wantSources := map[string][]string{
+			"Microsoft.Azure.ARO.HCP":           {teamAPath},
+			"Microsoft.Azure.ARO.HCP.Child":     {teamAPath},
+			"Microsoft.Azure.ARO.Classic":       {teamBPath},
+			"Microsoft.Azure.ARO.HCP.Extension": {teamBPath},
+		}
+		if diff := cmp.Diff(wantSources, report.Sources); diff != "" {
+			t.Errorf("unexpected sources (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(map[string]string{"Microsoft.Azure.ARO.HCP.Extension": "Microsoft.Azure.ARO.HCP"}, report.ExternalParents); diff != "" {
+			t.Errorf("unexpected external parents (-want +got):\n%s", diff)
+		}
+		if !strings.Contains(report.String(), "  Microsoft.Azure.ARO.HCP.Extension (external parent: Microsoft.Azure.ARO.HCP)\n") {
+			t.Errorf("expected the report to record the external parent, got:\n%s", report.String())
+		}
+	})
+
+	t.Run("problems are attributed to their files", func(t *testing.T) {
+		first := writeTempTopology(t, `entrypoints:
+- identifier: Microsoft.Azure.ARO.HCP.Other
+services:
+- serviceGroup: Microsoft.Azure.ARO.HCP
+  pipelinePath: foo
+  purpose: stuff
+- serviceGroup: Microsoft.Azure.ARO.HCP.Orphan
+  pipelinePath: foo
+  purpose: stuff
+  externalParent: Microsoft.Azure.ARO.HCP.Missing`)
+		second := writeTempTopology(t, `entrypoints:
+- identifier: Microsoft.Azure.ARO.HCP.Nowhere
+services:
+- serviceGroup: Microsoft.Azure.ARO.HCP
+  pipelinePath: bar
+  purpose: stuff
+- serviceGroup: Microsoft.Azure.ARO.HCP.Other
+  pipelinePath: bar
+  purpose: stuff
+  externalParent: Microsoft.Azure.ARO.HCP
+  children:
+  - serviceGroup: Microsoft.Azure.ARO.HCP.Nested
+    pipelinePath: bar
+    purpose: stuff
+    externalParent: Microsoft.Azure.ARO.HCP`)
+		report, err := Compose([]string{first, second})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []CompositionIssue{
+			{File: first, ServiceGroup: "Microsoft.Azure.ARO.HCP.Orphan", Message: "external parent Microsoft.Azure.ARO.HCP.Missing is not defined in any topology file"},
+			{File: first, ServiceGroup: "Microsoft.Azure.ARO.HCP.Other", Message: "entrypoint references a service group that is only defined in " + second},
+			{File: second, ServiceGroup: "Microsoft.Azure.ARO.HCP", Message: "service group is already defined in " + first + "; service groups must be unique across topologies"},
+			{File: second, ServiceGroup: "Microsoft.Azure.ARO.HCP.Other", Message: "service Microsoft.Azure.ARO.HCP.Nested has externalParent set but is a child of Microsoft.Azure.ARO.HCP.Other; externalParent is only allowed on top-level services"},
+			{File: second, ServiceGroup: "Microsoft.Azure.ARO.HCP.Other", Message: "external parent Microsoft.Azure.ARO.HCP must be defined exactly once, but is defined in " + first + ", " + second},
+			{File: second, ServiceGroup: "Microsoft.Azure.ARO.HCP.Nowhere", Message: "entrypoint references a service group that is not defined in any topology file"},
+		}
+		if diff := cmp.Diff(want, report.Issues); diff != "" {
+			t.Errorf("unexpected issues (-want +got):\n%s", diff)
+		}
+		if report.Err() == nil {
+			t.Errorf("expected an error")
+		}
+	})
+
+	t.Run("external parents are ignored for a single file", func(t *testing.T) {
+		only := writeTempTopology(t, `services:
+- serviceGroup: Microsoft.Azure.ARO.HCP
+  pipelinePath: foo
+  purpose: stuff
+  externalParent: Microsoft.Azure.ARO.HCP.Missing`)
+		report, err := Compose([]string{only})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := report.Err(); err != nil {
+			t.Errorf("unexpected issues: %v", err)
+		}
+	})
+}
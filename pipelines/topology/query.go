@@ -0,0 +1,112 @@
+package topology
+
+import (
+	"maps"
+)
+
+// Walk visits every service in the tree, parents before their children, along with whether the service is
+// effectively stamped - either declaring so itself or inheriting it from a stamped ancestor. Walking stops at the
+// first error returned by visit.
+func (t *Topology) Walk(visit func(service *Service, stamped bool) error) error {
+	for i := range t.Services {
+		if err := walk(&t.Services[i], false, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walk(s *Service, parentStamped bool, visit func(service *Service, stamped bool) error) error {
+	stamped := parentStamped || s.IsStamped()
+	if err := visit(s, stamped); err != nil {
+		return err
+	}
+	for i := range s.Children {
+		if err := walk(&s.Children[i], stamped, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindByMetadata lists the services whose metadata records the key, in the order they appear in the tree. When value
+// is non-empty, only services recording that value for the key are listed.
+func (t *Topology) FindByMetadata(key, value string) []*Service {
+	var found []*Service
+	_ = t.Walk(func(service *Service, _ bool) error {
+		recorded, ok := service.Metadata[key]
+		if ok && (value == "" || recorded == value) {
+			found = append(found, service)
+		}
+		return nil
+	})
+	return found
+}
+
+// Descendants lists every service beneath the service group in the tree, parents before their children.
+func (t *Topology) Descendants(serviceGroup string) ([]*Service, error) {
+	root, err := t.Lookup(serviceGroup)
+	if err != nil {
+		return nil, err
+	}
+	var descendants []*Service
+	for i := range root.Children {
+		_ = walk(&root.Children[i], false, func(service *Service, _ bool) error {
+			descendants = append(descendants, service)
+			return nil
+		})
+	}
+	return descendants, nil
+}
+
+// EffectiveStamped determines whether each service in the tree is stamped, accounting for stamped ancestors, as
+// PropagateStamped would record, without modifying the topology.
+func (t *Topology) EffectiveStamped() map[string]bool {
+	stamped := map[string]bool{}
+	_ = t.Walk(func(service *Service, effective bool) error {
+		stamped[service.ServiceGroup] = effective
+		return nil
+	})
+	return stamped
+}
+
+// Subtree extracts the tree rooted at the service group as a new topology, holding only the entrypoints that select
+// services within it. The root's stamped flag records what it inherited from its ancestors, so that the new topology
+// is stamped the same way, and its external parent is dropped, as the new topology is self-contained.
+func (t *Topology) Subtree(serviceGroup string) (*Topology, error) {
+	root, err := t.Lookup(serviceGroup)
+	if err != nil {
+		return nil, err
+	}
+	subtree := &Topology{Services: []Service{copyService(*root)}}
+	subtree.Services[0].ExternalParent = nil
+	if t.EffectiveStamped()[serviceGroup] {
+		subtree.Services[0].Stamped = ptr(true)
+	}
+	for _, entrypoint := range t.Entrypoints {
+		if _, err := subtree.Lookup(entrypoint.Identifier); err == nil {
+			entrypoint.Metadata = maps.Clone(entrypoint.Metadata)
+			subtree.Entrypoints = append(subtree.Entrypoints, entrypoint)
+		}
+	}
+	return subtree, nil
+}
+
+// copyService deeply copies the service and its children, so that changes to the copy are not visible in the original.
+func copyService(s Service) Service {
+	s.Metadata = maps.Clone(s.Metadata)
+	if s.ExternalParent != nil {
+		s.ExternalParent = ptr(*s.ExternalParent)
+	}
+	if s.Stamped != nil {
+		s.Stamped = ptr(*s.Stamped)
+	}
+	if s.Children != nil {
+		children := make([]Service, 0, len(s.Children))
+		for _, child := range s.Children {
+			children = append(children, copyService(child))
+		}
+		s.Children = children
+	}
+	return s
+}
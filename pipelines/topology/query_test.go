@@ -0,0 +1,166 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/yaml"
+)
+
+const queryTopology = `entrypoints:
+- identifier: Microsoft.Azure.ARO.HCP.Region
+- identifier: Microsoft.Azure.ARO.HCP.Management.Infra
+  metadata:
+    owner: mgmt
+services:
+- serviceGroup: Microsoft.Azure.ARO.HCP.Region
+  pipelinePath: region.yaml
+  purpose: regional infrastructure
+  metadata:
+    owner: infra
+  children:
+  - serviceGroup: Microsoft.Azure.ARO.HCP.Management.Infra
+    pipelinePath: mgmt.yaml
+    purpose: management clusters
+    stamped: true
+    metadata:
+      owner: mgmt
+      tier: core
+    children:
+    - serviceGroup: Microsoft.Azure.ARO.HCP.Maestro.Agent
+      pipelinePath: maestro.yaml
+      purpose: maestro agent
+      metadata:
+        owner: maestro
+        tier: core
+  - serviceGroup: Microsoft.Azure.ARO.HCP.Service.Infra
+    pipelinePath: svc.yaml
+    purpose: service clusters
+    metadata:
+      owner: infra
+`
+
+func loadQueryTopology(t *testing.T) *Topology {
+	t.Helper()
+	var topo Topology
+	if err := yaml.Unmarshal([]byte(queryTopology), &topo); err != nil {
+		t.Fatalf("failed to unmarshal topology: %v", err)
+	}
+	return &topo
+}
+
+func serviceGroups(services []*Service) []string {
+	var groups []string
+	for _, service := range services {
+		groups = append(groups, service.ServiceGroup)
+	}
+	return groups
+}
+
+func TestFindByMetadata(t *testing.T) {
+	topo := loadQueryTopology(t)
+	for _, testCase := range []struct {
+		name       string
+		key, value string
+		expected   []string
+	}{
+		{
+			name:     "key and value",
+			key:      "owner",
+			value:    "infra",
+			expected: []string{"Microsoft.Azure.ARO.HCP.Region", "Microsoft.Azure.ARO.HCP.Service.Infra"},
+		},
+		{
+			name:     "key only",
+			key:      "tier",
+			expected: []string{"Microsoft.Azure.ARO.HCP.Management.Infra", "Microsoft.Azure.ARO.HCP.Maestro.Agent"},
+		},
+		{
+			name:  "no match",
+			key:   "owner",
+			value: "nobody",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := cmp.Diff(testCase.expected, serviceGroups(topo.FindByMetadata(testCase.key, testCase.value))); diff != "" {
+				t.Errorf("unexpected services (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	topo := loadQueryTopology(t)
+	descendants, err := topo.Descendants("Microsoft.Azure.ARO.HCP.Region")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"Microsoft.Azure.ARO.HCP.Management.Infra", "Microsoft.Azure.ARO.HCP.Maestro.Agent", "Microsoft.Azure.ARO.HCP.Service.Infra"}
+	if diff := cmp.Diff(expected, serviceGroups(descendants)); diff != "" {
+		t.Errorf("unexpected descendants (-want +got):\n%s", diff)
+	}
+
+	descendants, err = topo.Descendants("Microsoft.Azure.ARO.HCP.Maestro.Agent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(descendants) != 0 {
+		t.Errorf("expected no descendants of a leaf, got %v", serviceGroups(descendants))
+	}
+
+	if _, err := topo.Descendants("Microsoft.Azure.ARO.HCP.Missing"); err == nil {
+		t.Errorf("expected an error for a missing service group")
+	}
+}
+
+func TestEffectiveStamped(t *testing.T) {
+	topo := loadQueryTopology(t)
+	expected := map[string]bool{
+		"Microsoft.Azure.ARO.HCP.Region":           false,
+		"Microsoft.Azure.ARO.HCP.Management.Infra": true,
+		"Microsoft.Azure.ARO.HCP.Maestro.Agent":    true,
+		"Microsoft.Azure.ARO.HCP.Service.Infra":    false,
+	}
+	if diff := cmp.Diff(expected, topo.EffectiveStamped()); diff != "" {
+		t.Errorf("unexpected stamped flags (-want +got):\n%s", diff)
+	}
+	if topo.Services[0].Children[0].Children[0].Stamped != nil {
+		t.Errorf("resolving stamped flags must not modify the topology")
+	}
+}
+
+func TestSubtree(t *testing.T) {
+	topo := loadQueryTopology(t)
+	subtree, err := topo.Subtree("Microsoft.Azure.ARO.HCP.Maestro.Agent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &Topology{
+		Services: []Service{{
+			ServiceGroup: "Microsoft.Azure.ARO.HCP.Maestro.Agent",
+			PipelinePath: "maestro.yaml",
+			Purpose:      "maestro agent",
+			Metadata:     map[string]string{"owner": "maestro", "tier": "core"},
+			Stamped:      ptr(true),
+		}},
+	}
+	if diff := cmp.Diff(expected, subtree); diff != "" {
+		t.Errorf("unexpected subtree (-want +got):\n%s", diff)
+	}
+
+	subtree, err = topo.Subtree("Microsoft.Azure.ARO.HCP.Management.Infra")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]Entrypoint{{Identifier: "Microsoft.Azure.ARO.HCP.Management.Infra", Metadata: map[string]string{"owner": "mgmt"}}}, subtree.Entrypoints); diff != "" {
+		t.Errorf("unexpected entrypoints (-want +got):\n%s", diff)
+	}
+	if err := subtree.Validate(); err != nil {
+		t.Errorf("expected the subtree to be a valid topology: %v", err)
+	}
+	subtree.Services[0].Children[0].Metadata["owner"] = "changed"
+	if owner := topo.Services[0].Children[0].Children[0].Metadata["owner"]; owner != "maestro" {
+		t.Errorf("changes to the subtree must not modify the topology, got owner %q", owner)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologycmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func NewTopologyCommand() (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:           "topology",
+		Short:         "Query the services in a topology",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	for _, newCommand := range []func() (*cobra.Command, error){
+		newFindCommand,
+		newDescendantsCommand,
+		newStampedCommand,
+		newExtractCommand,
+	} {
+		subcommand, err := newCommand()
+		if err != nil {
+			return nil, err
+		}
+		cmd.AddCommand(subcommand)
+	}
+	return cmd, nil
+}
+
+// newSubcommand creates a subcommand that operates on the topology provided with --topology.
+func newSubcommand(use, short string, bind func(*cobra.Command), run func(*cobra.Command, *Options) error) (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:           use,
+		Short:         short,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	opts := DefaultOptions()
+	if err := BindOptions(opts, cmd); err != nil {
+		return nil, fmt.Errorf("failed to bind options: %w", err)
+	}
+	bind(cmd)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		validated, err := opts.Validate()
+		if err != nil {
+			return err
+		}
+		completed, err := validated.Complete()
+		if err != nil {
+			return err
+		}
+		return run(cmd, completed)
+	}
+	return cmd, nil
+}
+
+func newFindCommand() (*cobra.Command, error) {
+	var key, value string
+	return newSubcommand("find", "List the services whose metadata records a key, optionally with a specific value",
+		func(cmd *cobra.Command) {
+			cmd.Flags().StringVar(&key, "key", key, "Metadata key to search for.")
+			cmd.Flags().StringVar(&value, "value", value, "Metadata value to search for. If unset, any value matches.")
+		},
+		func(cmd *cobra.Command, opts *Options) error {
+			if key == "" {
+				return fmt.Errorf("the metadata key must be provided with --key")
+			}
+			return opts.Find(cmd.OutOrStdout(), key, value)
+		},
+	)
+}
+
+func newDescendantsCommand() (*cobra.Command, error) {
+	var serviceGroup string
+	return newSubcommand("descendants", "List every service beneath a service in the topology",
+		func(cmd *cobra.Command) {
+			cmd.Flags().StringVar(&serviceGroup, "service-group", serviceGroup, "Service group whose descendants to list.")
+		},
+		func(cmd *cobra.Command, opts *Options) error {
+			if serviceGroup == "" {
+				return fmt.Errorf("the service group must be provided with --service-group")
+			}
+			return opts.Descendants(cmd.OutOrStdout(), serviceGroup)
+		},
+	)
+}
+
+func newStampedCommand() (*cobra.Command, error) {
+	return newSubcommand("stamped", "List every service along with whether it is stamped, accounting for stamped ancestors",
+		func(*cobra.Command) {},
+		func(cmd *cobra.Command, opts *Options) error {
+			return opts.Stamped(cmd.OutOrStdout())
+		},
+	)
+}
+
+func newExtractCommand() (*cobra.Command, error) {
+	var serviceGroup string
+	return newSubcommand("extract", "Extract the sub-tree rooted at a service as a new topology document",
+		func(cmd *cobra.Command) {
+			cmd.Flags().StringVar(&serviceGroup, "service-group", serviceGroup, "Service group at the root of the sub-tree.")
+		},
+		func(cmd *cobra.Command, opts *Options) error {
+			if serviceGroup == "" {
+				return fmt.Errorf("the service group must be provided with --service-group")
+			}
+			return opts.Extract(cmd.OutOrStdout(), serviceGroup)
+		},
+	)
+}
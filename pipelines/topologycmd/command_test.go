@@ -0,0 +1,74 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologycmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologyCommand(t *testing.T) {
+	topologies := []string{"--topology", "../topology/testdata/team-a/topology.yaml", "--topology", "../topology/testdata/team-b/topology.yaml"}
+	for _, testCase := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "descendants",
+			args:     []string{"descendants", "--service-group", "Microsoft.Azure.ARO.HCP"},
+			expected: "Microsoft.Azure.ARO.HCP.Child\nMicrosoft.Azure.ARO.HCP.Extension\n",
+		},
+		{
+			name: "stamped",
+			args: []string{"stamped"},
+			expected: `SERVICE GROUP                      STAMPED
+Microsoft.Azure.ARO.HCP            false
+Microsoft.Azure.ARO.HCP.Child      false
+Microsoft.Azure.ARO.HCP.Extension  false
+Microsoft.Azure.ARO.Classic        false
+`,
+		},
+		{
+			name: "extract",
+			args: []string{"extract", "--service-group", "Microsoft.Azure.ARO.HCP.Child"},
+			expected: `services:
+- pipelinePath: child-pipeline.yaml
+  purpose: HCP child service
+  serviceGroup: Microsoft.Azure.ARO.HCP.Child
+`,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			cmd, err := NewTopologyCommand()
+			require.NoError(t, err)
+			out := &bytes.Buffer{}
+			cmd.SetOut(out)
+			cmd.SetArgs(append(testCase.args, topologies...))
+			require.NoError(t, cmd.Execute())
+			assert.Equal(t, testCase.expected, out.String())
+		})
+	}
+
+	t.Run("missing service group", func(t *testing.T) {
+		cmd, err := NewTopologyCommand()
+		require.NoError(t, err)
+		cmd.SetArgs(append([]string{"extract", "--service-group", "Microsoft.Azure.ARO.HCP.Missing"}, topologies...))
+		require.EqualError(t, cmd.Execute(), "service group Microsoft.Azure.ARO.HCP.Missing not found in service tree")
+	})
+}
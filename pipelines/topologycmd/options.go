@@ -0,0 +1,141 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologycmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+)
+
+func DefaultOptions() *RawOptions {
+	return &RawOptions{}
+}
+
+func BindOptions(opts *RawOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringSliceVar(&opts.TopologyPaths, "topology", opts.TopologyPaths, "Path to a topology file. May be provided more than once to combine topologies.")
+	if err := cmd.MarkFlagFilename("topology"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a file: %w", "topology", err)
+	}
+	return nil
+}
+
+// RawOptions holds input values.
+type RawOptions struct {
+	TopologyPaths []string
+}
+
+// validatedOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedOptions struct {
+	TopologyPaths []string
+}
+
+type ValidatedOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedOptions
+}
+
+// completedOptions is a private wrapper that enforces a call of Complete() before queries can be invoked.
+type completedOptions struct {
+	Topology *topology.Topology
+}
+
+type Options struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedOptions
+}
+
+func (o *RawOptions) Validate() (*ValidatedOptions, error) {
+	if len(o.TopologyPaths) == 0 {
+		return nil, fmt.Errorf("at least one topology file must be provided with --topology")
+	}
+
+	return &ValidatedOptions{
+		validatedOptions: &validatedOptions{
+			TopologyPaths: o.TopologyPaths,
+		},
+	}, nil
+}
+
+func (o *ValidatedOptions) Complete() (*Options, error) {
+	topo, err := topology.LoadCombined(o.TopologyPaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load topology: %w", err)
+	}
+
+	return &Options{
+		completedOptions: &completedOptions{
+			Topology: &topo.Topology,
+		},
+	}, nil
+}
+
+// Find writes the service groups whose metadata records the key, and the value if it is set, one per line.
+func (opts *Options) Find(out io.Writer, key, value string) error {
+	return writeServiceGroups(out, opts.Topology.FindByMetadata(key, value))
+}
+
+// Descendants writes every service group beneath the service group, one per line.
+func (opts *Options) Descendants(out io.Writer, serviceGroup string) error {
+	descendants, err := opts.Topology.Descendants(serviceGroup)
+	if err != nil {
+		return err
+	}
+	return writeServiceGroups(out, descendants)
+}
+
+// Stamped writes a table of every service group and whether it is stamped.
+func (opts *Options) Stamped(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "SERVICE GROUP\tSTAMPED"); err != nil {
+		return err
+	}
+	if err := opts.Topology.Walk(func(service *topology.Service, stamped bool) error {
+		_, err := fmt.Fprintf(w, "%s\t%t\n", service.ServiceGroup, stamped)
+		return err
+	}); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Extract writes the sub-tree rooted at the service group as a topology document.
+func (opts *Options) Extract(out io.Writer, serviceGroup string) error {
+	subtree, err := opts.Topology.Subtree(serviceGroup)
+	if err != nil {
+		return err
+	}
+	encoded, err := yaml.Marshal(subtree)
+	if err != nil {
+		return fmt.Errorf("failed to marshal topology: %w", err)
+	}
+	_, err = out.Write(encoded)
+	return err
+}
+
+func writeServiceGroups(out io.Writer, services []*topology.Service) error {
+	for _, service := range services {
+		if _, err := fmt.Fprintln(out, service.ServiceGroup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -22,6 +22,11 @@ import (
 
 const StepActionARM = "ARM"
 
+func init() {
+	RegisterStepType(StepActionARM, func() Step { return &ARMStep{} })
+	RegisterStepType(StepActionARMStack, func() Step { return &ARMStackStep{} })
+}
+
 // ARMStep represents an ARM deployment step.
 type ARMStep struct {
 	StepMeta        `json:",inline"`
@@ -31,6 +36,9 @@ type ARMStep struct {
 	DeploymentLevel string     `json:"deploymentLevel,omitempty"`
 	OutputOnly      bool       `json:"outputOnly,omitempty"`
 	DeploymentMode  string     `json:"deploymentMode,omitempty"`
+	// Outputs declares the outputs of the deployment, which other steps may consume as inputs. When unset, outputs are
+	// inferred from the template if the pipeline is loaded WithInferredOutputs.
+	Outputs []Output `json:"outputs,omitempty"`
 }
 
 // Description
@@ -43,6 +51,10 @@ func (s *ARMStep) Description() string {
 	return fmt.Sprintf("Step %s\n  Kind: %s\n  %s", s.Name, s.Action, strings.Join(details, "\n  "))
 }
 
+func (s *ARMStep) DeclaredOutputs() []Output {
+	return s.Outputs
+}
+
 func (s *ARMStep) RequiredInputs() []StepDependency {
 	var deps []StepDependency
 	for _, val := range s.Variables {
@@ -70,6 +82,9 @@ type ARMStackStep struct {
 	ActionOnUnmanage string `json:"actionOnUnmanage,omitempty"`
 	// BypassStackOutOfSyncError allows bypassing service errors that indicate the stack resource list is not correctly synchronized.
 	BypassStackOutOfSyncError bool `json:"bypassStackOutOfSyncError,omitempty"`
+	// Outputs declares the outputs of the deployment, which other steps may consume as inputs. When unset, outputs are
+	// inferred from the template if the pipeline is loaded WithInferredOutputs.
+	Outputs []Output `json:"outputs,omitempty"`
 }
 
 // Description
@@ -82,6 +97,10 @@ func (s *ARMStackStep) Description() string {
 	return fmt.Sprintf("Step %s\n  Kind: %s\n  %s", s.Name, s.Action, strings.Join(details, "\n  "))
 }
 
+func (s *ARMStackStep) DeclaredOutputs() []Output {
+	return s.Outputs
+}
+
 func (s *ARMStackStep) RequiredInputs() []StepDependency {
 	var deps []StepDependency
 	for _, val := range s.Variables {
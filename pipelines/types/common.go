@@ -16,8 +16,10 @@ package types
 
 import (
 	"fmt"
+	"reflect"
 	"slices"
 	"strings"
+	"time"
 )
 
 // WellFormedChecker allows introspection of how well-formed this step is over inputs.
@@ -38,6 +40,7 @@ type Step interface {
 	ExternalDependencies() []ExternalStepDependency
 	AutomatedRetries() *AutomatedRetry
 	ConsideredForServiceGroupCompletion() bool
+	Condition() string
 }
 
 type ValidationStep interface {
@@ -58,6 +61,12 @@ type StepMeta struct {
 	// dependent service groups begin. Setting this flag will omit this particular step from that set of leaf nodes
 	// that block execution of dependent steps.
 	OmitFromServiceGroupCompletion bool `json:"omitFromServiceGroupCompletion,omitempty"`
+
+	// When is a CEL expression that determines whether this step runs. It is evaluated against the resolved
+	// configuration, bound to `config`, and the rollout context, bound to `ctx` with the region, regionShort, stamp,
+	// cloud and environment. Graphs built with conditions omit steps whose expression evaluates to false, so that
+	// their dependents depend on the steps the omitted step depended on instead.
+	When string `json:"when,omitempty"`
 }
 
 // StepDependency describes a step that must run before the dependent step may begin.
@@ -129,6 +138,67 @@ func (m *StepMeta) ConsideredForServiceGroupCompletion() bool {
 	return !m.OmitFromServiceGroupCompletion
 }
 
+// Condition exposes the CEL expression that determines whether this step runs. Steps without one always run.
+func (m *StepMeta) Condition() string {
+	return m.When
+}
+
+// StepTimeout parses the timeout of steps that declare one in a timeout field. The boolean result is false when the
+// step has no timeout field or leaves it unset.
+func StepTimeout(step Step) (time.Duration, bool, error) {
+	v := reflect.ValueOf(step)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return 0, false, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return 0, false, nil
+	}
+	field := v.FieldByName("Timeout")
+	if !field.IsValid() || field.Kind() != reflect.String || field.String() == "" {
+		return 0, false, nil
+	}
+	timeout, err := time.ParseDuration(field.String())
+	if err != nil {
+		return 0, false, fmt.Errorf("step %s: invalid timeout %q: %w", step.StepName(), field.String(), err)
+	}
+	return timeout, true, nil
+}
+
+func init() {
+	for action, factory := range map[string]func() Step{
+		StepActionSafeFly:                      func() Step { return &SafeFlyStep{} },
+		StepActionHelm:                         func() Step { return &HelmStep{} },
+		StepActionDelegateChildZone:            func() Step { return &DelegateChildZoneStep{} },
+		StepActionSetCertificateIssuer:         func() Step { return &SetCertificateIssuerStep{} },
+		StepActionCreateCertificate:            func() Step { return &CreateCertificateStep{} },
+		StepActionResourceProviderRegistration: func() Step { return &ResourceProviderRegistrationStep{} },
+		StepActionRPLogs:                       func() Step { return &LogsStep{} },
+		StepActionClusterLogs:                  func() Step { return &LogsStep{} },
+		StepActionFeatureRegistration:          func() Step { return &FeatureRegistrationStep{} },
+		StepActionProviderFeatureRegistration:  func() Step { return &ProviderFeatureRegistrationStep{} },
+		StepActionEv2Registration:              func() Step { return &Ev2RegistrationStep{} },
+		StepActionSecretSync:                   func() Step { return &SecretSyncStep{} },
+		StepActionKusto:                        func() Step { return &KustoStep{} },
+		StepActionPav2:                         func() Step { return &Pav2Step{} },
+		StepActionPublishGenevaAction:          func() Step { return &PublishGenevaActionStep{} },
+		StepActionRunGenevaAction:              func() Step { return &RunGenevaActionStep{} },
+		StepActionGenevaHealth:                 func() Step { return &GenevaHealthStep{} },
+		StepActionPublishGenevaAutomation:      func() Step { return &PublishGenevaAutomationStep{} },
+		StepActionProwJob:                      func() Step { return &ProwJobStep{} },
+		StepActionGrafanaDashboards:            func() Step { return &GrafanaDashboardsStep{} },
+		StepActionGrafanaManage:                func() Step { return &GrafanaManageStep{} },
+		StepActionGrafanaDatasources:           func() Step { return &GrafanaDatasourcesStep{} },
+		StepActionKustoEntityGroups:            func() Step { return &KustoEntityGroupsStep{} },
+	} {
+		RegisterStepType(action, factory)
+	}
+	RegisterValidationStepType(StepActionProwJob, func() ValidationStep { return &ProwJobValidationStep{} })
+}
+
+// GenericStep holds any step with an action that has no registered step type.
 type GenericStep struct {
 	StepMeta `json:",inline"`
 }
@@ -145,6 +215,7 @@ func (s *GenericStep) IsWellFormedOverInputs() bool {
 	return false
 }
 
+// GenericValidationStep holds any validation step with an action that has no registered validation step type.
 type GenericValidationStep struct {
 	StepMeta   `json:",inline"`
 	Validation []string `json:"validation,omitempty"`
@@ -268,7 +339,7 @@ type CreateCertificateStep struct {
 	SecretName      Value  `json:"secretName,omitempty"`
 	ApplicationId   Value  `json:"applicationId,omitempty"`
 	CommonName      Value  `json:"commonName,omitempty"`
-	Manage          *Value `json:"manage,omitempty"`
+	Manage          *Value `json:"manage,omitempty" kind:"any"`
 }
 
 func (s *CreateCertificateStep) Description() string {
@@ -294,7 +365,7 @@ const StepActionResourceProviderRegistration = "ResourceProviderRegistration"
 
 type ResourceProviderRegistrationStep struct {
 	StepMeta                   `json:",inline"`
-	ResourceProviderNamespaces Value `json:"resourceProviderNamespaces,omitempty"`
+	ResourceProviderNamespaces Value `json:"resourceProviderNamespaces,omitempty" kind:"list"`
 }
 
 func (s *ResourceProviderRegistrationStep) Description() string {
@@ -681,8 +752,8 @@ type PublishGenevaAutomationStep struct {
 	SecretKeyVault             Value  `json:"secretKeyVault,omitempty"`
 	KustoClientSecretName      Value  `json:"kustoClientSecretName,omitempty"`
 	GenevaAutomationSecretName Value  `json:"genevaAutomationSecretName,omitempty"`
-	IcmServiceId               Value  `json:"icmServiceId,omitempty"`
-	IcmTeamId                  *Value `json:"icmTeamId,omitempty"`
+	IcmServiceId               Value  `json:"icmServiceId,omitempty" kind:"any"`
+	IcmTeamId                  *Value `json:"icmTeamId,omitempty" kind:"any"`
 	WorkflowPath               string `json:"workflowPath,omitempty"`
 
 	GenevaAutomationArtifact AdoArtifactDownloadPipelineReference `json:"genevaAutomationArtifact,omitempty"`
@@ -720,12 +791,12 @@ type ProwJobStep struct {
 	TokenKeyvault        string `json:"tokenKeyvault"`
 	TokenSecret          string `json:"tokenSecret"`
 	JobName              string `json:"jobName"`
-	GatePromotion        string `json:"gatePromotion"`                  // string-encoded boolean, passed to command as a flag
+	GatePromotion        bool   `json:"gatePromotion"`                  // passed to command as a flag; string-encoded before pipeline.schema.v2
 	AllowedSubscriptions string `json:"allowedSubscriptions,omitempty"` // optional slot-manager subscription allowlist override; see slot-manager docs
 	Commit               string `json:"commit,omitempty"`               // optional source commit SHA to pin the Prow job to
 	Repo                 string `json:"repo,omitempty"`                 // optional GitHub repo name override (default: ARO-HCP)
 	BaseRef              string `json:"baseRef,omitempty"`              // optional Git base ref override (default: main)
-	DryRun               Value  `json:"dryRun,omitempty"`
+	DryRun               Value  `json:"dryRun,omitempty" kind:"bool"`
 
 	// IdentityFrom specifies the managed identity with which this deployment will run in Ev2.
 	IdentityFrom Input `json:"identityFrom,omitempty"`
@@ -808,8 +879,8 @@ type GrafanaManageStep struct {
 	GrafanaName              Value  `json:"grafanaName"`
 	Location                 Value  `json:"location"`
 	SKU                      Value  `json:"sku,omitempty"`
-	MajorVersion             Value  `json:"majorVersion,omitempty"`
-	ZoneRedundancy           Value  `json:"zoneRedundancy,omitempty"`
+	MajorVersion             Value  `json:"majorVersion,omitempty" kind:"any"`
+	ZoneRedundancy           Value  `json:"zoneRedundancy,omitempty" kind:"any"`
 	PublicNetworkAccess      Value  `json:"publicNetworkAccess,omitempty"`
 	CrossTenantSecurityGroup Value  `json:"crossTenantSecurityGroup,omitempty"`
 	Timeout                  string `json:"timeout,omitempty"`
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -399,3 +400,29 @@ func TestGrafanaDatasourcesStepNoBooleanFields(t *testing.T) {
 		}
 	}
 }
+
+func TestStepTimeout(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		step     Step
+		expected time.Duration
+		declared bool
+		err      bool
+	}{
+		{name: "shell", step: &ShellStep{Timeout: "15m"}, expected: 15 * time.Minute, declared: true},
+		{name: "helm", step: &HelmStep{Timeout: "1h"}, expected: time.Hour, declared: true},
+		{name: "unset", step: &ShellStep{}},
+		{name: "no timeout field", step: &ARMStep{}},
+		{name: "invalid", step: &ShellStep{Timeout: "soon"}, err: true},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			timeout, declared, err := StepTimeout(testCase.step)
+			if (err != nil) != testCase.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if timeout != testCase.expected || declared != testCase.declared {
+				t.Errorf("expected %v (declared=%v), got %v (declared=%v)", testCase.expected, testCase.declared, timeout, declared)
+			}
+		})
+	}
+}
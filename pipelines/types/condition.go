@@ -0,0 +1,123 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+
+	"github.com/Azure/ARO-Tools/config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+)
+
+var conditionEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return config.NewCELEnv(
+		cel.Variable("config", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("ctx", cel.MapType(cel.StringType, cel.StringType)),
+	)
+})
+
+// compiledCondition is the outcome of compiling a condition, cached in compiledConditions by its expression.
+type compiledCondition struct {
+	program cel.Program
+	err     error
+}
+
+// compiledConditions caches compiled conditions, as the same expressions are evaluated for every step, stamp and
+// context a pipeline is loaded for. Programs are safe for concurrent use.
+var compiledConditions sync.Map
+
+// CompileCondition compiles the CEL expression in a step's when field, which must evaluate to a bool. Expressions
+// reading from config are only known to be dynamically typed until they are evaluated. Expressions are only
+// compiled once, later calls return the same program.
+func CompileCondition(expression string) (cel.Program, error) {
+	if cached, ok := compiledConditions.Load(expression); ok {
+		compiled := cached.(compiledCondition)
+		return compiled.program, compiled.err
+	}
+	program, err := compileCondition(expression)
+	compiledConditions.Store(expression, compiledCondition{program: program, err: err})
+	return program, err
+}
+
+func compileCondition(expression string) (cel.Program, error) {
+	env, err := conditionEnv()
+	if err != nil {
+		return nil, err
+	}
+	checked, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile CEL expression %q: %w", expression, issues.Err())
+	}
+	if outputType := checked.OutputType(); outputType != cel.BoolType && outputType != cel.DynType {
+		return nil, fmt.Errorf("CEL expression %q must return bool, got %s", expression, checked.OutputType())
+	}
+	return env.Program(checked)
+}
+
+// ConditionEvaluator determines which steps run in a rollout context, by evaluating the condition in their when field.
+type ConditionEvaluator struct {
+	config types2.Configuration
+	ctx    map[string]string
+}
+
+// NewConditionEvaluator creates an evaluator for the context described by the replacements, with cfg as the resolved
+// configuration for that context.
+func NewConditionEvaluator(cfg types2.Configuration, replacements *config.ConfigReplacements) *ConditionEvaluator {
+	return &ConditionEvaluator{
+		config: cfg,
+		ctx: map[string]string{
+			"region":      replacements.RegionReplacement,
+			"regionShort": replacements.RegionShortReplacement,
+			"stamp":       replacements.StampReplacement,
+			"cloud":       replacements.CloudReplacement,
+			"environment": replacements.EnvironmentReplacement,
+		},
+	}
+}
+
+// ForStamp creates an evaluator for the same context, but a different stamp.
+func (e *ConditionEvaluator) ForStamp(stamp string) *ConditionEvaluator {
+	ctx := maps.Clone(e.ctx)
+	ctx["stamp"] = stamp
+	return &ConditionEvaluator{config: e.config, ctx: ctx}
+}
+
+// Enabled determines whether the step runs in this context.
+func (e *ConditionEvaluator) Enabled(step Step) (bool, error) {
+	expression := step.Condition()
+	if expression == "" {
+		return true, nil
+	}
+	program, err := CompileCondition(expression)
+	if err != nil {
+		return false, fmt.Errorf("step %s: %w", step.StepName(), err)
+	}
+	result, _, err := program.Eval(map[string]any{
+		"config": map[string]any(e.config),
+		"ctx":    e.ctx,
+	})
+	if err != nil {
+		return false, fmt.Errorf("step %s: failed to evaluate CEL expression %q: %w", step.StepName(), expression, err)
+	}
+	enabled, ok := result.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("step %s: CEL expression %q returned non-bool value: %T", step.StepName(), expression, result.Value())
+	}
+	return enabled, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+)
+
+func TestConditionEvaluator(t *testing.T) {
+	evaluator := NewConditionEvaluator(
+		types2.Configuration{
+			"feature": map[string]any{"enabled": true},
+			"version": "1.30.2",
+		},
+		&config.ConfigReplacements{
+			RegionReplacement:      "eastus",
+			RegionShortReplacement: "eus",
+			StampReplacement:       "1",
+			CloudReplacement:       "public",
+			EnvironmentReplacement: "int",
+		},
+	)
+
+	for _, testCase := range []struct {
+		name      string
+		when      string
+		evaluator *ConditionEvaluator
+		enabled   bool
+		err       string
+	}{
+		{name: "no condition", enabled: true},
+		{name: "config", when: "config.feature.enabled", enabled: true},
+		{name: "context", when: `ctx.cloud == "public" && ctx.region != "westus3"`, enabled: true},
+		{name: "semver", when: `semver(config.version) < semver("1.29.0")`, enabled: false},
+		{name: "stamp", when: `ctx.stamp == "1"`, enabled: true},
+		{name: "other stamp", when: `ctx.stamp == "1"`, evaluator: evaluator.ForStamp("2"), enabled: false},
+		{name: "invalid syntax", when: "config.feature.enabled &&", err: `step test: failed to compile CEL expression "config.feature.enabled &&"`},
+		{name: "non-bool", when: "ctx.region", err: `step test: CEL expression "ctx.region" must return bool, got string`},
+		{name: "non-bool config", when: "config.version", err: `step test: CEL expression "config.version" returned non-bool value: string`},
+		{name: "missing config", when: "config.missing", err: `step test: failed to evaluate CEL expression "config.missing"`},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			e := evaluator
+			if testCase.evaluator != nil {
+				e = testCase.evaluator
+			}
+			enabled, err := e.Enabled(&ShellStep{StepMeta: StepMeta{Name: "test", When: testCase.when}})
+			if testCase.err != "" {
+				require.ErrorContains(t, err, testCase.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.enabled, enabled)
+		})
+	}
+}
+
+func TestCompileConditionCached(t *testing.T) {
+	program, err := CompileCondition(`ctx.stamp == "cached"`)
+	require.NoError(t, err)
+	again, err := CompileCondition(`ctx.stamp == "cached"`)
+	require.NoError(t, err)
+	assert.Same(t, program, again)
+
+	_, err = CompileCondition("ctx.stamp ==")
+	require.Error(t, err)
+	_, againErr := CompileCondition("ctx.stamp ==")
+	assert.Equal(t, err, againErr)
+}
+
+const conditionPipeline = `serviceGroup: test
+rolloutName: test
+resourceGroups:
+- name: rg
+  resourceGroup: rg
+  subscription: sub
+  steps:
+  - name: script
+    action: Shell
+    command: make
+    shellIdentity:
+      value: test-msi
+    when: config.feature.enabled
+  - name: broken
+    action: Shell
+    command: make
+    shellIdentity:
+      value: test-msi
+    when: ctx.region
+`
+
+func TestValidateConditions(t *testing.T) {
+	dir := t.TempDir()
+	pipelinePath := filepath.Join(dir, "pipeline.yaml")
+	require.NoError(t, os.WriteFile(pipelinePath, []byte(conditionPipeline), 0644))
+
+	report, err := ValidatePipelineFile(pipelinePath, map[string]any{})
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, IssueCodeInvalidCondition, report.Issues[0].Code)
+	assert.Equal(t, "resourceGroups[0].steps[1].when", report.Issues[0].Location)
+	require.NotNil(t, report.Issues[0].Position)
+	assert.Equal(t, 19, report.Issues[0].Position.Line)
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Matches determines whether the constraint allows execution in a context. An empty value in the context leaves that
+// parameter unconstrained, so that contexts that are not specific to e.g. a stamp match every stamp. Singleton limits
+// how often a pipeline runs, not where, so it does not affect whether the constraint matches.
+func (c *ExecutionConstraint) Matches(cloud, environment, region, stamp string) bool {
+	matches := func(allowed []string, value string) bool {
+		return len(allowed) == 0 || value == "" || slices.Contains(allowed, value)
+	}
+	return matches(c.Clouds, cloud) &&
+		matches(c.Environments, environment) &&
+		matches(c.Regions, region) &&
+		matches(c.Stamps, stamp)
+}
+
+// AppliesTo determines whether the resource group is deployed in a context, which is true when it has no execution
+// constraints or when any one of them matches the context. Unstamped resource groups are deployed once for all
+// stamps, so the stamp is only considered for stamped resource groups.
+func (m *ResourceGroupMeta) AppliesTo(cloud, environment, region, stamp string) bool {
+	if len(m.ExecutionConstraints) == 0 {
+		return true
+	}
+	if !m.Stamped {
+		stamp = ""
+	}
+	for _, constraint := range m.ExecutionConstraints {
+		if constraint.Matches(cloud, environment, region, stamp) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForContext returns a copy of the pipeline containing only the resource groups that apply to a context, as determined
+// by AppliesTo. Steps that depend on steps in an omitted resource group depend on the steps those depended on instead,
+// transitively, so that ordering between the remaining steps is preserved. The receiver is not modified; steps whose
+// dependencies change are copied.
+//
+// An error is returned if a remaining step consumes outputs of a step in an omitted resource group.
+func (p *Pipeline) ForContext(cloud, environment, region, stamp string) (*Pipeline, error) {
+	omitted := map[StepDependency]Step{}
+	var resourceGroups []*ResourceGroup
+	for _, rg := range p.ResourceGroups {
+		if rg.ResourceGroupMeta == nil || rg.AppliesTo(cloud, environment, region, stamp) {
+			resourceGroups = append(resourceGroups, rg)
+			continue
+		}
+		for _, step := range rg.Steps {
+			omitted[StepDependency{ResourceGroup: rg.Name, Step: step.StepName()}] = step
+		}
+		for _, step := range rg.ValidationSteps {
+			omitted[StepDependency{ResourceGroup: rg.Name, Step: step.StepName()}] = step
+		}
+	}
+
+	var resolve func(deps []StepDependency, visited sets.Set[StepDependency]) []StepDependency
+	resolve = func(deps []StepDependency, visited sets.Set[StepDependency]) []StepDependency {
+		var resolved []StepDependency
+		for _, dep := range deps {
+			step, isOmitted := omitted[dep]
+			if !isOmitted {
+				if !slices.Contains(resolved, dep) {
+					resolved = append(resolved, dep)
+				}
+				continue
+			}
+			if visited.Has(dep) {
+				continue
+			}
+			visited.Insert(dep)
+			for _, replacement := range resolve(slices.Concat(step.Dependencies(), step.RequiredInputs()), visited) {
+				if !slices.Contains(resolved, replacement) {
+					resolved = append(resolved, replacement)
+				}
+			}
+		}
+		return resolved
+	}
+
+	// rewire determines the dependencies a remaining step has in the pruned pipeline, reporting whether they changed.
+	rewire := func(rg *ResourceGroup, step Step) ([]StepDependency, bool, error) {
+		for _, input := range step.RequiredInputs() {
+			if _, isOmitted := omitted[input]; isOmitted {
+				return nil, false, fmt.Errorf("step %s/%s consumes outputs of step %s/%s, whose resource group does not apply to this context", rg.Name, step.StepName(), input.ResourceGroup, input.Step)
+			}
+		}
+		changed := false
+		for _, dep := range step.Dependencies() {
+			if _, isOmitted := omitted[dep]; isOmitted {
+				changed = true
+			}
+		}
+		if !changed {
+			return nil, false, nil
+		}
+		return resolve(step.Dependencies(), sets.New[StepDependency]()), true, nil
+	}
+
+	pruned := *p
+	pruned.ResourceGroups = make([]*ResourceGroup, 0, len(resourceGroups))
+	for _, rg := range resourceGroups {
+		copied := *rg
+		copied.Steps = slices.Clone(rg.Steps)
+		copied.ValidationSteps = slices.Clone(rg.ValidationSteps)
+		for i, step := range copied.Steps {
+			deps, changed, err := rewire(rg, step)
+			if err != nil {
+				return nil, err
+			}
+			if !changed {
+				continue
+			}
+			if copied.Steps[i], err = withDependencies(step, deps, newStep); err != nil {
+				return nil, fmt.Errorf("step %s/%s: %w", rg.Name, step.StepName(), err)
+			}
+		}
+		for i, step := range copied.ValidationSteps {
+			deps, changed, err := rewire(rg, step)
+			if err != nil {
+				return nil, err
+			}
+			if !changed {
+				continue
+			}
+			if copied.ValidationSteps[i], err = withDependencies(step, deps, newValidationStep); err != nil {
+				return nil, fmt.Errorf("step %s/%s: %w", rg.Name, step.StepName(), err)
+			}
+		}
+		pruned.ResourceGroups = append(pruned.ResourceGroups, &copied)
+	}
+	return &pruned, nil
+}
+
+// withDependencies copies a step, replacing the steps it depends on. Steps are copied through their serialized form
+// so that every registered step type can be handled, whatever its Go type.
+func withDependencies[T Step](step T, deps []StepDependency, factory func(action string) T) (T, error) {
+	var copied T
+	raw, err := json.Marshal(step)
+	if err != nil {
+		return copied, fmt.Errorf("failed to marshal step: %w", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return copied, fmt.Errorf("failed to unmarshal step: %w", err)
+	}
+	if len(deps) == 0 {
+		delete(fields, "dependsOn")
+	} else {
+		fields["dependsOn"] = deps
+	}
+	if raw, err = json.Marshal(fields); err != nil {
+		return copied, fmt.Errorf("failed to marshal step: %w", err)
+	}
+	copied = factory(step.ActionType())
+	if err := json.Unmarshal(raw, copied); err != nil {
+		return copied, fmt.Errorf("failed to unmarshal step: %w", err)
+	}
+	return copied, nil
+}
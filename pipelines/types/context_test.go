@@ -0,0 +1,204 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppliesTo(t *testing.T) {
+	for _, testCase := range []struct {
+		name        string
+		meta        ResourceGroupMeta
+		cloud       string
+		environment string
+		region      string
+		stamp       string
+		expected    bool
+	}{
+		{
+			name:     "unconstrained",
+			cloud:    "public",
+			expected: true,
+		},
+		{
+			name: "matching cloud and environment",
+			meta: ResourceGroupMeta{ExecutionConstraints: []ExecutionConstraint{
+				{Clouds: []string{"public", "ff"}, Environments: []string{"int"}},
+			}},
+			cloud:       "ff",
+			environment: "int",
+			region:      "usgovvirginia",
+			expected:    true,
+		},
+		{
+			name: "mismatched environment",
+			meta: ResourceGroupMeta{ExecutionConstraints: []ExecutionConstraint{
+				{Clouds: []string{"public"}, Environments: []string{"int"}},
+			}},
+			cloud:       "public",
+			environment: "prod",
+			expected:    false,
+		},
+		{
+			name: "any constraint matches",
+			meta: ResourceGroupMeta{ExecutionConstraints: []ExecutionConstraint{
+				{Clouds: []string{"public"}, Regions: []string{"eastus"}},
+				{Clouds: []string{"public"}, Regions: []string{"westus3"}},
+			}},
+			cloud:    "public",
+			region:   "westus3",
+			expected: true,
+		},
+		{
+			name: "no constraint matches",
+			meta: ResourceGroupMeta{ExecutionConstraints: []ExecutionConstraint{
+				{Clouds: []string{"public"}, Regions: []string{"eastus"}},
+				{Clouds: []string{"ff"}},
+			}},
+			cloud:    "public",
+			region:   "westus3",
+			expected: false,
+		},
+		{
+			name: "unspecified region is unconstrained",
+			meta: ResourceGroupMeta{ExecutionConstraints: []ExecutionConstraint{
+				{Regions: []string{"eastus"}},
+			}},
+			cloud:    "public",
+			expected: true,
+		},
+		{
+			name: "singleton does not constrain",
+			meta: ResourceGroupMeta{ExecutionConstraints: []ExecutionConstraint{
+				{Singleton: true, Clouds: []string{"public"}},
+			}},
+			cloud:    "public",
+			expected: true,
+		},
+		{
+			name: "stamped resource group in another stamp",
+			meta: ResourceGroupMeta{Stamped: true, ExecutionConstraints: []ExecutionConstraint{
+				{Stamps: []string{"1"}},
+			}},
+			cloud:    "public",
+			stamp:    "2",
+			expected: false,
+		},
+		{
+			name: "unstamped resource group ignores stamp",
+			meta: ResourceGroupMeta{ExecutionConstraints: []ExecutionConstraint{
+				{Stamps: []string{"1"}},
+			}},
+			cloud:    "public",
+			stamp:    "2",
+			expected: true,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, testCase.meta.AppliesTo(testCase.cloud, testCase.environment, testCase.region, testCase.stamp))
+		})
+	}
+}
+
+const contextPipeline = `serviceGroup: test
+rolloutName: test
+resourceGroups:
+- name: global
+  resourceGroup: global
+  subscription: sub
+  steps:
+  - name: setup
+    action: Shell
+    command: make setup
+    shellIdentity:
+      value: test-msi
+- name: public
+  resourceGroup: public
+  subscription: sub
+  executionConstraints:
+  - clouds:
+    - public
+  steps:
+  - name: deploy
+    action: Shell
+    command: make deploy
+    shellIdentity:
+      value: test-msi
+    dependsOn:
+    - resourceGroup: global
+      step: setup
+- name: regional
+  resourceGroup: regional
+  subscription: sub
+  steps:
+  - name: rollout
+    action: Shell
+    command: make rollout
+    shellIdentity:
+      value: test-msi
+    dependsOn:
+    - resourceGroup: public
+      step: deploy
+`
+
+func TestForContext(t *testing.T) {
+	pipeline, err := NewPipelineFromBytes([]byte(contextPipeline), map[string]any{})
+	require.NoError(t, err)
+
+	public, err := pipeline.ForContext("public", "int", "eastus", "")
+	require.NoError(t, err)
+	assert.Equal(t, pipeline, public)
+
+	sovereign, err := pipeline.ForContext("ff", "int", "usgovvirginia", "")
+	require.NoError(t, err)
+	require.Len(t, sovereign.ResourceGroups, 2)
+	assert.Equal(t, "global", sovereign.ResourceGroups[0].Name)
+	assert.Equal(t, "regional", sovereign.ResourceGroups[1].Name)
+	rollout := sovereign.ResourceGroups[1].Steps[0].(*ShellStep)
+	assert.Equal(t, []StepDependency{{ResourceGroup: "global", Step: "setup"}}, rollout.DependsOn)
+	assert.Equal(t, "make rollout", rollout.Command)
+	require.NoError(t, sovereign.Validate())
+
+	// the original pipeline is not modified
+	require.Len(t, pipeline.ResourceGroups, 3)
+	assert.Equal(t, []StepDependency{{ResourceGroup: "public", Step: "deploy"}}, pipeline.ResourceGroups[2].Steps[0].Dependencies())
+
+	consumer := pipeline.ResourceGroups[2].Steps[0].(*ShellStep)
+	consumer.Variables = []Variable{{Name: "ENDPOINT", Value: Value{Input: &Input{
+		StepDependency: StepDependency{ResourceGroup: "public", Step: "deploy"},
+		Name:           "endpoint",
+	}}}}
+	_, err = pipeline.ForContext("ff", "int", "usgovvirginia", "")
+	require.EqualError(t, err, "step regional/rollout consumes outputs of step public/deploy, whose resource group does not apply to this context")
+}
+
+func TestValidateExecutionConstraints(t *testing.T) {
+	rg := &ResourceGroup{ResourceGroupMeta: &ResourceGroupMeta{
+		Name:                 "rg",
+		Subscription:         "sub",
+		ExecutionConstraints: []ExecutionConstraint{{Clouds: []string{"public"}}, {Stamps: []string{"1"}}},
+	}}
+	report := rg.validationReport("resourceGroups[0]")
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, IssueCodeInvalidExecutionConstraint, report.Issues[0].Code)
+	assert.Equal(t, "resourceGroups[0].executionConstraints[1].stamps", report.Issues[0].Location)
+
+	rg.Stamped = true
+	require.NoError(t, rg.Validate())
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// maxCyclesPerComponent bounds the cycles reported between one set of mutually dependent steps, as a densely
+// connected set of steps can form exponentially many.
+const maxCyclesPerComponent = 10
+
+// CycleError records the dependency cycles found between steps of a pipeline. Callers that want to render
+// the cycles themselves can use errors.As to retrieve the paths.
+type CycleError struct {
+	// Cycles holds each cycle as an ordered list of hops in execution order: every step runs before the next
+	// one in the list, and the last entry repeats the first to close the loop.
+	Cycles [][]StepDependency
+	// Truncated is set when some set of mutually dependent steps forms more cycles than are reported, in which
+	// case Cycles holds only the first few found between those steps.
+	Truncated bool
+}
+
+func (e *CycleError) Error() string {
+	var formatted []string
+	for _, cycle := range e.Cycles {
+		formatted = append(formatted, formatCycle(cycle))
+	}
+	if e.Truncated {
+		return fmt.Sprintf("pipeline has more than %d dependency cycle(s), showing the first: %s", len(e.Cycles), strings.Join(formatted, "; "))
+	}
+	return fmt.Sprintf("pipeline has %d dependency cycle(s): %s", len(e.Cycles), strings.Join(formatted, "; "))
+}
+
+// formatCycle renders a cycle as resourceGroup/step hops joined by arrows.
+func formatCycle(cycle []StepDependency) string {
+	hops := make([]string, 0, len(cycle))
+	for _, hop := range cycle {
+		hops = append(hops, fmt.Sprintf("%s/%s", hop.ResourceGroup, hop.Step))
+	}
+	return strings.Join(hops, " -> ")
+}
+
+// detectCycles returns a *CycleError listing the elementary cycles formed by Dependencies() and RequiredInputs()
+// of the steps in the pipeline, or nil if the pipeline is acyclic. Dependencies must already be known to resolve.
+// At most maxCyclesPerComponent cycles are listed for each strongly connected component.
+func (p *Pipeline) detectCycles() error {
+	var order []StepDependency
+	children := map[StepDependency][]StepDependency{}
+	for _, rg := range p.ResourceGroups {
+		for _, step := range rg.Steps {
+			order = append(order, StepDependency{ResourceGroup: rg.Name, Step: step.StepName()})
+		}
+	}
+	for _, rg := range p.ResourceGroups {
+		for _, step := range rg.Steps {
+			id := StepDependency{ResourceGroup: rg.Name, Step: step.StepName()}
+			dependsOn := slices.Concat(step.Dependencies(), step.RequiredInputs())
+			slices.SortFunc(dependsOn, SortDependencies)
+			for _, dep := range slices.Compact(dependsOn) {
+				children[dep] = append(children[dep], id)
+			}
+		}
+	}
+
+	cycleErr := &CycleError{}
+	for _, component := range stronglyConnectedComponents(order, children) {
+		cycles, truncated := elementaryCycles(component, children, maxCyclesPerComponent)
+		cycleErr.Cycles = append(cycleErr.Cycles, cycles...)
+		cycleErr.Truncated = cycleErr.Truncated || truncated
+	}
+	if len(cycleErr.Cycles) == 0 {
+		return nil
+	}
+	return cycleErr
+}
+
+// stronglyConnectedComponents uses Tarjan's algorithm to partition the steps, returning only those components
+// that can contain a cycle: more than one step, or a single step that depends on itself. Steps within a component
+// keep the order in which they were declared.
+func stronglyConnectedComponents(order []StepDependency, children map[StepDependency][]StepDependency) [][]StepDependency {
+	position := make(map[StepDependency]int, len(order))
+	for i, id := range order {
+		position[id] = i
+	}
+
+	index := map[StepDependency]int{}
+	lowLink := map[StepDependency]int{}
+	onStack := sets.New[StepDependency]()
+	var stack []StepDependency
+	var components [][]StepDependency
+
+	var visit func(id StepDependency)
+	visit = func(id StepDependency) {
+		index[id] = len(index)
+		lowLink[id] = index[id]
+		stack = append(stack, id)
+		onStack.Insert(id)
+
+		for _, child := range children[id] {
+			if _, visited := index[child]; !visited {
+				visit(child)
+				lowLink[id] = min(lowLink[id], lowLink[child])
+			} else if onStack.Has(child) {
+				lowLink[id] = min(lowLink[id], index[child])
+			}
+		}
+
+		if lowLink[id] != index[id] {
+			return
+		}
+		var component []StepDependency
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack.Delete(top)
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		if len(component) > 1 || slices.Contains(children[id], id) {
+			slices.SortFunc(component, func(a, b StepDependency) int {
+				return position[a] - position[b]
+			})
+			components = append(components, component)
+		}
+	}
+
+	for _, id := range order {
+		if _, visited := index[id]; !visited {
+			visit(id)
+		}
+	}
+	slices.SortFunc(components, func(a, b []StepDependency) int {
+		return position[a[0]] - position[b[0]]
+	})
+	return components
+}
+
+// elementaryCycles uses Johnson's algorithm to enumerate the cycles within one strongly connected component, stopping
+// once limit cycles have been found; truncated reports whether any were left out. Each cycle is reported once,
+// starting from its earliest-declared step.
+func elementaryCycles(component []StepDependency, children map[StepDependency][]StepDependency, limit int) (cycles [][]StepDependency, truncated bool) {
+	position := make(map[StepDependency]int, len(component))
+	for i, id := range component {
+		position[id] = i
+	}
+
+	for start, root := range component {
+		var path []StepDependency
+		blocked := sets.New[StepDependency]()
+		blockedBy := map[StepDependency]sets.Set[StepDependency]{}
+
+		var unblock func(id StepDependency)
+		unblock = func(id StepDependency) {
+			blocked.Delete(id)
+			for waiting := range blockedBy[id] {
+				delete(blockedBy[id], waiting)
+				if blocked.Has(waiting) {
+					unblock(waiting)
+				}
+			}
+		}
+
+		// visit extends the path to id, returning whether a cycle was found through it
+		var visit func(id StepDependency) bool
+		visit = func(id StepDependency) bool {
+			found := false
+			path = append(path, id)
+			blocked.Insert(id)
+			var successors []StepDependency
+			for _, child := range children[id] {
+				if childPosition, inComponent := position[child]; inComponent && childPosition >= start {
+					successors = append(successors, child)
+				}
+			}
+			for _, child := range successors {
+				if truncated {
+					return true
+				}
+				switch {
+				case child == root:
+					if len(cycles) == limit {
+						truncated = true
+						return true
+					}
+					cycles = append(cycles, append(slices.Clone(path), root))
+					found = true
+				case !blocked.Has(child):
+					found = visit(child) || found
+				}
+			}
+			if found {
+				unblock(id)
+			} else {
+				for _, child := range successors {
+					if blockedBy[child] == nil {
+						blockedBy[child] = sets.New[StepDependency]()
+					}
+					blockedBy[child].Insert(id)
+				}
+			}
+			path = path[:len(path)-1]
+			return found
+		}
+		if visit(root); truncated {
+			break
+		}
+	}
+	return cycles, truncated
+}
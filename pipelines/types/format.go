@@ -0,0 +1,245 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/Azure/ARO-Tools/tools/yamlwrap"
+)
+
+var (
+	stepType           = reflect.TypeFor[Step]()
+	validationStepType = reflect.TypeFor[ValidationStep]()
+	stepDependencyType = reflect.TypeFor[StepDependency]()
+)
+
+// FormatPipeline rewrites a pipeline document in canonical form, so that pipelines only differ where their content
+// does. Keys are ordered like the fields of the types they unmarshal into - for steps, the type registered for their
+// action - with keys that don't correspond to a field following in their original order, and map keys are sorted.
+// Dependencies are sorted with SortDependencies. Scalars are only quoted where YAML requires it, and collections are
+// written in block style, indented by two spaces.
+//
+// The document may be a template. Comments and template expressions are preserved, but template control structures
+// that make the document invalid YAML, like {{- if }} on a line of their own, are not supported.
+func FormatPipeline(content []byte) ([]byte, error) {
+	// unquoted template expressions are not valid YAML, so they're wrapped into strings while we parse
+	wrapped, err := yamlwrap.WrapYAML(content, false)
+	if err != nil {
+		return nil, err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(wrapped, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline: %w", err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("pipeline must be a YAML mapping")
+	}
+	root := document.Content[0]
+	// a comment heading the document stays at the top, whichever key ends up first
+	var leading string
+	if len(root.Content) > 0 {
+		leading, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	if err := formatNode(root, reflect.TypeFor[Pipeline]()); err != nil {
+		return nil, err
+	}
+	if leading != "" {
+		root.Content[0].HeadComment = strings.TrimSuffix(leading+"\n"+root.Content[0].HeadComment, "\n")
+	}
+
+	var formatted bytes.Buffer
+	encoder := yaml.NewEncoder(&formatted)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, fmt.Errorf("failed to encode pipeline: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode pipeline: %w", err)
+	}
+
+	// formatting must never change what the document holds, so make sure it round-trips before handing it back
+	var expected, actual any
+	if err := document.Decode(&expected); err != nil {
+		return nil, fmt.Errorf("failed to decode pipeline: %w", err)
+	}
+	if err := yaml.Unmarshal(formatted.Bytes(), &actual); err != nil {
+		return nil, fmt.Errorf("formatted pipeline is not valid YAML: %w", err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		return nil, fmt.Errorf("formatting changed the content of the pipeline")
+	}
+	return yamlwrap.UnwrapYAML(formatted.Bytes())
+}
+
+// formatNode formats the node in place, given the type it unmarshals into. Nodes with an unknown type, like those
+// for fields of type any, are only normalized in style.
+func formatNode(node *yaml.Node, t reflect.Type) error {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Interface {
+		switch t {
+		case stepType:
+			t = reflect.TypeOf(newStep(scalarValue(mappingValue(node, "action")))).Elem()
+		case validationStepType:
+			t = reflect.TypeOf(newValidationStep(scalarValue(mappingValue(node, "action")))).Elem()
+		default:
+			t = nil
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		node.Style &^= yaml.FlowStyle
+		var valueType func(key string) reflect.Type
+		switch {
+		case t != nil && t.Kind() == reflect.Struct:
+			fields := jsonFields(t)
+			order := make([]string, 0, len(fields))
+			for _, field := range fields {
+				order = append(order, field.name)
+			}
+			sortMappingKeys(node, func(a, b string) int {
+				return rank(order, a) - rank(order, b)
+			})
+			valueType = func(key string) reflect.Type {
+				if i := slices.IndexFunc(fields, func(field jsonField) bool { return field.name == key }); i >= 0 {
+					return fields[i].t
+				}
+				return nil
+			}
+		case t != nil && t.Kind() == reflect.Map:
+			sortMappingKeys(node, strings.Compare)
+			valueType = func(string) reflect.Type { return t.Elem() }
+		default:
+			valueType = func(string) reflect.Type { return nil }
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := formatNode(node.Content[i], nil); err != nil {
+				return err
+			}
+			if err := formatNode(node.Content[i+1], valueType(node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		node.Style &^= yaml.FlowStyle
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		if elem == stepDependencyType {
+			sortDependencyNodes(node)
+		}
+		for _, item := range node.Content {
+			if err := formatNode(item, elem); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		// wrapped template expressions must stay quoted, so that they are unwrapped
+		if !strings.Contains(node.Value, yamlwrap.WrapperMarker) {
+			node.Style &^= yaml.SingleQuotedStyle | yaml.DoubleQuotedStyle
+		}
+	}
+	return nil
+}
+
+// jsonField is a field of a struct as encoding/json sees it, with embedded structs flattened.
+type jsonField struct {
+	name string
+	t    reflect.Type
+}
+
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(embedded)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{name: name, t: field.Type})
+	}
+	return fields
+}
+
+// rank orders keys by their index in order, placing unknown keys last.
+func rank(order []string, key string) int {
+	if i := slices.Index(order, key); i >= 0 {
+		return i
+	}
+	return len(order)
+}
+
+// sortMappingKeys stably sorts the key-value pairs of a mapping node by their keys.
+func sortMappingKeys(node *yaml.Node, compare func(a, b string) int) {
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{key: node.Content[i], value: node.Content[i+1]})
+	}
+	slices.SortStableFunc(pairs, func(a, b pair) int {
+		return compare(a.key.Value, b.key.Value)
+	})
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p.key, p.value)
+	}
+}
+
+// sortDependencyNodes stably sorts a sequence of dependencies with SortDependencies.
+func sortDependencyNodes(node *yaml.Node) {
+	dependency := func(item *yaml.Node) StepDependency {
+		return StepDependency{
+			ResourceGroup: scalarValue(mappingValue(item, "resourceGroup")),
+			Step:          scalarValue(mappingValue(item, "step")),
+		}
+	}
+	slices.SortStableFunc(node.Content, func(a, b *yaml.Node) int {
+		return SortDependencies(dependency(a), dependency(b))
+	})
+}
+
+func scalarValue(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/testutil"
+)
+
+func TestFormatPipeline(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		content  string
+		expected string
+		err      string
+	}{
+		{
+			name: "keys, dependencies and quoting",
+			content: `# the pipeline
+resourceGroups:
+- steps:
+  - command: "make deploy" # deploy it
+    dependsOn:
+    - {step: b, resourceGroup: rg}
+    - step: a
+      resourceGroup: rg
+    action: Shell
+    name: deploy
+    unknown: kept
+  subscription: '{{ .subscription }}'
+  name: rg
+- name: other
+  steps: []
+serviceGroup: "Microsoft.Azure.ARO.HCP.Test"
+$schema: pipeline.schema.v1
+`,
+			expected: `# the pipeline
+$schema: pipeline.schema.v1
+serviceGroup: Microsoft.Azure.ARO.HCP.Test
+resourceGroups:
+  - name: rg
+    subscription: '{{ .subscription }}'
+    steps:
+      - name: deploy
+        action: Shell
+        dependsOn:
+          - resourceGroup: rg
+            step: a
+          - resourceGroup: rg
+            step: b
+        command: make deploy # deploy it
+        unknown: kept
+  - name: other
+    steps: []
+`,
+		},
+		{
+			name: "step types and maps",
+			content: `resourceGroups:
+- name: rg
+  steps:
+  - timeout: 10m
+    inputVariables:
+      z: {resourceGroup: rg, step: a, name: z}
+      a: {name: a, step: a, resourceGroup: rg}
+    chartDir: chart
+    name: helm
+    action: Helm
+    releaseName: {{ .release }}
+`,
+			expected: `resourceGroups:
+  - name: rg
+    steps:
+      - name: helm
+        action: Helm
+        releaseName: {{ .release }}
+        chartDir: chart
+        inputVariables:
+          a:
+            resourceGroup: rg
+            step: a
+            name: a
+          z:
+            resourceGroup: rg
+            step: a
+            name: z
+        timeout: 10m
+`,
+		},
+		{
+			name:    "not a mapping",
+			content: "- name: rg\n",
+			err:     "pipeline must be a YAML mapping",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			formatted, err := FormatPipeline([]byte(testCase.content))
+			if testCase.err != "" {
+				require.EqualError(t, err, testCase.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, string(formatted))
+		})
+	}
+}
+
+func TestFormatPipelineFile(t *testing.T) {
+	content, err := os.ReadFile("../testdata/pipeline.yaml")
+	require.NoError(t, err)
+
+	formatted, err := FormatPipeline(content)
+	require.NoError(t, err)
+	testutil.CompareWithFixture(t, formatted, testutil.WithExtension(".yaml"))
+
+	reformatted, err := FormatPipeline(formatted)
+	require.NoError(t, err)
+	assert.Equal(t, string(formatted), string(reformatted), "formatting must be idempotent")
+}
@@ -23,6 +23,10 @@ import (
 
 const StepActionImageMirror = "ImageMirror"
 
+func init() {
+	RegisterStepType(StepActionImageMirror, func() Step { return &ImageMirrorStep{} })
+}
+
 //go:embed on-demand.sh
 var OnDemandSyncScript []byte
 
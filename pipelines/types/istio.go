@@ -21,6 +21,10 @@ import (
 
 const StepActionIstioUpgrade = "IstioUpgrade"
 
+func init() {
+	RegisterStepType(StepActionIstioUpgrade, func() Step { return &IstioUpgradeStep{} })
+}
+
 type IstioUpgradeStep struct {
 	StepMeta     `json:",inline"`
 	AKSCluster   Value  `json:"aksCluster"`
@@ -0,0 +1,296 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/tools/yamlwrap"
+)
+
+// migration upgrades a document to a new version of the pipeline format by recording edits to its source.
+type migration func(document *yaml.Node, edits *documentEdits) error
+
+// MigratePipeline rewrites a pipeline document declaring the schema from so that it conforms to the schema to,
+// applying the migration for every version in between. An empty from is treated like a document without a
+// $schema. Pipelines can only be migrated to newer versions.
+//
+// The document may be a template. Migrations edit the source text in place, so comments, formatting and template
+// expressions are preserved outside the values that change. Template control structures that make the document
+// invalid YAML, like {{- if }} on a line of their own, are not supported.
+func MigratePipeline(content []byte, from, to string) ([]byte, error) {
+	fromIndex, err := pipelineSchemaIndex(from)
+	if err != nil {
+		return nil, err
+	}
+	toIndex, err := pipelineSchemaIndex(to)
+	if err != nil {
+		return nil, err
+	}
+	if toIndex < fromIndex {
+		return nil, fmt.Errorf("cannot migrate pipeline from %s to older schema %s", pipelineSchemaVersions[fromIndex].ref, pipelineSchemaVersions[toIndex].ref)
+	}
+
+	for _, version := range pipelineSchemaVersions[fromIndex+1 : toIndex+1] {
+		content, err = migrateDocument(content, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate pipeline to %s: %w", version.ref, err)
+		}
+	}
+	return content, nil
+}
+
+// PipelineSchemaRef determines the schema that a pipeline document declares, which may be a template. Documents
+// without a $schema use the original version of the pipeline format.
+func PipelineSchemaRef(content []byte) (string, error) {
+	wrapped, err := yamlwrap.WrapYAML(content, false)
+	if err != nil {
+		return "", err
+	}
+	var document struct {
+		Schema string `yaml:"$schema"`
+	}
+	if err := yaml.Unmarshal(wrapped, &document); err != nil {
+		return "", fmt.Errorf("failed to determine pipeline schema: %w", err)
+	}
+	if document.Schema == "" {
+		return defaultSchemaRef, nil
+	}
+	return document.Schema, nil
+}
+
+// migrateToLatest migrates rendered pipeline content to the latest schema, so that it can be unmarshalled into the
+// types in this package.
+func migrateToLatest(content []byte) ([]byte, error) {
+	schemaRef, err := PipelineSchemaRef(content)
+	if err != nil {
+		return nil, err
+	}
+	if schemaRef == LatestPipelineSchemaRef() {
+		return content, nil
+	}
+	return MigratePipeline(content, schemaRef, LatestPipelineSchemaRef())
+}
+
+func migrateDocument(content []byte, version pipelineSchemaVersion) ([]byte, error) {
+	// unquoted template expressions are not valid YAML, so they're wrapped into strings while we parse
+	wrapped, err := yamlwrap.WrapYAML(content, false)
+	if err != nil {
+		return nil, err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(wrapped, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline: %w", err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("pipeline must be a YAML mapping")
+	}
+	root := document.Content[0]
+
+	edits := &documentEdits{}
+	if err := version.upgrade(root, edits); err != nil {
+		return nil, err
+	}
+	if schema := mappingValue(root, "$schema"); schema != nil {
+		edits.replace(schema, version.ref)
+	} else {
+		edits.insert(root.Content[0].Line, fmt.Sprintf("$schema: %s", version.ref))
+	}
+
+	migrated, err := edits.apply(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return yamlwrap.UnwrapYAML(migrated)
+}
+
+// migrateV1ToV2 normalizes string-encoded booleans into real booleans.
+func migrateV1ToV2(document *yaml.Node, edits *documentEdits) error {
+	for i, rg := range sequenceItems(mappingValue(document, "resourceGroups")) {
+		for _, key := range []string{"steps", "validationSteps"} {
+			for j, step := range sequenceItems(mappingValue(rg, key)) {
+				if action := mappingValue(step, "action"); action == nil || action.Value != StepActionProwJob {
+					continue
+				}
+				location := config.JoinLocation(config.IndexLocation(config.JoinLocation(config.IndexLocation("resourceGroups", i), key), j), "gatePromotion")
+				if err := normalizeBool(location, mappingValue(step, "gatePromotion"), edits); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeBool rewrites a string-encoded boolean scalar as a boolean, with the empty string meaning false. Template
+// expressions are unquoted, so that the value they render to is parsed as a boolean.
+func normalizeBool(location string, node *yaml.Node, edits *documentEdits) error {
+	if node == nil || node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" {
+		return nil
+	}
+	if strings.HasPrefix(node.Value, yamlwrap.WrapperMarker) {
+		// already an unquoted template expression
+		return nil
+	}
+	if strings.Contains(node.Value, "{{") {
+		edits.replace(node, strconv.Quote(yamlwrap.WrapperMarker+node.Value))
+		return nil
+	}
+	if node.Value == "" {
+		// the original schema allowed an empty string, which did not gate promotion
+		edits.replace(node, "false")
+		return nil
+	}
+	value, err := strconv.ParseBool(node.Value)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a boolean", location, node.Value)
+	}
+	edits.replace(node, strconv.FormatBool(value))
+	return nil
+}
+
+// mappingValue finds the value for a key in a mapping node, if any.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sequenceItems lists the items in a sequence node, if any.
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+// documentEdits records changes to the source of a YAML document, to be applied all at once.
+type documentEdits struct {
+	replacements []replacement
+	insertions   []insertion
+}
+
+type replacement struct {
+	node  *yaml.Node
+	value string
+}
+
+type insertion struct {
+	line int
+	text string
+}
+
+// replace records that the source text of a scalar node should be replaced by value.
+func (e *documentEdits) replace(node *yaml.Node, value string) {
+	e.replacements = append(e.replacements, replacement{node: node, value: value})
+}
+
+// insert records that a line should be inserted before the given (1-indexed) line.
+func (e *documentEdits) insert(line int, text string) {
+	e.insertions = append(e.insertions, insertion{line: line, text: text})
+}
+
+func (e *documentEdits) apply(content []byte) ([]byte, error) {
+	lines := strings.Split(string(content), "\n")
+
+	// replacements later in a line are applied first, so that earlier offsets remain valid
+	replacements := slices.Clone(e.replacements)
+	slices.SortFunc(replacements, func(a, b replacement) int {
+		if a.node.Line != b.node.Line {
+			return a.node.Line - b.node.Line
+		}
+		return b.node.Column - a.node.Column
+	})
+	for _, r := range replacements {
+		index := r.node.Line - 1
+		if index < 0 || index >= len(lines) {
+			return nil, fmt.Errorf("line %d is out of range", r.node.Line)
+		}
+		start, end, err := scalarSpan(lines[index], r.node)
+		if err != nil {
+			return nil, err
+		}
+		lines[index] = lines[index][:start] + r.value + lines[index][end:]
+	}
+
+	// insertions are applied bottom-up, so that earlier line numbers remain valid
+	insertions := slices.Clone(e.insertions)
+	slices.SortStableFunc(insertions, func(a, b insertion) int {
+		return b.line - a.line
+	})
+	for _, i := range insertions {
+		index := max(min(i.line-1, len(lines)), 0)
+		lines = slices.Insert(lines, index, i.text)
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// scalarSpan finds the byte offsets at which a scalar node starts and ends within its line.
+func scalarSpan(line string, node *yaml.Node) (int, int, error) {
+	start := len(line)
+	column := 1
+	for offset := range line {
+		if column == node.Column {
+			start = offset
+			break
+		}
+		column++
+	}
+	if start == len(line) {
+		return 0, 0, fmt.Errorf("line %d: column %d is out of range", node.Line, node.Column)
+	}
+
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		for i := start + 1; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return start, i + 1, nil
+			}
+		}
+	case yaml.SingleQuotedStyle:
+		for i := start + 1; i < len(line); i++ {
+			if line[i] != '\'' {
+				continue
+			}
+			if i+1 < len(line) && line[i+1] == '\'' {
+				i++
+				continue
+			}
+			return start, i + 1, nil
+		}
+	case 0:
+		end := len(line)
+		if comment := strings.Index(line[start:], " #"); comment >= 0 {
+			end = start + comment
+		}
+		return start, start + len(strings.TrimRight(line[start:end], " \t")), nil
+	}
+	return 0, 0, fmt.Errorf("line %d: only single-line scalars can be migrated", node.Line)
+}
@@ -0,0 +1,205 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/config/ev2config"
+	"github.com/Azure/ARO-Tools/testutil"
+)
+
+func TestMigratePipeline(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		content  string
+		from, to string
+		expected string
+		err      string
+	}{
+		{
+			name: "string-encoded booleans",
+			content: `$schema: pipeline.schema.v1 # the schema
+serviceGroup: test
+resourceGroups:
+- name: rg
+  steps:
+  - name: prow
+    action: ProwJob
+    gatePromotion: "True" # gate the rollout
+  - name: shell
+    action: Shell
+    gatePromotion: "true"
+  validationSteps:
+  - {name: prow, action: ProwJob, gatePromotion: 'false'}
+  - name: templated
+    action: ProwJob
+    gatePromotion: '{{ .gate }}'
+  - name: unquoted
+    action: ProwJob
+    gatePromotion: {{ .gate }}
+  - name: boolean
+    action: ProwJob
+    gatePromotion: true
+`,
+			from: pipelineSchemaV1Ref,
+			to:   pipelineSchemaV2Ref,
+			expected: `$schema: pipeline.schema.v2 # the schema
+serviceGroup: test
+resourceGroups:
+- name: rg
+  steps:
+  - name: prow
+    action: ProwJob
+    gatePromotion: true # gate the rollout
+  - name: shell
+    action: Shell
+    gatePromotion: "true"
+  validationSteps:
+  - {name: prow, action: ProwJob, gatePromotion: false}
+  - name: templated
+    action: ProwJob
+    gatePromotion: {{ .gate }}
+  - name: unquoted
+    action: ProwJob
+    gatePromotion: {{ .gate }}
+  - name: boolean
+    action: ProwJob
+    gatePromotion: true
+`,
+		},
+		{
+			name: "no declared schema",
+			content: `# a comment
+serviceGroup: test
+`,
+			to: pipelineSchemaV2Ref,
+			expected: `# a comment
+$schema: pipeline.schema.v2
+serviceGroup: test
+`,
+		},
+		{
+			name:     "same version",
+			content:  "serviceGroup: test\n",
+			from:     pipelineSchemaV1Ref,
+			to:       pipelineSchemaV1Ref,
+			expected: "serviceGroup: test\n",
+		},
+		{
+			name: "empty gatePromotion",
+			content: `$schema: pipeline.schema.v1
+resourceGroups:
+- name: rg
+  steps:
+  - name: double
+    action: ProwJob
+    gatePromotion: ""
+  - name: single
+    action: ProwJob
+    gatePromotion: '' # not gated
+`,
+			from: pipelineSchemaV1Ref,
+			to:   pipelineSchemaV2Ref,
+			expected: `$schema: pipeline.schema.v2
+resourceGroups:
+- name: rg
+  steps:
+  - name: double
+    action: ProwJob
+    gatePromotion: false
+  - name: single
+    action: ProwJob
+    gatePromotion: false # not gated
+`,
+		},
+		{
+			name: "invalid boolean",
+			content: `resourceGroups:
+- steps:
+  - action: ProwJob
+    gatePromotion: sometimes
+`,
+			to:  pipelineSchemaV2Ref,
+			err: `failed to migrate pipeline to pipeline.schema.v2: resourceGroups[0].steps[0].gatePromotion: "sometimes" is not a boolean`,
+		},
+		{
+			name:    "downgrade",
+			content: "serviceGroup: test\n",
+			from:    pipelineSchemaV2Ref,
+			to:      pipelineSchemaV1Ref,
+			err:     "cannot migrate pipeline from pipeline.schema.v2 to older schema pipeline.schema.v1",
+		},
+		{
+			name:    "unknown schema",
+			content: "serviceGroup: test\n",
+			from:    "pipeline.schema.v0",
+			to:      pipelineSchemaV2Ref,
+			err:     "unsupported schema reference: pipeline.schema.v0",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			migrated, err := MigratePipeline([]byte(testCase.content), testCase.from, testCase.to)
+			if testCase.err != "" {
+				require.EqualError(t, err, testCase.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, string(migrated))
+		})
+	}
+}
+
+func TestMigratePipelineFile(t *testing.T) {
+	content, err := os.ReadFile("../testdata/pipeline.yaml")
+	require.NoError(t, err)
+
+	from, err := PipelineSchemaRef(content)
+	require.NoError(t, err)
+	assert.Equal(t, pipelineSchemaV1Ref, from)
+
+	migrated, err := MigratePipeline(content, from, LatestPipelineSchemaRef())
+	require.NoError(t, err)
+	testutil.CompareWithFixture(t, string(migrated), testutil.WithExtension(".yaml"))
+
+	region := "uksouth"
+	ev2, err := ev2config.ResolveConfig("public", region)
+	require.NoError(t, err)
+	provider, err := config.NewConfigProvider("../testdata/config.yaml")
+	require.NoError(t, err)
+	resolver, err := provider.GetResolver(&config.ConfigReplacements{
+		RegionReplacement:      region,
+		RegionShortReplacement: "uks",
+		StampReplacement:       "1",
+		CloudReplacement:       "public",
+		EnvironmentReplacement: "int",
+		Ev2Config:              ev2,
+	})
+	require.NoError(t, err)
+	cfg, err := resolver.GetRegionConfiguration(region)
+	require.NoError(t, err)
+
+	// loading the original pipeline migrates it, so both versions must load to the same thing
+	original, err := NewPipelineFromBytes(content, cfg)
+	require.NoError(t, err)
+	upgraded, err := NewPipelineFromBytes(migrated, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, original, upgraded)
+}
@@ -0,0 +1,265 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/config"
+)
+
+// OutputType is the type of data a step output holds, using the names of ARM template output types.
+type OutputType string
+
+const (
+	OutputTypeString       OutputType = "string"
+	OutputTypeSecureString OutputType = "securestring"
+	OutputTypeInt          OutputType = "int"
+	OutputTypeBool         OutputType = "bool"
+	OutputTypeArray        OutputType = "array"
+	OutputTypeObject       OutputType = "object"
+	OutputTypeSecureObject OutputType = "secureobject"
+)
+
+// Kind determines the kind of data held by an output of this type. Outputs of an unknown type may hold anything.
+func (t OutputType) Kind() ValueKind {
+	switch OutputType(strings.ToLower(string(t))) {
+	case OutputTypeString, OutputTypeSecureString:
+		return ValueKindString
+	case OutputTypeInt:
+		return ValueKindNumber
+	case OutputTypeBool:
+		return ValueKindBool
+	case OutputTypeArray:
+		return ValueKindList
+	case OutputTypeObject, OutputTypeSecureObject:
+		return ValueKindMap
+	default:
+		return ValueKindAny
+	}
+}
+
+// Output declares an output variable produced by a step, which other steps may consume with an Input.
+type Output struct {
+	// Name is the name of the output variable, referenced by Input.Name.
+	Name string `json:"name"`
+	// Type is the type of data the output holds. When unset, consumers are not type-checked.
+	Type OutputType `json:"type,omitempty"`
+	// Description documents the output for consumers.
+	Description string `json:"description,omitempty"`
+}
+
+// OutputDeclarer is implemented by steps that can declare the outputs they produce. A step returning nil has not
+// declared its outputs, so Inputs consuming from it can't be checked; a step returning an empty list produces none.
+type OutputDeclarer interface {
+	DeclaredOutputs() []Output
+}
+
+// InferOutputs declares the outputs of ARM and ARMStack steps that don't declare any, reading them from the template
+// the step deploys. Template paths are relative to baseDir, the directory holding the pipeline. Steps whose template
+// does not exist are left undeclared, and templates that can't be read are reported as warnings.
+func InferOutputs(pipeline *Pipeline, baseDir string) *ValidationReport {
+	report := &ValidationReport{}
+	for i, rg := range pipeline.ResourceGroups {
+		for j, step := range rg.Steps {
+			var template string
+			var outputs *[]Output
+			switch s := step.(type) {
+			case *ARMStep:
+				template, outputs = s.Template, &s.Outputs
+			case *ARMStackStep:
+				template, outputs = s.Template, &s.Outputs
+			default:
+				continue
+			}
+			if template == "" || *outputs != nil {
+				continue
+			}
+			inferred, err := InferTemplateOutputs(filepath.Join(baseDir, template))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			location := config.JoinLocation(config.IndexLocation(config.JoinLocation(config.IndexLocation("resourceGroups", i), "steps"), j), "template")
+			if err != nil {
+				report.addWarning(location, IssueCodeOutputInference, err, "failed to infer outputs from template: %v", err)
+				continue
+			}
+			*outputs = inferred
+		}
+	}
+	return report
+}
+
+// InferTemplateOutputs reads the outputs declared by an ARM template, either a Bicep file or a compiled JSON template.
+func InferTemplateOutputs(templatePath string) ([]Output, error) {
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	switch filepath.Ext(templatePath) {
+	case ".bicep":
+		return bicepOutputs(content), nil
+	case ".json":
+		return jsonTemplateOutputs(content)
+	default:
+		return nil, fmt.Errorf("unsupported template %s: expected a .bicep or .json file", templatePath)
+	}
+}
+
+var (
+	bicepOutput      = regexp.MustCompile(`^\s*output\s+([A-Za-z_][A-Za-z0-9_]*)\s+([A-Za-z_][A-Za-z0-9_.]*)`)
+	bicepDescription = regexp.MustCompile(`^\s*@(?:sys\.)?description\(\s*'((?:[^'\\]|\\.)*)'\s*\)`)
+	bicepSecure      = regexp.MustCompile(`^\s*@(?:sys\.)?secure\(\s*\)`)
+)
+
+// bicepOutputs finds the output declarations in a Bicep file, along with the decorators on the lines above them.
+// User-defined types are recorded without a type, so that their consumers aren't type-checked.
+func bicepOutputs(content []byte) []Output {
+	outputs := []Output{}
+	var description string
+	var secure bool
+	for _, line := range strings.Split(string(content), "\n") {
+		if match := bicepDescription.FindStringSubmatch(line); match != nil {
+			description = strings.ReplaceAll(match[1], `\'`, `'`)
+			continue
+		}
+		if bicepSecure.MatchString(line) {
+			secure = true
+			continue
+		}
+		if match := bicepOutput.FindStringSubmatch(line); match != nil {
+			output := Output{Name: match[1], Description: description}
+			switch outputType := OutputType(match[2]); outputType {
+			case OutputTypeString, OutputTypeInt, OutputTypeBool, OutputTypeArray, OutputTypeObject:
+				output.Type = outputType
+			case "resource":
+				output.Type = OutputTypeObject
+			}
+			if secure {
+				switch output.Type {
+				case OutputTypeString:
+					output.Type = OutputTypeSecureString
+				case OutputTypeObject:
+					output.Type = OutputTypeSecureObject
+				}
+			}
+			outputs = append(outputs, output)
+		}
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "@") && !strings.HasPrefix(trimmed, "//") {
+			description, secure = "", false
+		}
+	}
+	return outputs
+}
+
+// jsonTemplateOutputs reads the outputs section of a compiled ARM template.
+func jsonTemplateOutputs(content []byte) ([]Output, error) {
+	var template struct {
+		Outputs map[string]struct {
+			Type     string `json:"type"`
+			Metadata struct {
+				Description string `json:"description"`
+			} `json:"metadata"`
+		} `json:"outputs"`
+	}
+	if err := json.Unmarshal(content, &template); err != nil {
+		return nil, fmt.Errorf("failed to parse ARM template: %w", err)
+	}
+	outputs := []Output{}
+	for name, output := range template.Outputs {
+		outputs = append(outputs, Output{
+			Name:        name,
+			Type:        OutputType(strings.ToLower(output.Type)),
+			Description: output.Metadata.Description,
+		})
+	}
+	slices.SortFunc(outputs, func(a, b Output) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return outputs, nil
+}
+
+var inputType = reflect.TypeFor[Input]()
+
+// validateInputs checks every Input consumed in the pipeline against the outputs declared by the step it references.
+// Inputs from steps that don't exist, or that haven't declared their outputs, are not checked here.
+func (p *Pipeline) validateInputs(report *ValidationReport) {
+	producers := map[StepDependency]Step{}
+	for _, rg := range p.ResourceGroups {
+		if rg.ResourceGroupMeta == nil {
+			continue
+		}
+		for _, step := range rg.Steps {
+			producers[StepDependency{ResourceGroup: rg.Name, Step: step.StepName()}] = step
+		}
+	}
+
+	check := func(location string, input Input, kind ValueKind) {
+		declarer, ok := producers[input.StepDependency].(OutputDeclarer)
+		if !ok {
+			return
+		}
+		outputs := declarer.DeclaredOutputs()
+		if outputs == nil {
+			return
+		}
+		location = config.JoinLocation(location, "name")
+		index := slices.IndexFunc(outputs, func(output Output) bool {
+			// ARM treats output names case-insensitively
+			return strings.EqualFold(output.Name, input.Name)
+		})
+		if index == -1 {
+			var names []string
+			for _, output := range outputs {
+				names = append(names, output.Name)
+			}
+			report.addError(location, IssueCodeUnknownOutput,
+				fmt.Errorf("input %s/%s.%s invalid: step declares no such output", input.ResourceGroup, input.Step, input.Name),
+				"step %s/%s declares no output %q, declared outputs are [%s]", input.ResourceGroup, input.Step, input.Name, strings.Join(names, ", "),
+			)
+			return
+		}
+		if actual := outputs[index].Type.Kind(); kind != ValueKindAny && actual != ValueKindAny && actual != kind {
+			report.addError(location, IssueCodeOutputKindMismatch,
+				fmt.Errorf("input %s/%s.%s invalid: output is a %s, expected %s", input.ResourceGroup, input.Step, input.Name, actual, kind),
+				"output %q of step %s/%s holds %s, expected %s", input.Name, input.ResourceGroup, input.Step, actual, kind,
+			)
+		}
+	}
+
+	walkPipeline(p, func(location string, v reflect.Value, kind ValueKind) bool {
+		if v.Type() == inputType {
+			check(location, v.Interface().(Input), ValueKindAny)
+			return true
+		}
+		value, isValue := valueOf(v)
+		if !isValue {
+			return false
+		}
+		if value.Input != nil {
+			check(config.JoinLocation(location, "input"), *value.Input, kind)
+		}
+		return true
+	})
+}
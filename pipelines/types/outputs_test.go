@@ -0,0 +1,157 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const outputsBicep = `param name string
+
+@description('The ID of the cluster.')
+output clusterId string = cluster.id
+
+@secure()
+output adminPassword string = password
+
+// the number of nodes
+@description('Node count.')
+output nodeCount int = 3
+output tags object = {
+  owner: 'me'
+}
+output cluster resource 'Microsoft.ContainerService/managedClusters@2024-01-01' = cluster
+output custom myType = value
+`
+
+const outputsTemplate = `{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+  "outputs": {
+    "zoneName": {"type": "String", "metadata": {"description": "The DNS zone."}},
+    "enabled": {"type": "Bool"}
+  }
+}`
+
+func TestInferTemplateOutputs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.bicep"), []byte(outputsBicep), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.json"), []byte(outputsTemplate), 0644))
+
+	outputs, err := InferTemplateOutputs(filepath.Join(dir, "main.bicep"))
+	require.NoError(t, err)
+	assert.Equal(t, []Output{
+		{Name: "clusterId", Type: OutputTypeString, Description: "The ID of the cluster."},
+		{Name: "adminPassword", Type: OutputTypeSecureString},
+		{Name: "nodeCount", Type: OutputTypeInt, Description: "Node count."},
+		{Name: "tags", Type: OutputTypeObject},
+		{Name: "cluster", Type: OutputTypeObject},
+		{Name: "custom"},
+	}, outputs)
+
+	outputs, err = InferTemplateOutputs(filepath.Join(dir, "main.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []Output{
+		{Name: "enabled", Type: OutputTypeBool},
+		{Name: "zoneName", Type: OutputTypeString, Description: "The DNS zone."},
+	}, outputs)
+
+	_, err = InferTemplateOutputs(filepath.Join(dir, "main.bicepparam"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+const outputsPipeline = `serviceGroup: test
+rolloutName: test
+resourceGroups:
+- name: rg
+  resourceGroup: rg
+  subscription: sub
+  steps:
+  - name: deploy
+    action: ARM
+    template: main.bicep
+    parameters: main.bicepparam
+    deploymentLevel: ResourceGroup
+  - name: script
+    action: Shell
+    command: make
+    shellIdentity:
+      value: test-msi
+    outputs:
+    - name: endpoint
+      type: string
+      description: The endpoint that was created.
+  - name: consume
+    action: Shell
+    command: make
+    shellIdentity:
+      input:
+        resourceGroup: rg
+        step: deploy
+        name: nodeCount
+    variables:
+    - name: CLUSTER_ID
+      input:
+        resourceGroup: rg
+        step: deploy
+        name: clusterName
+    - name: ENDPOINT
+      input:
+        resourceGroup: rg
+        step: script
+        name: Endpoint
+`
+
+func TestValidateInputs(t *testing.T) {
+	dir := t.TempDir()
+	pipelinePath := filepath.Join(dir, "pipeline.yaml")
+	require.NoError(t, os.WriteFile(pipelinePath, []byte(outputsPipeline), 0644))
+
+	// without the template, the outputs of the ARM step are unknown
+	pipeline, err := NewPipelineFromFile(pipelinePath, map[string]any{})
+	require.NoError(t, err)
+	assert.Nil(t, pipeline.ResourceGroups[0].Steps[0].(*ARMStep).Outputs)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.bicep"), []byte(outputsBicep), 0644))
+
+	// inference is opt-in, so a template next to the pipeline doesn't change how it loads
+	pipeline, err = NewPipelineFromFile(pipelinePath, map[string]any{})
+	require.NoError(t, err)
+	assert.Nil(t, pipeline.ResourceGroups[0].Steps[0].(*ARMStep).Outputs)
+
+	report, err := ValidatePipelineFile(pipelinePath, map[string]any{}, WithInferredOutputs())
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 2)
+
+	assert.Equal(t, IssueCodeUnknownOutput, report.Issues[0].Code)
+	assert.Equal(t, "resourceGroups[0].steps[2].variables[0].input.name", report.Issues[0].Location)
+	assert.Equal(t, `step rg/deploy declares no output "clusterName", declared outputs are [clusterId, adminPassword, nodeCount, tags, cluster, custom]`, report.Issues[0].Message)
+	require.NotNil(t, report.Issues[0].Position)
+	assert.Equal(t, 35, report.Issues[0].Position.Line)
+
+	assert.Equal(t, IssueCodeOutputKindMismatch, report.Issues[1].Code)
+	assert.Equal(t, "resourceGroups[0].steps[2].shellIdentity.input.name", report.Issues[1].Location)
+	assert.Equal(t, `output "nodeCount" of step rg/deploy holds number, expected string`, report.Issues[1].Message)
+	require.NotNil(t, report.Issues[1].Position)
+	assert.Equal(t, 29, report.Issues[1].Position.Line)
+
+	_, err = NewPipelineFromFile(pipelinePath, map[string]any{}, WithInferredOutputs())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `declares no output "clusterName"`)
+}
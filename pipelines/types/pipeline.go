@@ -17,6 +17,7 @@ package types
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 
@@ -44,33 +45,48 @@ type BuildStep struct {
 	Args []string `json:"args"`
 }
 
-// NewPipelineFromFile prepocesses and creates a new Pipeline instance from a file.
+// NewPipelineFromFile prepocesses and creates a new Pipeline instance from a file. With WithInferredOutputs, ARM
+// steps that don't declare their outputs have them inferred from their template, relative to the file.
 //
 // Parameters:
 //   - pipelineFilePath: The path to the pipeline file.
 //   - cfg: The configuration object used for preprocessing the file.
+//   - opts: Options for validating the pipeline, like WithStrictActions or WithInferredOutputs.
 //
 // Returns:
 //   - A pointer to a new Pipeline instance if successful.
 //   - An error if there was a problem preprocessing the file, validating the schema,
 //     unmarshaling the pipeline, or validating the pipeline instance.
-func NewPipelineFromFile(pipelineFilePath string, cfg types2.Configuration) (*Pipeline, error) {
+func NewPipelineFromFile(pipelineFilePath string, cfg types2.Configuration, opts ...PipelineOption) (*Pipeline, error) {
 	content, err := os.ReadFile(pipelineFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %s: %w", pipelineFilePath, err)
 	}
 
-	return NewPipelineFromBytes(content, cfg)
+	return newPipeline(content, cfg, pipelineFilePath, opts...)
 }
 
-func NewPipelineFromBytes(pipelineBytes []byte, cfg types2.Configuration) (*Pipeline, error) {
+func NewPipelineFromBytes(pipelineBytes []byte, cfg types2.Configuration, opts ...PipelineOption) (*Pipeline, error) {
+	return newPipeline(pipelineBytes, cfg, "", opts...)
+}
+
+// newPipeline loads a pipeline, reporting validation errors at their position in file, which may be empty when the
+// content did not come from disk.
+func newPipeline(pipelineBytes []byte, cfg types2.Configuration, file string, opts ...PipelineOption) (*Pipeline, error) {
 	bytes, err := config.PreprocessContent(pipelineBytes, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to preprocess pipeline file: %w", err)
 	}
 
 	if err := ValidatePipelineSchema(bytes); err != nil {
-		return nil, fmt.Errorf("failed to validate pipeline schema: %w", err)
+		report := ValidatePipelineSchemaReport(bytes)
+		attachSourcePositions(report, file, pipelineBytes, bytes)
+		return nil, fmt.Errorf("failed to validate pipeline schema: %w", report.Err())
+	}
+
+	bytes, err = migrateToLatest(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate pipeline file: %w", err)
 	}
 
 	var pipeline Pipeline
@@ -78,8 +94,16 @@ func NewPipelineFromBytes(pipelineBytes []byte, cfg types2.Configuration) (*Pipe
 		return nil, fmt.Errorf("failed to unmarshal pipeline file: %w", err)
 	}
 
-	if err := pipeline.Validate(); err != nil {
-		return nil, fmt.Errorf("pipeline file failed validation: %w", err)
+	options := newPipelineOptions(opts)
+	report := &ValidationReport{}
+	if file != "" && options.inferOutputs {
+		report.Merge(InferOutputs(&pipeline, filepath.Dir(file)))
+	}
+	report.Merge(pipeline.ValidationReport())
+	options.apply(report)
+	if report.HasErrors() {
+		attachSourcePositions(report, file, pipelineBytes, bytes)
+		return nil, fmt.Errorf("pipeline file failed validation: %w", report.Err())
 	}
 
 	return &pipeline, nil
@@ -87,63 +111,131 @@ func NewPipelineFromBytes(pipelineBytes []byte, cfg types2.Configuration) (*Pipe
 
 // Validate checks the integrity of the pipeline and its resource groups.
 // It ensures that there are no duplicate step names, that all dependencies exist,
-// and that each resource group is valid.
+// that steps do not depend on each other in a cycle, that inputs consume outputs declared by
+// the steps they reference, that automated retries stay within the limits Ev2 enforces, and that
+// each resource group is valid.
+// Dependency cycles are reported as a *CycleError. Use ValidationReport to find every
+// problem at once rather than only the first.
 //
 // Returns:
 //   - An error if the pipeline or any of its resource groups are invalid.
 //   - nil if the pipeline and all its resource groups are valid.
 func (p *Pipeline) Validate() error {
+	for _, issue := range p.ValidationReport().Issues {
+		if issue.Severity == SeverityError {
+			return issue.cause
+		}
+	}
+	return nil
+}
+
+// ValidationReport runs the same checks as Validate, collecting every issue found instead of
+// returning on the first.
+func (p *Pipeline) ValidationReport() *ValidationReport {
+	report := &ValidationReport{}
 	groups := sets.New[string]()
 	references := map[string]sets.Set[string]{}
 	for i, rg := range p.ResourceGroups {
+		if rg.ResourceGroupMeta == nil {
+			continue
+		}
+		location := fmt.Sprintf("resourceGroups[%d]", i)
 		if groups.Has(rg.Name) {
-			return fmt.Errorf("pipeline.resourceGroups[%d:%s]: resource group name %q duplicated", i, rg.Name, rg.Name)
+			report.addError(location, IssueCodeDuplicateResourceGroup,
+				fmt.Errorf("pipeline.resourceGroups[%d:%s]: resource group name %q duplicated", i, rg.Name, rg.Name),
+				"resource group name %q duplicated", rg.Name,
+			)
 		}
 		groups.Insert(rg.Name)
 
 		steps := sets.New[string]()
 		for j, step := range rg.Steps {
+			if !isRegisteredStepType(step.ActionType()) {
+				report.addWarning(fmt.Sprintf("%s.steps[%d].action", location, j), IssueCodeUnknownStepAction,
+					fmt.Errorf("pipeline.resourceGroups[%d:%s].steps[%d:%s]: unknown action %q", i, rg.Name, j, step.StepName(), step.ActionType()),
+					"unknown action %q", step.ActionType(),
+				)
+			}
+			if step.Condition() != "" {
+				if _, err := CompileCondition(step.Condition()); err != nil {
+					report.addError(fmt.Sprintf("%s.steps[%d].when", location, j), IssueCodeInvalidCondition,
+						fmt.Errorf("pipeline.resourceGroups[%d:%s].steps[%d:%s]: invalid condition: %w", i, rg.Name, j, step.StepName(), err),
+						"invalid condition: %v", err,
+					)
+				}
+			}
+			report.Merge(step.AutomatedRetries().validationReport(fmt.Sprintf("%s.steps[%d].automatedRetry", location, j)))
 			if steps.Has(step.StepName()) {
-				return fmt.Errorf("pipeline.resourceGroups[%d:%s].steps[%d:%s]: step name %q duplicated", i, rg.Name, j, step.StepName(), step.StepName())
+				report.addError(fmt.Sprintf("%s.steps[%d]", location, j), IssueCodeDuplicateStep,
+					fmt.Errorf("pipeline.resourceGroups[%d:%s].steps[%d:%s]: step name %q duplicated", i, rg.Name, j, step.StepName(), step.StepName()),
+					"step name %q duplicated", step.StepName(),
+				)
 			}
 			steps.Insert(step.StepName())
 		}
+		if existing, recorded := references[rg.Name]; recorded {
+			steps = steps.Union(existing)
+		}
 		references[rg.Name] = steps
 
-		serviceValidationSteps := sets.New[string]()
 		for j, step := range rg.ValidationSteps {
-			if steps.Has(step.StepName()) {
-				return fmt.Errorf("pipeline.resourceGroups[%d:%s].serviceValidationSteps[%d:%s]: step name %q duplicated with a regular step", i, rg.Name, j, step.StepName(), step.StepName())
+			if !isRegisteredValidationStepType(step.ActionType()) {
+				report.addWarning(fmt.Sprintf("%s.validationSteps[%d].action", location, j), IssueCodeUnknownStepAction,
+					fmt.Errorf("pipeline.resourceGroups[%d:%s].serviceValidationSteps[%d:%s]: unknown action %q", i, rg.Name, j, step.StepName(), step.ActionType()),
+					"unknown action %q", step.ActionType(),
+				)
+			}
+			if step.Condition() != "" {
+				if _, err := CompileCondition(step.Condition()); err != nil {
+					report.addError(fmt.Sprintf("%s.validationSteps[%d].when", location, j), IssueCodeInvalidCondition,
+						fmt.Errorf("pipeline.resourceGroups[%d:%s].serviceValidationSteps[%d:%s]: invalid condition: %w", i, rg.Name, j, step.StepName(), err),
+						"invalid condition: %v", err,
+					)
+				}
 			}
+			report.Merge(step.AutomatedRetries().validationReport(fmt.Sprintf("%s.validationSteps[%d].automatedRetry", location, j)))
 			if steps.Has(step.StepName()) {
-				return fmt.Errorf("pipeline.resourceGroups[%d:%s].serviceValidationSteps[%d:%s]: step name %q duplicated", i, rg.Name, j, step.StepName(), step.StepName())
+				report.addError(fmt.Sprintf("%s.validationSteps[%d]", location, j), IssueCodeDuplicateValidationStep,
+					fmt.Errorf("pipeline.resourceGroups[%d:%s].serviceValidationSteps[%d:%s]: step name %q duplicated with a regular step", i, rg.Name, j, step.StepName(), step.StepName()),
+					"step name %q duplicated with a regular step", step.StepName(),
+				)
 			}
-			serviceValidationSteps.Insert(step.StepName())
 		}
 	}
 
 	for i, rg := range p.ResourceGroups {
+		if rg.ResourceGroupMeta == nil {
+			continue
+		}
 		for j, step := range rg.Steps {
+			location := fmt.Sprintf("resourceGroups[%d].steps[%d]", i, j)
 			for _, dep := range append(step.Dependencies(), step.RequiredInputs()...) {
 				group, exists := references[dep.ResourceGroup]
 				if !exists {
-					return fmt.Errorf("pipeline.resourceGroups[%d:%s].steps[%d:%s]: dependency %s/%s invalid: no such resource group %s", i, rg.Name, j, step.StepName(), dep.ResourceGroup, dep.Step, dep.ResourceGroup)
+					report.addError(location, IssueCodeUnknownResourceGroup,
+						fmt.Errorf("pipeline.resourceGroups[%d:%s].steps[%d:%s]: dependency %s/%s invalid: no such resource group %s", i, rg.Name, j, step.StepName(), dep.ResourceGroup, dep.Step, dep.ResourceGroup),
+						"dependency %s/%s invalid: no such resource group %s", dep.ResourceGroup, dep.Step, dep.ResourceGroup,
+					)
+					continue
 				}
 				if !group.Has(dep.Step) {
-					return fmt.Errorf("pipeline.resourceGroups[%d:%s].steps[%d:%s]: dependency %s/%s invalid: resource group %s has no step %s", i, rg.Name, j, step.StepName(), dep.ResourceGroup, dep.Step, dep.ResourceGroup, dep.Step)
+					report.addError(location, IssueCodeUnknownStep,
+						fmt.Errorf("pipeline.resourceGroups[%d:%s].steps[%d:%s]: dependency %s/%s invalid: resource group %s has no step %s", i, rg.Name, j, step.StepName(), dep.ResourceGroup, dep.Step, dep.ResourceGroup, dep.Step),
+						"dependency %s/%s invalid: resource group %s has no step %s", dep.ResourceGroup, dep.Step, dep.ResourceGroup, dep.Step,
+					)
 				}
 			}
 		}
 	}
 
-	// todo check for circular dependencies
+	if err := p.detectCycles(); err != nil {
+		report.addError("resourceGroups", IssueCodeDependencyCycle, err, "%v", err)
+	}
 
-	// validate resource groups
-	for _, rg := range p.ResourceGroups {
-		err := rg.Validate()
-		if err != nil {
-			return err
-		}
+	p.validateInputs(report)
+
+	for i, rg := range p.ResourceGroups {
+		report.Merge(rg.validationReport(fmt.Sprintf("resourceGroups[%d]", i)))
 	}
-	return nil
+	return report
 }
@@ -0,0 +1,267 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/Azure/ARO-Tools/config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+)
+
+// Severity classifies how serious a validation issue is.
+type Severity string
+
+const (
+	// SeverityError marks issues that make a pipeline unusable.
+	SeverityError Severity = "error"
+	// SeverityWarning marks issues that should be fixed but do not prevent the pipeline from being used.
+	SeverityWarning Severity = "warning"
+)
+
+// IssueCode is a stable identifier for a class of validation issue. Codes never change once published, so
+// tooling may match on them.
+type IssueCode string
+
+const (
+	IssueCodeMalformedPipeline          IssueCode = "MalformedPipeline"
+	IssueCodeUnsupportedSchema          IssueCode = "UnsupportedSchema"
+	IssueCodeSchemaViolation            IssueCode = "SchemaViolation"
+	IssueCodeDuplicateResourceGroup     IssueCode = "DuplicateResourceGroup"
+	IssueCodeDuplicateStep              IssueCode = "DuplicateStep"
+	IssueCodeDuplicateValidationStep    IssueCode = "DuplicateValidationStep"
+	IssueCodeUnknownResourceGroup       IssueCode = "UnknownResourceGroup"
+	IssueCodeUnknownStep                IssueCode = "UnknownStep"
+	IssueCodeDependencyCycle            IssueCode = "DependencyCycle"
+	IssueCodeMissingResourceGroupMeta   IssueCode = "MissingResourceGroupMeta"
+	IssueCodeMissingResourceGroupName   IssueCode = "MissingResourceGroupName"
+	IssueCodeMissingSubscription        IssueCode = "MissingSubscription"
+	IssueCodeUnknownStepAction          IssueCode = "UnknownStepAction"
+	IssueCodeUnresolvedConfigRef        IssueCode = "UnresolvedConfigRef"
+	IssueCodeConfigRefKindMismatch      IssueCode = "ConfigRefKindMismatch"
+	IssueCodeUnknownOutput              IssueCode = "UnknownOutput"
+	IssueCodeOutputKindMismatch         IssueCode = "OutputKindMismatch"
+	IssueCodeOutputInference            IssueCode = "OutputInference"
+	IssueCodeInvalidCondition           IssueCode = "InvalidCondition"
+	IssueCodeInvalidExecutionConstraint IssueCode = "InvalidExecutionConstraint"
+	IssueCodeInvalidAutomatedRetry      IssueCode = "InvalidAutomatedRetry"
+)
+
+// ValidationIssue is one problem found while validating a pipeline.
+type ValidationIssue struct {
+	// Location is a JSONPath-like reference to the offending element, like resourceGroups[2].steps[4]. It is
+	// empty for issues that concern the document as a whole.
+	Location string    `json:"location"`
+	Severity Severity  `json:"severity"`
+	Code     IssueCode `json:"code"`
+	Message  string    `json:"message"`
+	// Position is where the offending element starts in the pipeline file, before templating. It is only known
+	// for issues found by ValidatePipelineBytes or ValidatePipelineFile.
+	Position *config.Position `json:"position,omitempty"`
+
+	// cause holds the error that Validate historically returned for this issue, and any typed error (like
+	// *CycleError) that callers may want to retrieve with errors.As.
+	cause error
+}
+
+func (i ValidationIssue) Error() string {
+	message := i.Message
+	if i.Location != "" {
+		message = fmt.Sprintf("%s: %s", i.Location, message)
+	}
+	if i.Position != nil {
+		message = fmt.Sprintf("%s: %s", i.Position, message)
+	}
+	return message
+}
+
+func (i ValidationIssue) Unwrap() error {
+	return i.cause
+}
+
+// ValidationReport collects every issue found in one validation pass, rather than stopping at the first.
+// The report marshals to JSON for consumption by CI annotation tooling.
+type ValidationReport struct {
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// Merge appends the issues from another report to this one.
+func (r *ValidationReport) Merge(other *ValidationReport) {
+	if other == nil {
+		return
+	}
+	r.Issues = append(r.Issues, other.Issues...)
+}
+
+// HasErrors determines if any issue in the report has error severity.
+func (r *ValidationReport) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns nil if the report holds no errors, or an error listing every error-severity issue otherwise.
+// Typed errors underlying the issues remain reachable through errors.As.
+func (r *ValidationReport) Err() error {
+	var errs []error
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AttachPositions records the source position of every issue that does not have one yet, using the closest
+// position known for its location.
+func (r *ValidationReport) AttachPositions(positions config.Positions) {
+	for i := range r.Issues {
+		if r.Issues[i].Position != nil {
+			continue
+		}
+		if position, ok := positions.Lookup(r.Issues[i].Location); ok {
+			r.Issues[i].Position = &position
+		}
+	}
+}
+
+func (r *ValidationReport) addError(location string, code IssueCode, cause error, message string, args ...any) {
+	r.addIssue(location, SeverityError, code, cause, message, args...)
+}
+
+func (r *ValidationReport) addWarning(location string, code IssueCode, cause error, message string, args ...any) {
+	r.addIssue(location, SeverityWarning, code, cause, message, args...)
+}
+
+func (r *ValidationReport) addIssue(location string, severity Severity, code IssueCode, cause error, message string, args ...any) {
+	r.Issues = append(r.Issues, ValidationIssue{
+		Location: location,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(message, args...),
+		cause:    cause,
+	})
+}
+
+// ValidatePipelineFile runs every validation available for the pipeline file at the given path, like
+// ValidatePipelineBytes, recording the path in the position of each issue.
+func ValidatePipelineFile(pipelineFilePath string, cfg types2.Configuration, opts ...PipelineOption) (*ValidationReport, error) {
+	content, err := os.ReadFile(pipelineFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", pipelineFilePath, err)
+	}
+	return validatePipeline(content, cfg, pipelineFilePath, opts...)
+}
+
+// ValidatePipelineBytes runs every validation available for a pipeline document in one pass - schema validation
+// followed by semantic validation of the unmarshalled pipeline - and reports all issues found. Issues are positioned
+// at the line and column of the pre-template document that they concern. An error is only returned when the document
+// could not be templated, in which case no validation was possible.
+func ValidatePipelineBytes(pipelineBytes []byte, cfg types2.Configuration, opts ...PipelineOption) (*ValidationReport, error) {
+	return validatePipeline(pipelineBytes, cfg, "", opts...)
+}
+
+func validatePipeline(pipelineBytes []byte, cfg types2.Configuration, file string, opts ...PipelineOption) (*ValidationReport, error) {
+	bytes, err := config.PreprocessContent(pipelineBytes, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preprocess pipeline file: %w", err)
+	}
+
+	options := newPipelineOptions(opts)
+	report := ValidatePipelineSchemaReport(bytes)
+
+	migrated, err := migrateToLatest(bytes)
+	if err != nil {
+		if !report.HasErrors() {
+			report.addError("", IssueCodeMalformedPipeline, err, "failed to migrate pipeline: %v", err)
+		}
+		attachSourcePositions(report, file, pipelineBytes, bytes)
+		return report, nil
+	}
+
+	var pipeline Pipeline
+	if err := yaml.Unmarshal(migrated, &pipeline); err != nil {
+		if !report.HasErrors() {
+			report.addError("", IssueCodeMalformedPipeline, err, "failed to unmarshal pipeline: %v", err)
+		}
+	} else {
+		if file != "" && options.inferOutputs {
+			report.Merge(InferOutputs(&pipeline, filepath.Dir(file)))
+		}
+		report.Merge(pipeline.ValidationReport())
+	}
+
+	options.apply(report)
+	attachSourcePositions(report, file, pipelineBytes, bytes)
+	return report, nil
+}
+
+// attachSourcePositions maps the issues in the report back onto the pre-template source. Positions are a diagnostic
+// aid, so a document that can't be parsed for them is left as-is.
+func attachSourcePositions(report *ValidationReport, file string, source, rendered []byte) {
+	if len(report.Issues) == 0 {
+		return
+	}
+	positions, err := config.NewPositions(file, source, rendered)
+	if err != nil {
+		return
+	}
+	report.AttachPositions(positions)
+}
+
+// ValidatePipelineSchemaReport validates pipeline content against the schema it references, recording one issue
+// per schema violation.
+func ValidatePipelineSchemaReport(pipelineContent []byte) *ValidationReport {
+	report := &ValidationReport{}
+
+	pipelineMap := make(map[string]interface{})
+	if err := yaml.Unmarshal(pipelineContent, &pipelineMap); err != nil {
+		report.addError("", IssueCodeMalformedPipeline, err, "failed to unmarshal pipeline YAML content: %v", err)
+		return report
+	}
+
+	pipelineSchema, schemaRef, err := getSchemaForPipeline(pipelineMap)
+	if err != nil {
+		report.addError("$schema", IssueCodeUnsupportedSchema, err, "failed to load pipeline schema: %v", err)
+		return report
+	}
+
+	err = pipelineSchema.Validate(pipelineMap)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		seen := map[string]bool{}
+		for _, leaf := range config.SchemaViolations(validationErr) {
+			location := config.LocationFromTokens(leaf.InstanceLocation)
+			message := leaf.BasicOutput().Error.String()
+			if key := location + "\x00" + message; !seen[key] {
+				seen[key] = true
+				report.addError(location, IssueCodeSchemaViolation, leaf, "not compliant with schema %s: %s", schemaRef, message)
+			}
+		}
+	} else if err != nil {
+		report.addError("", IssueCodeSchemaViolation, err, "pipeline is not compliant with schema %s: %v", schemaRef, err)
+	}
+	return report
+}
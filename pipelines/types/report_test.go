@@ -0,0 +1,198 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineValidationReport(t *testing.T) {
+	pipeline := &Pipeline{
+		ResourceGroups: []*ResourceGroup{
+			{
+				ResourceGroupMeta: &ResourceGroupMeta{Name: "rg1", ResourceGroup: "rg1"},
+				Steps: []Step{
+					&ShellStep{StepMeta: StepMeta{Name: "step1", Action: "Shell"}},
+					&ShellStep{StepMeta: StepMeta{Name: "step1", Action: "Shell"}},
+					&ShellStep{StepMeta: StepMeta{
+						Name:      "step2",
+						Action:    "Shell",
+						DependsOn: []StepDependency{{ResourceGroup: "rg1", Step: "missing"}, {ResourceGroup: "rg3", Step: "step1"}},
+					}},
+				},
+			},
+			{
+				ResourceGroupMeta: &ResourceGroupMeta{Name: "rg1", ResourceGroup: "rg1", Subscription: "sub"},
+				ValidationSteps: []ValidationStep{
+					&ShellValidationStep{ShellStep: ShellStep{StepMeta: StepMeta{Name: "step2", Action: "Shell"}}},
+				},
+			},
+			{
+				ResourceGroupMeta: &ResourceGroupMeta{Name: "rg2", ResourceGroup: "rg2", Subscription: "sub"},
+				Steps: []Step{
+					&ShellStep{StepMeta: StepMeta{
+						Name:      "loop",
+						Action:    "Shell",
+						DependsOn: []StepDependency{{ResourceGroup: "rg2", Step: "loop"}},
+					}},
+				},
+			},
+			{},
+		},
+	}
+
+	report := pipeline.ValidationReport()
+	var codes, locations []string
+	for _, issue := range report.Issues {
+		assert.Equal(t, SeverityError, issue.Severity)
+		codes = append(codes, string(issue.Code))
+		locations = append(locations, issue.Location)
+	}
+	assert.Equal(t, []string{
+		"DuplicateStep",
+		"DuplicateResourceGroup",
+		"DuplicateValidationStep",
+		"UnknownStep",
+		"UnknownResourceGroup",
+		"DependencyCycle",
+		"MissingSubscription",
+		"MissingResourceGroupMeta",
+	}, codes)
+	assert.Equal(t, []string{
+		"resourceGroups[0].steps[1]",
+		"resourceGroups[1]",
+		"resourceGroups[1].validationSteps[0]",
+		"resourceGroups[0].steps[2]",
+		"resourceGroups[0].steps[2]",
+		"resourceGroups",
+		"resourceGroups[0].subscription",
+		"resourceGroups[3]",
+	}, locations)
+
+	assert.True(t, report.HasErrors())
+	err := report.Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "resourceGroups[0].steps[2]: dependency rg1/missing invalid: resource group rg1 has no step missing")
+	assert.Contains(t, err.Error(), "resourceGroups[0].subscription: subscription is required")
+	var cycleErr *CycleError
+	require.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, [][]StepDependency{{{ResourceGroup: "rg2", Step: "loop"}, {ResourceGroup: "rg2", Step: "loop"}}}, cycleErr.Cycles)
+
+	// Validate keeps returning only the first problem, with its historical message.
+	assert.EqualError(t, pipeline.Validate(), `pipeline.resourceGroups[0:rg1].steps[1:step1]: step name "step1" duplicated`)
+}
+
+func TestValidationReportJSON(t *testing.T) {
+	report := &ValidationReport{}
+	report.addError("resourceGroups[2].steps[4]", IssueCodeUnknownStep, errors.New("cause"), "resource group %s has no step %s", "rg", "step")
+
+	raw, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"issues":[{"location":"resourceGroups[2].steps[4]","severity":"error","code":"UnknownStep","message":"resource group rg has no step step"}]}`, string(raw))
+	assert.NoError(t, (&ValidationReport{}).Err())
+}
+
+func TestValidatePipelineSchemaReport(t *testing.T) {
+	content := []byte(`serviceGroup: test
+rolloutName: test
+resourceGroups:
+- name: rg
+  resourceGroup: rg
+  subscription: sub
+  steps:
+  - name: first
+    action: Shell
+    command: echo hello
+    timeout: tomorrow
+    shellIdentity:
+      value: test-msi
+  - name: second
+    action: Shell
+    command: echo hello
+    timeout: yesterday
+    shellIdentity:
+      value: test-msi
+`)
+	report := ValidatePipelineSchemaReport(content)
+	require.True(t, report.HasErrors())
+
+	locations := map[string]bool{}
+	for _, issue := range report.Issues {
+		assert.Equal(t, IssueCodeSchemaViolation, issue.Code)
+		locations[issue.Location] = true
+	}
+	assert.True(t, locations["resourceGroups[0].steps[0].timeout"], "expected an issue for the first step, got %v", report.Issues)
+	assert.True(t, locations["resourceGroups[0].steps[1].timeout"], "expected an issue for the second step, got %v", report.Issues)
+
+	unsupported := ValidatePipelineSchemaReport([]byte(`$schema: pipeline.schema.v0`))
+	require.Len(t, unsupported.Issues, 1)
+	assert.Equal(t, IssueCodeUnsupportedSchema, unsupported.Issues[0].Code)
+	assert.Equal(t, "$schema", unsupported.Issues[0].Location)
+}
+
+func TestValidatePipelineBytesPositions(t *testing.T) {
+	content := []byte(`serviceGroup: test
+rolloutName: test
+resourceGroups:
+- name: rg
+  resourceGroup: rg
+  subscription: sub
+  steps:
+{{- if .enabled }}
+  - name: first
+    action: Shell
+    command: echo hello
+    shellIdentity:
+      value: test-msi
+{{- end }}
+  - name: second
+    action: Shell
+    command: echo {{ .greeting }}
+    timeout: tomorrow
+    shellIdentity:
+      value: test-msi
+    dependsOn:
+    - resourceGroup: rg
+      step: missing
+`)
+	report, err := ValidatePipelineBytes(content, map[string]any{"enabled": true, "greeting": "hello"})
+	require.NoError(t, err)
+	require.True(t, report.HasErrors())
+
+	positions := map[string]string{}
+	for _, issue := range report.Issues {
+		require.NotNil(t, issue.Position, "issue without position: %v", issue)
+		positions[issue.Location] = issue.Position.String()
+	}
+	assert.Equal(t, "18:5", positions["resourceGroups[0].steps[1].timeout"])
+
+	report, err = ValidatePipelineBytes(content, map[string]any{"enabled": false, "greeting": "hello"})
+	require.NoError(t, err)
+	positions = map[string]string{}
+	for _, issue := range report.Issues {
+		require.NotNil(t, issue.Position, "issue without position: %v", issue)
+		positions[issue.Location] = issue.Position.String()
+	}
+	assert.Equal(t, "18:5", positions["resourceGroups[0].steps[0].timeout"])
+
+	_, err = NewPipelineFromBytes(content, map[string]any{"enabled": true, "greeting": "hello"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "18:5: resourceGroups[0].steps[1].timeout: not compliant with schema pipeline.schema.v1")
+}
@@ -0,0 +1,209 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+)
+
+// ValueKind is the kind of data a Value is expected to hold.
+type ValueKind string
+
+const (
+	// ValueKindAny accepts any data.
+	ValueKindAny    ValueKind = "any"
+	ValueKindString ValueKind = "string"
+	ValueKindBool   ValueKind = "bool"
+	ValueKindNumber ValueKind = "number"
+	ValueKindList   ValueKind = "list"
+	ValueKindMap    ValueKind = "map"
+)
+
+// valueKindTag is the struct tag that declares the kind of a Value field. Value fields without the tag hold strings,
+// while Variables and Values in maps may hold anything, as they're passed through to templates and commands as-is.
+// Fields that accept values of more than one kind, like numbers that are also commonly quoted, are tagged "any".
+const valueKindTag = "kind"
+
+var (
+	valueType    = reflect.TypeFor[Value]()
+	variableType = reflect.TypeFor[Variable]()
+)
+
+// ResolveValues checks every Value and Variable in the pipeline that references the configuration: the referenced
+// key must exist in cfg, and must hold the kind of data the field expects. Every missing or ill-typed reference is
+// reported, located at the field that holds it.
+func ResolveValues(pipeline *Pipeline, cfg types2.Configuration) *ValidationReport {
+	report := &ValidationReport{}
+	visit := func(location string, v reflect.Value, kind ValueKind) bool {
+		value, isValue := valueOf(v)
+		if !isValue {
+			return false
+		}
+		if value.ConfigRef == "" {
+			return true
+		}
+		resolved, err := cfg.GetByPath(value.ConfigRef)
+		if err != nil {
+			var missingKeyErr *types2.MissingKeyError
+			if errors.As(err, &missingKeyErr) {
+				report.addError(location, IssueCodeUnresolvedConfigRef, err, "configRef %q not found in configuration", value.ConfigRef)
+			} else {
+				report.addError(location, IssueCodeUnresolvedConfigRef, err, "configRef %q could not be resolved: %v", value.ConfigRef, err)
+			}
+			return true
+		}
+		if actual := valueKindOf(resolved); kind != ValueKindAny && actual != kind {
+			report.addError(location, IssueCodeConfigRefKindMismatch,
+				fmt.Errorf("configRef %q resolves to %s, expected %s", value.ConfigRef, actual, kind),
+				"configRef %q resolves to %s, expected %s", value.ConfigRef, actual, kind,
+			)
+		}
+		return true
+	}
+
+	walkPipeline(pipeline, visit)
+	return report
+}
+
+// fieldVisitor is called for every field found while walking a pipeline, with the kind of data expected of the Values
+// held by the field. Returning true stops the walk from descending into the field.
+type fieldVisitor func(location string, v reflect.Value, kind ValueKind) bool
+
+// walkPipeline walks the fields of every step and every subscription provisioning in the pipeline.
+func walkPipeline(pipeline *Pipeline, visit fieldVisitor) {
+	for i, rg := range pipeline.ResourceGroups {
+		location := config.IndexLocation("resourceGroups", i)
+		if rg.SubscriptionProvisioning != nil {
+			walkFields(config.JoinLocation(location, "subscriptionProvisioning"), reflect.ValueOf(rg.SubscriptionProvisioning), ValueKindString, visit)
+		}
+		for j, step := range rg.Steps {
+			walkFields(config.IndexLocation(config.JoinLocation(location, "steps"), j), reflect.ValueOf(step), ValueKindString, visit)
+		}
+		for j, step := range rg.ValidationSteps {
+			walkFields(config.IndexLocation(config.JoinLocation(location, "validationSteps"), j), reflect.ValueOf(step), ValueKindString, visit)
+		}
+	}
+}
+
+// valueOf determines whether a field holds a Value, either directly or as a Variable.
+func valueOf(v reflect.Value) (Value, bool) {
+	switch v.Type() {
+	case valueType:
+		return v.Interface().(Value), true
+	case variableType:
+		return v.Interface().(Variable).Value, true
+	}
+	return Value{}, false
+}
+
+// walkFields calls visit for v and, unless the visitor handled it, for everything held by v in nested structs, slices
+// and maps, with its location. kind is the expected kind of a Value found at v itself.
+func walkFields(location string, v reflect.Value, kind ValueKind, visit fieldVisitor) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walkFields(location, v.Elem(), kind, visit)
+		}
+		return
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			walkFields(config.IndexLocation(location, i), v.Index(i), kind, visit)
+		}
+		return
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(a.String(), b.String())
+		})
+		for _, key := range keys {
+			walkFields(config.JoinLocation(location, key.String()), v.MapIndex(key), kind, visit)
+		}
+		return
+	case reflect.Struct:
+	default:
+		return
+	}
+
+	if visit(location, v, kind) {
+		return
+	}
+
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// embedded structs like StepMeta are inlined, so their fields share the location of the parent
+		fieldLocation := location
+		if !field.Anonymous {
+			if name == "" {
+				name = field.Name
+			}
+			fieldLocation = config.JoinLocation(location, name)
+		}
+		walkFields(fieldLocation, v.Field(i), fieldValueKind(field), visit)
+	}
+}
+
+// fieldValueKind determines the kind of data expected in the Values held by a struct field.
+func fieldValueKind(field reflect.StructField) ValueKind {
+	if kind, declared := field.Tag.Lookup(valueKindTag); declared {
+		return ValueKind(kind)
+	}
+	t := field.Type
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == valueType {
+		return ValueKindString
+	}
+	return ValueKindAny
+}
+
+// valueKindOf determines the kind of data in a resolved configuration value.
+func valueKindOf(value any) ValueKind {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String:
+		return ValueKindString
+	case reflect.Bool:
+		return ValueKindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return ValueKindNumber
+	case reflect.Slice, reflect.Array:
+		return ValueKindList
+	case reflect.Map:
+		return ValueKindMap
+	case reflect.Invalid:
+		return "null"
+	default:
+		return ValueKind(fmt.Sprintf("%T", value))
+	}
+}
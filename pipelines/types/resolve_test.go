@@ -0,0 +1,147 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/config/ev2config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+)
+
+func TestResolveValues(t *testing.T) {
+	region := "uksouth"
+	ev2, err := ev2config.ResolveConfig("public", region)
+	require.NoError(t, err)
+	provider, err := config.NewConfigProvider("../testdata/config.yaml")
+	require.NoError(t, err)
+	resolver, err := provider.GetResolver(&config.ConfigReplacements{
+		RegionReplacement:      region,
+		RegionShortReplacement: "uks",
+		StampReplacement:       "1",
+		CloudReplacement:       "public",
+		EnvironmentReplacement: "int",
+		Ev2Config:              ev2,
+	})
+	require.NoError(t, err)
+	cfg, err := resolver.GetRegionConfiguration(region)
+	require.NoError(t, err)
+
+	pipeline, err := NewPipelineFromFile("../testdata/pipeline.yaml", cfg)
+	require.NoError(t, err)
+
+	// the test pipeline references some keys that the test configuration doesn't provide
+	report := ResolveValues(pipeline, cfg)
+	var locations []string
+	for _, issue := range report.Issues {
+		assert.Equal(t, IssueCodeUnresolvedConfigRef, issue.Code)
+		locations = append(locations, issue.Location)
+	}
+	assert.Equal(t, []string{
+		"resourceGroups[0].steps[4].dstsHost",
+		"resourceGroups[2].steps[10].secretKeyVault",
+		"resourceGroups[2].steps[10].secretName",
+		"resourceGroups[2].steps[10].additionalScopeBindings.kustoEndpoint",
+	}, locations)
+}
+
+func TestResolveValuesIssues(t *testing.T) {
+	cfg := types2.Configuration{
+		"name":    "value",
+		"enabled": true,
+		"count":   int64(3),
+		"items":   []any{"a", "b"},
+	}
+	pipeline := &Pipeline{
+		ResourceGroups: []*ResourceGroup{{
+			ResourceGroupMeta: &ResourceGroupMeta{Name: "rg"},
+			Steps: []Step{
+				&ShellStep{
+					StepMeta:      StepMeta{Name: "shell", Action: StepActionShell},
+					ShellIdentity: Value{ConfigRef: "count"},
+					Variables: []Variable{
+						{Name: "OK", Value: Value{ConfigRef: "items"}},
+						{Name: "MISSING", Value: Value{ConfigRef: "missing.key"}},
+					},
+				},
+				&ResourceProviderRegistrationStep{
+					StepMeta:                   StepMeta{Name: "rp", Action: StepActionResourceProviderRegistration},
+					ResourceProviderNamespaces: Value{ConfigRef: "name"},
+				},
+				&ResourceProviderRegistrationStep{
+					StepMeta:                   StepMeta{Name: "rp-ok", Action: StepActionResourceProviderRegistration},
+					ResourceProviderNamespaces: Value{ConfigRef: "items"},
+				},
+				&CreateCertificateStep{
+					StepMeta: StepMeta{Name: "certificate", Action: StepActionCreateCertificate},
+					Manage:   &Value{ConfigRef: "enabled"},
+				},
+				&GrafanaManageStep{
+					StepMeta:       StepMeta{Name: "grafana", Action: StepActionGrafanaManage},
+					GrafanaName:    Value{ConfigRef: "name"},
+					MajorVersion:   Value{ConfigRef: "count"},
+					ZoneRedundancy: Value{ConfigRef: "enabled"},
+				},
+			},
+			ValidationSteps: []ValidationStep{
+				&ShellValidationStep{
+					ShellStep: ShellStep{
+						StepMeta:      StepMeta{Name: "check", Action: StepActionShell},
+						ShellIdentity: Value{ConfigRef: "enabled"},
+					},
+				},
+			},
+		}},
+	}
+
+	report := ResolveValues(pipeline, cfg)
+	require.True(t, report.HasErrors())
+
+	type issue struct {
+		location string
+		code     IssueCode
+		message  string
+	}
+	var issues []issue
+	for _, i := range report.Issues {
+		issues = append(issues, issue{location: i.Location, code: i.Code, message: i.Message})
+	}
+	assert.Equal(t, []issue{
+		{
+			location: "resourceGroups[0].steps[0].variables[1]",
+			code:     IssueCodeUnresolvedConfigRef,
+			message:  `configRef "missing.key" not found in configuration`,
+		},
+		{
+			location: "resourceGroups[0].steps[0].shellIdentity",
+			code:     IssueCodeConfigRefKindMismatch,
+			message:  `configRef "count" resolves to number, expected string`,
+		},
+		{
+			location: "resourceGroups[0].steps[1].resourceProviderNamespaces",
+			code:     IssueCodeConfigRefKindMismatch,
+			message:  `configRef "name" resolves to string, expected list`,
+		},
+		{
+			location: "resourceGroups[0].validationSteps[0].shellIdentity",
+			code:     IssueCodeConfigRefKindMismatch,
+			message:  `configRef "enabled" resolves to bool, expected string`,
+		},
+	}, issues)
+}
@@ -68,19 +68,48 @@ type ExecutionConstraint struct {
 	Environments []string `json:"environments,omitempty"`
 	// Regions define the regions in which this pipeline should run, for the given clouds and environments. If unset, execution will be unconstrained across regions.
 	Regions []string `json:"regions,omitempty"`
+	// Stamps define the stamps in which this pipeline should run, for the given clouds, environments and regions. Only stamped
+	// resource groups may be constrained by stamp. If unset, execution will be unconstrained across stamps.
+	Stamps []string `json:"stamps,omitempty"`
 }
 
 func (rg *ResourceGroup) Validate() error {
+	for _, issue := range rg.validationReport("").Issues {
+		if issue.Severity == SeverityError {
+			return issue.cause
+		}
+	}
+	return nil
+}
+
+// validationReport records every issue with the resource group, using location to identify it in the pipeline.
+func (rg *ResourceGroup) validationReport(location string) *ValidationReport {
+	report := &ValidationReport{}
+	field := func(name string) string {
+		if location == "" {
+			return name
+		}
+		return location + "." + name
+	}
 	if rg.ResourceGroupMeta == nil {
-		return fmt.Errorf("resource group metadata is required")
+		report.addError(location, IssueCodeMissingResourceGroupMeta, fmt.Errorf("resource group metadata is required"), "resource group metadata is required")
+		return report
 	}
 	if rg.Name == "" {
-		return fmt.Errorf("resource group name is required")
+		report.addError(field("name"), IssueCodeMissingResourceGroupName, fmt.Errorf("resource group name is required"), "resource group name is required")
 	}
 	if rg.Subscription == "" {
-		return fmt.Errorf("subscription is required")
+		report.addError(field("subscription"), IssueCodeMissingSubscription, fmt.Errorf("subscription is required"), "subscription is required")
 	}
-	return nil
+	for i, constraint := range rg.ExecutionConstraints {
+		if len(constraint.Stamps) > 0 && !rg.Stamped {
+			report.addError(field(fmt.Sprintf("executionConstraints[%d].stamps", i)), IssueCodeInvalidExecutionConstraint,
+				fmt.Errorf("executionConstraints[%d]: stamps may only constrain stamped resource groups", i),
+				"stamps may only constrain stamped resource groups",
+			)
+		}
+	}
+	return report
 }
 
 type Steps []Step
@@ -98,65 +127,7 @@ func (s *Steps) UnmarshalJSON(data []byte) error {
 			return fmt.Errorf("steps[%d]: failed to unmarshal step metadata from raw json: %w", i, err)
 		}
 
-		var step Step
-		switch stepMeta.Action {
-		case StepActionShell:
-			step = &ShellStep{}
-		case StepActionSafeFly:
-			step = &SafeFlyStep{}
-		case StepActionHelm:
-			step = &HelmStep{}
-		case StepActionARM:
-			step = &ARMStep{}
-		case StepActionARMStack:
-			step = &ARMStackStep{}
-		case StepActionDelegateChildZone:
-			step = &DelegateChildZoneStep{}
-		case StepActionSetCertificateIssuer:
-			step = &SetCertificateIssuerStep{}
-		case StepActionCreateCertificate:
-			step = &CreateCertificateStep{}
-		case StepActionResourceProviderRegistration:
-			step = &ResourceProviderRegistrationStep{}
-		case StepActionImageMirror:
-			step = &ImageMirrorStep{}
-		case StepActionRPLogs, StepActionClusterLogs:
-			step = &LogsStep{}
-		case StepActionFeatureRegistration:
-			step = &FeatureRegistrationStep{}
-		case StepActionProviderFeatureRegistration:
-			step = &ProviderFeatureRegistrationStep{}
-		case StepActionEv2Registration:
-			step = &Ev2RegistrationStep{}
-		case StepActionSecretSync:
-			step = &SecretSyncStep{}
-		case StepActionKusto:
-			step = &KustoStep{}
-		case StepActionPav2:
-			step = &Pav2Step{}
-		case StepActionPublishGenevaAction:
-			step = &PublishGenevaActionStep{}
-		case StepActionRunGenevaAction:
-			step = &RunGenevaActionStep{}
-		case StepActionGenevaHealth:
-			step = &GenevaHealthStep{}
-		case StepActionPublishGenevaAutomation:
-			step = &PublishGenevaAutomationStep{}
-		case StepActionProwJob:
-			step = &ProwJobStep{}
-		case StepActionGrafanaDashboards:
-			step = &GrafanaDashboardsStep{}
-		case StepActionGrafanaManage:
-			step = &GrafanaManageStep{}
-		case StepActionGrafanaDatasources:
-			step = &GrafanaDatasourcesStep{}
-		case StepActionKustoEntityGroups:
-			step = &KustoEntityGroupsStep{}
-		case StepActionIstioUpgrade:
-			step = &IstioUpgradeStep{}
-		default:
-			step = &GenericStep{}
-		}
+		step := newStep(stepMeta.Action)
 		if err := yaml.Unmarshal(rawStep, step); err != nil {
 			return fmt.Errorf("steps[%d]: failed to unmarshal step from metadata remainder: %w", i, err)
 		}
@@ -181,15 +152,7 @@ func (s *ValidationSteps) UnmarshalJSON(data []byte) error {
 			return fmt.Errorf("steps[%d]: failed to unmarshal step metadata from raw json: %w", i, err)
 		}
 
-		var step ValidationStep
-		switch stepMeta.Action {
-		case StepActionShell:
-			step = &ShellValidationStep{}
-		case StepActionProwJob:
-			step = &ProwJobValidationStep{}
-		default:
-			step = &GenericValidationStep{}
-		}
+		step := newValidationStep(stepMeta.Action)
 		if err := yaml.Unmarshal(rawStep, step); err != nil {
 			return fmt.Errorf("steps[%d]: failed to unmarshal step from metadata remainder: %w", i, err)
 		}
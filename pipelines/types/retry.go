@@ -0,0 +1,100 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Limits on automated retries, as enforced by Ev2.
+const (
+	// MaxErrorContainsAny is the maximum number of strings in AutomatedRetry.ErrorContainsAny.
+	MaxErrorContainsAny = 16
+	// MaxErrorContainsAnyBytes is the maximum length of AutomatedRetry.ErrorContainsAny, encoded as JSON.
+	MaxErrorContainsAnyBytes = 1024
+	// DefaultRetryCount is the number of retries made when AutomatedRetry.MaximumRetryCount is unset.
+	DefaultRetryCount = 1
+	// MinRetryCount and MaxRetryCount bound AutomatedRetry.MaximumRetryCount.
+	MinRetryCount = 1
+	MaxRetryCount = 10
+	// MinDurationBetweenRetries and MaxDurationBetweenRetries bound AutomatedRetry.DurationBetweenRetries.
+	MinDurationBetweenRetries = time.Minute
+	MaxDurationBetweenRetries = 3 * time.Hour
+)
+
+// RetryCount is the maximum number of retries, applying the default when MaximumRetryCount is unset.
+func (r *AutomatedRetry) RetryCount() int {
+	if r.MaximumRetryCount == 0 {
+		return DefaultRetryCount
+	}
+	return r.MaximumRetryCount
+}
+
+// RetryDelay parses the time to wait between retries, which is zero when DurationBetweenRetries is unset.
+func (r *AutomatedRetry) RetryDelay() (time.Duration, error) {
+	if r.DurationBetweenRetries == "" {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(r.DurationBetweenRetries)
+	if err != nil {
+		return 0, fmt.Errorf("invalid durationBetweenRetries %q: %w", r.DurationBetweenRetries, err)
+	}
+	return delay, nil
+}
+
+// validationReport records every limit the automated retry exceeds, using location to identify it in the pipeline.
+func (r *AutomatedRetry) validationReport(location string) *ValidationReport {
+	report := &ValidationReport{}
+	if r == nil {
+		return report
+	}
+	field := func(name string) string {
+		return location + "." + name
+	}
+	if len(r.ErrorContainsAny) > MaxErrorContainsAny {
+		report.addError(field("errorContainsAny"), IssueCodeInvalidAutomatedRetry,
+			fmt.Errorf("%s: %d strings exceed the limit of %d", field("errorContainsAny"), len(r.ErrorContainsAny), MaxErrorContainsAny),
+			"errorContainsAny holds %d strings, at most %d are allowed", len(r.ErrorContainsAny), MaxErrorContainsAny,
+		)
+	}
+	if encoded, err := json.Marshal(r.ErrorContainsAny); err == nil && len(encoded) > MaxErrorContainsAnyBytes {
+		report.addError(field("errorContainsAny"), IssueCodeInvalidAutomatedRetry,
+			fmt.Errorf("%s: encoded length of %d bytes exceeds the limit of %d", field("errorContainsAny"), len(encoded), MaxErrorContainsAnyBytes),
+			"errorContainsAny is %d bytes long when encoded, at most %d are allowed", len(encoded), MaxErrorContainsAnyBytes,
+		)
+	}
+	if r.MaximumRetryCount != 0 && (r.MaximumRetryCount < MinRetryCount || r.MaximumRetryCount > MaxRetryCount) {
+		report.addError(field("maximumRetryCount"), IssueCodeInvalidAutomatedRetry,
+			fmt.Errorf("%s: %d is not between %d and %d", field("maximumRetryCount"), r.MaximumRetryCount, MinRetryCount, MaxRetryCount),
+			"maximumRetryCount must be between %d and %d, got %d", MinRetryCount, MaxRetryCount, r.MaximumRetryCount,
+		)
+	}
+	delay, err := r.RetryDelay()
+	switch {
+	case err != nil:
+		report.addError(field("durationBetweenRetries"), IssueCodeInvalidAutomatedRetry,
+			fmt.Errorf("%s: %w", field("durationBetweenRetries"), err),
+			"%v", err,
+		)
+	case r.DurationBetweenRetries != "" && (delay < MinDurationBetweenRetries || delay > MaxDurationBetweenRetries):
+		report.addError(field("durationBetweenRetries"), IssueCodeInvalidAutomatedRetry,
+			fmt.Errorf("%s: %s is not between %s and %s", field("durationBetweenRetries"), delay, MinDurationBetweenRetries, MaxDurationBetweenRetries),
+			"durationBetweenRetries must be between %s and %s, got %s", MinDurationBetweenRetries, MaxDurationBetweenRetries, delay,
+		)
+	}
+	return report
+}
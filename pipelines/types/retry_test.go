@@ -0,0 +1,102 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutomatedRetryValidation(t *testing.T) {
+	tooMany := make([]string, MaxErrorContainsAny+1)
+	for i := range tooMany {
+		tooMany[i] = "error"
+	}
+	for _, testCase := range []struct {
+		name     string
+		retry    *AutomatedRetry
+		messages []string
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:  "valid",
+			retry: &AutomatedRetry{ErrorContainsAny: []string{"Conflict"}, MaximumRetryCount: 10, DurationBetweenRetries: "3h"},
+		},
+		{
+			name:     "too many strings",
+			retry:    &AutomatedRetry{ErrorContainsAny: tooMany},
+			messages: []string{"errorContainsAny holds 17 strings, at most 16 are allowed"},
+		},
+		{
+			name:     "strings too long",
+			retry:    &AutomatedRetry{ErrorContainsAny: []string{strings.Repeat("a", 600), strings.Repeat("b", 600)}},
+			messages: []string{"errorContainsAny is 1207 bytes long when encoded, at most 1024 are allowed"},
+		},
+		{
+			name:     "retry count out of range",
+			retry:    &AutomatedRetry{MaximumRetryCount: 11},
+			messages: []string{"maximumRetryCount must be between 1 and 10, got 11"},
+		},
+		{
+			name:     "duration too short",
+			retry:    &AutomatedRetry{DurationBetweenRetries: "30s"},
+			messages: []string{"durationBetweenRetries must be between 1m0s and 3h0m0s, got 30s"},
+		},
+		{
+			name:     "invalid duration",
+			retry:    &AutomatedRetry{MaximumRetryCount: -1, DurationBetweenRetries: "1 minute"},
+			messages: []string{"maximumRetryCount must be between 1 and 10, got -1", `invalid durationBetweenRetries "1 minute": time: unknown unit " minute" in duration "1 minute"`},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			report := testCase.retry.validationReport("steps[0].automatedRetry")
+			var messages []string
+			for _, issue := range report.Issues {
+				assert.Equal(t, IssueCodeInvalidAutomatedRetry, issue.Code)
+				assert.True(t, strings.HasPrefix(issue.Location, "steps[0].automatedRetry."), issue.Location)
+				messages = append(messages, issue.Message)
+			}
+			assert.Equal(t, testCase.messages, messages)
+		})
+	}
+}
+
+const retryPipeline = `serviceGroup: test
+rolloutName: test
+resourceGroups:
+- name: rg
+  resourceGroup: rg
+  subscription: sub
+  steps:
+  - name: script
+    action: Shell
+    command: make
+    shellIdentity:
+      value: test-msi
+    automatedRetry:
+      errorContainsAny:
+      - Conflict
+      durationBetweenRetries: 10s
+`
+
+func TestValidateAutomatedRetry(t *testing.T) {
+	_, err := NewPipelineFromBytes([]byte(retryPipeline), map[string]any{})
+	require.ErrorContains(t, err, "resourceGroups[0].steps[0].automatedRetry.durationBetweenRetries: durationBetweenRetries must be between 1m0s and 3h0m0s, got 10s")
+}
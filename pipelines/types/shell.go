@@ -21,6 +21,11 @@ import (
 
 const StepActionShell = "Shell"
 
+func init() {
+	RegisterStepType(StepActionShell, func() Step { return &ShellStep{} })
+	RegisterValidationStepType(StepActionShell, func() ValidationStep { return &ShellValidationStep{} })
+}
+
 // ShellStep represents a shell step
 type ShellStep struct {
 	StepMeta   `json:",inline"`
@@ -44,6 +49,8 @@ type ShellStep struct {
 	// with a single h, m, or s unit.
 	// If unset, consumers should apply their default shell step timeout.
 	Timeout string `json:"timeout,omitempty"`
+	// Outputs declares the output variables the command produces, which other steps may consume as inputs.
+	Outputs []Output `json:"outputs,omitempty"`
 }
 
 // Reference represents a configurable reference
@@ -62,6 +69,10 @@ func (s *ShellStep) Description() string {
 	return fmt.Sprintf("Step %s\n  Kind: %s\n  Command: %s\n", s.Name, s.Action, s.Command)
 }
 
+func (s *ShellStep) DeclaredOutputs() []Output {
+	return s.Outputs
+}
+
 func (s *ShellStep) RequiredInputs() []StepDependency {
 	var deps []StepDependency
 	for _, val := range append(s.Variables, s.DryRun.Variables...) {
@@ -0,0 +1,157 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+var (
+	stepTypesLock       sync.RWMutex
+	stepTypes           = map[string]func() Step{}
+	validationStepTypes = map[string]func() ValidationStep{}
+)
+
+// RegisterStepType registers the Go type used for steps with the given action. When a pipeline is loaded, each step
+// is unmarshalled into the value returned by the factory for its action. Steps with an action that has not been
+// registered are loaded as a *GenericStep, or rejected when pipelines are loaded with WithStrictActions.
+//
+// RegisterStepType is intended to be called from init functions. It panics if the action is empty, if the factory is
+// nil, or if the action has already been registered.
+func RegisterStepType(action string, factory func() Step) {
+	stepTypesLock.Lock()
+	defer stepTypesLock.Unlock()
+	register(stepTypes, action, factory)
+}
+
+// RegisterValidationStepType registers the Go type used for validation steps with the given action, like
+// RegisterStepType does for regular steps. Unregistered actions are loaded as a *GenericValidationStep.
+func RegisterValidationStepType(action string, factory func() ValidationStep) {
+	stepTypesLock.Lock()
+	defer stepTypesLock.Unlock()
+	register(validationStepTypes, action, factory)
+}
+
+func register[T any](registry map[string]func() T, action string, factory func() T) {
+	if action == "" {
+		panic("step action must not be empty")
+	}
+	if factory == nil {
+		panic(fmt.Sprintf("step action %q registered with a nil factory", action))
+	}
+	if _, exists := registry[action]; exists {
+		panic(fmt.Sprintf("step action %q registered more than once", action))
+	}
+	registry[action] = factory
+}
+
+// StepTypes lists the actions registered for steps, in sorted order.
+func StepTypes() []string {
+	stepTypesLock.RLock()
+	defer stepTypesLock.RUnlock()
+	return sets.List(sets.KeySet(stepTypes))
+}
+
+// ValidationStepTypes lists the actions registered for validation steps, in sorted order.
+func ValidationStepTypes() []string {
+	stepTypesLock.RLock()
+	defer stepTypesLock.RUnlock()
+	return sets.List(sets.KeySet(validationStepTypes))
+}
+
+// newStep creates an empty step of the type registered for the action.
+func newStep(action string) Step {
+	stepTypesLock.RLock()
+	defer stepTypesLock.RUnlock()
+	factory, registered := stepTypes[action]
+	if !registered {
+		return &GenericStep{}
+	}
+	return factory()
+}
+
+// newValidationStep creates an empty validation step of the type registered for the action.
+func newValidationStep(action string) ValidationStep {
+	stepTypesLock.RLock()
+	defer stepTypesLock.RUnlock()
+	factory, registered := validationStepTypes[action]
+	if !registered {
+		return &GenericValidationStep{}
+	}
+	return factory()
+}
+
+func isRegisteredStepType(action string) bool {
+	stepTypesLock.RLock()
+	defer stepTypesLock.RUnlock()
+	_, registered := stepTypes[action]
+	return registered
+}
+
+func isRegisteredValidationStepType(action string) bool {
+	stepTypesLock.RLock()
+	defer stepTypesLock.RUnlock()
+	_, registered := validationStepTypes[action]
+	return registered
+}
+
+// PipelineOption customizes how a pipeline is loaded and validated.
+type PipelineOption func(*pipelineOptions)
+
+type pipelineOptions struct {
+	strictActions bool
+	inferOutputs  bool
+}
+
+// WithStrictActions rejects steps whose action has not been registered with RegisterStepType or
+// RegisterValidationStepType. By default, such steps are loaded as generic steps and only reported as warnings, so
+// a typo in an action would otherwise go unnoticed.
+func WithStrictActions() PipelineOption {
+	return func(o *pipelineOptions) {
+		o.strictActions = true
+	}
+}
+
+// WithInferredOutputs declares the outputs of ARM and ARMStack steps that don't declare any by reading them from the
+// template the step deploys, relative to the pipeline file, so that the inputs consuming them are checked. It has no
+// effect on pipelines that were not loaded from a file.
+func WithInferredOutputs() PipelineOption {
+	return func(o *pipelineOptions) {
+		o.inferOutputs = true
+	}
+}
+
+func newPipelineOptions(opts []PipelineOption) *pipelineOptions {
+	options := &pipelineOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// apply adjusts a validation report to the options.
+func (o *pipelineOptions) apply(report *ValidationReport) {
+	if !o.strictActions {
+		return
+	}
+	for i := range report.Issues {
+		if report.Issues[i].Code == IssueCodeUnknownStepAction {
+			report.Issues[i].Severity = SeverityError
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type customStep struct {
+	StepMeta `json:",inline"`
+	Target   string `json:"target"`
+}
+
+func (s *customStep) Description() string {
+	return "custom"
+}
+
+func (s *customStep) RequiredInputs() []StepDependency {
+	return nil
+}
+
+func init() {
+	RegisterStepType("TestCustom", func() Step { return &customStep{} })
+}
+
+const stepTypesPipeline = `serviceGroup: test
+rolloutName: test
+resourceGroups:
+- name: rg
+  resourceGroup: rg
+  subscription: sub
+  steps:
+  - name: custom
+    action: TestCustom
+    target: somewhere
+  - name: typo
+    action: Shel
+    command: echo hello
+  validationSteps:
+  - name: check
+    action: Shell
+    command: echo hello
+    shellIdentity:
+      value: test-msi
+    validation:
+    - Internal
+`
+
+func TestRegisterStepType(t *testing.T) {
+	pipeline, err := NewPipelineFromBytes([]byte(stepTypesPipeline), map[string]any{})
+	require.NoError(t, err)
+
+	steps := pipeline.ResourceGroups[0].Steps
+	require.Len(t, steps, 2)
+	custom, ok := steps[0].(*customStep)
+	require.True(t, ok, "expected a *customStep, got %T", steps[0])
+	assert.Equal(t, "somewhere", custom.Target)
+	assert.IsType(t, &GenericStep{}, steps[1])
+	assert.IsType(t, &ShellValidationStep{}, pipeline.ResourceGroups[0].ValidationSteps[0])
+
+	assert.Contains(t, StepTypes(), "TestCustom")
+	assert.Contains(t, StepTypes(), StepActionShell)
+	assert.Equal(t, []string{StepActionProwJob, StepActionShell}, ValidationStepTypes())
+
+	assert.PanicsWithValue(t, `step action "TestCustom" registered more than once`, func() {
+		RegisterStepType("TestCustom", func() Step { return &customStep{} })
+	})
+	assert.Panics(t, func() { RegisterStepType("", func() Step { return &customStep{} }) })
+	assert.Panics(t, func() { RegisterValidationStepType("TestNil", nil) })
+}
+
+func TestStrictActions(t *testing.T) {
+	report, err := ValidatePipelineBytes([]byte(stepTypesPipeline), map[string]any{})
+	require.NoError(t, err)
+	assert.False(t, report.HasErrors())
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, IssueCodeUnknownStepAction, report.Issues[0].Code)
+	assert.Equal(t, SeverityWarning, report.Issues[0].Severity)
+	assert.Equal(t, "resourceGroups[0].steps[1].action", report.Issues[0].Location)
+
+	report, err = ValidatePipelineBytes([]byte(stepTypesPipeline), map[string]any{}, WithStrictActions())
+	require.NoError(t, err)
+	assert.True(t, report.HasErrors())
+
+	_, err = NewPipelineFromBytes([]byte(stepTypesPipeline), map[string]any{}, WithStrictActions())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `12:5: resourceGroups[0].steps[1].action: unknown action "Shel"`)
+}
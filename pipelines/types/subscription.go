@@ -3,7 +3,7 @@ package types
 type SubscriptionProvisioning struct {
 	DisplayName                   Value  `json:"displayName"`
 	AIRSRegisteredUserPrincipalId *Value `json:"airsRegisteredUserPrincipalId,omitempty"`
-	CertificateDomains            *Value `json:"certificateDomains,omitempty"`
+	CertificateDomains            *Value `json:"certificateDomains,omitempty" kind:"list"`
 	BackfillSubscriptionId        *Value `json:"backfillSubscriptionId,omitempty"`
 
 	// RoleAssignmentParameters is a relative path to the .bicepparam file used to deploy the bootstrapping role-assignments
@@ -17,6 +17,9 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
 
@@ -28,24 +31,143 @@ import (
 //go:embed pipeline.schema.v1.json
 var pipelineSchemaV1Content []byte
 var pipelineSchemaV1Ref = "pipeline.schema.v1"
+
+var pipelineSchemaV2Ref = "pipeline.schema.v2"
+
+// defaultSchemaRef is assumed for pipelines that do not declare a schema, which predate versioning.
 var defaultSchemaRef = pipelineSchemaV1Ref
 
+// pipelineSchemaVersion is one version of the pipeline format.
+type pipelineSchemaVersion struct {
+	ref string
+	// changes derive the schema for this version from the schema for the previous one, so that definitions shared
+	// between versions are only written down once, in pipeline.schema.v1.json.
+	changes []schemaChange
+	// upgrade migrates a document conforming to the previous version so that it conforms to this one.
+	upgrade migration
+}
+
+// schemaChange replaces the value at a JSON pointer in a schema.
+type schemaChange struct {
+	pointer string
+	value   any
+}
+
+// pipelineSchemaVersions lists every version of the pipeline format, oldest first. The Go types in this package
+// always reflect the latest version; documents using an older one are migrated as they are loaded.
+var pipelineSchemaVersions = []pipelineSchemaVersion{
+	{ref: pipelineSchemaV1Ref},
+	{
+		ref: pipelineSchemaV2Ref,
+		changes: []schemaChange{
+			{pointer: "/definitions/prowJobStep/allOf/1/properties/gatePromotion/type", value: "boolean"},
+		},
+		upgrade: migrateV1ToV2,
+	},
+}
+
+// pipelineSchemaContents holds the schema for each version in pipelineSchemaVersions.
+var pipelineSchemaContents = sync.OnceValues(func() ([][]byte, error) {
+	var schema map[string]any
+	if err := json.Unmarshal(pipelineSchemaV1Content, &schema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schema content: %v", err)
+	}
+	contents := make([][]byte, 0, len(pipelineSchemaVersions))
+	for _, version := range pipelineSchemaVersions {
+		schema["title"] = version.ref
+		for _, change := range version.changes {
+			if err := replaceAtPointer(schema, change.pointer, change.value); err != nil {
+				return nil, fmt.Errorf("failed to derive schema %s: %w", version.ref, err)
+			}
+		}
+		content, err := json.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal schema %s: %w", version.ref, err)
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+})
+
+// replaceAtPointer replaces the value that a JSON pointer refers to in a decoded document. The value must already
+// exist, so that changes can't silently stop applying when the schema they change is edited.
+func replaceAtPointer(document any, pointer string, value any) error {
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	current := document
+	for i, token := range tokens {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		last := i == len(tokens)-1
+		switch node := current.(type) {
+		case map[string]any:
+			if _, exists := node[token]; !exists {
+				return fmt.Errorf("%s: %q not found", pointer, token)
+			}
+			if last {
+				node[token] = value
+				return nil
+			}
+			current = node[token]
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return fmt.Errorf("%s: invalid index %q", pointer, token)
+			}
+			if last {
+				node[index] = value
+				return nil
+			}
+			current = node[index]
+		default:
+			return fmt.Errorf("%s: cannot index %T with %q", pointer, current, token)
+		}
+	}
+	return nil
+}
+
+// PipelineSchemaRefs lists the schema references that pipelines may declare, oldest first.
+func PipelineSchemaRefs() []string {
+	refs := make([]string, 0, len(pipelineSchemaVersions))
+	for _, version := range pipelineSchemaVersions {
+		refs = append(refs, version.ref)
+	}
+	return refs
+}
+
+// LatestPipelineSchemaRef is the schema reference for the newest version of the pipeline format.
+func LatestPipelineSchemaRef() string {
+	return pipelineSchemaVersions[len(pipelineSchemaVersions)-1].ref
+}
+
+// pipelineSchemaIndex finds the position of a schema in pipelineSchemaVersions.
+func pipelineSchemaIndex(schemaRef string) (int, error) {
+	if schemaRef == "" {
+		schemaRef = defaultSchemaRef
+	}
+	for i, version := range pipelineSchemaVersions {
+		if version.ref == schemaRef {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unsupported schema reference: %s", schemaRef)
+}
+
 func getSchemaForPipeline(pipelineMap map[string]interface{}) (pipelineSchema *jsonschema.Schema, schemaRef string, err error) {
 	schemaRef, _ = pipelineMap["$schema"].(string)
 	return getSchemaForRef(schemaRef)
 }
 
 func getSchemaForRef(schemaRef string) (*jsonschema.Schema, string, error) {
-	if schemaRef == "" {
-		schemaRef = defaultSchemaRef
+	index, err := pipelineSchemaIndex(schemaRef)
+	if err != nil {
+		return nil, "", err
 	}
-	switch schemaRef {
-	case pipelineSchemaV1Ref:
-		pipelineSchema, err := compileSchema(schemaRef, pipelineSchemaV1Content)
-		return pipelineSchema, schemaRef, err
-	default:
-		return nil, "", fmt.Errorf("unsupported schema reference: %s", schemaRef)
+	contents, err := pipelineSchemaContents()
+	if err != nil {
+		return nil, "", err
 	}
+	version := pipelineSchemaVersions[index]
+	pipelineSchema, err := compileSchema(version.ref, contents[index])
+	return pipelineSchema, version.ref, err
 }
 
 func ValidatePipelineSchema(pipelineContent []byte) error {
@@ -15,6 +15,11 @@
 package types
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -237,6 +242,154 @@ func TestPipelineValidate(t *testing.T) {
 	}
 }
 
+func TestPipelineValidateCycles(t *testing.T) {
+	shell := func(name string, dependsOn ...StepDependency) Step {
+		return &ShellStep{
+			StepMeta: StepMeta{
+				Name:      name,
+				Action:    "Shell",
+				DependsOn: dependsOn,
+			},
+			Command: "echo " + name,
+		}
+	}
+	dep := func(rg, step string) StepDependency {
+		return StepDependency{ResourceGroup: rg, Step: step}
+	}
+	group := func(name string, steps ...Step) *ResourceGroup {
+		return &ResourceGroup{
+			ResourceGroupMeta: &ResourceGroupMeta{
+				Name:          name,
+				ResourceGroup: name,
+				Subscription:  "sub1",
+			},
+			Steps: steps,
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		pipeline *Pipeline
+		cycles   [][]StepDependency
+		err      string
+	}{
+		{
+			name: "acyclic",
+			pipeline: &Pipeline{
+				ResourceGroups: []*ResourceGroup{
+					group("rg1", shell("a"), shell("b", dep("rg1", "a"))),
+					group("rg2", shell("c", dep("rg1", "a"), dep("rg1", "b"))),
+				},
+			},
+		},
+		{
+			name: "self-dependency",
+			pipeline: &Pipeline{
+				ResourceGroups: []*ResourceGroup{
+					group("rg1", shell("a", dep("rg1", "a"))),
+				},
+			},
+			cycles: [][]StepDependency{
+				{dep("rg1", "a"), dep("rg1", "a")},
+			},
+			err: "pipeline has 1 dependency cycle(s): rg1/a -> rg1/a",
+		},
+		{
+			name: "cycle across resource groups",
+			pipeline: &Pipeline{
+				ResourceGroups: []*ResourceGroup{
+					group("rg1", shell("a", dep("rg2", "c")), shell("b", dep("rg1", "a"))),
+					group("rg2", shell("c", dep("rg1", "b"))),
+				},
+			},
+			cycles: [][]StepDependency{
+				{dep("rg1", "a"), dep("rg1", "b"), dep("rg2", "c"), dep("rg1", "a")},
+			},
+			err: "pipeline has 1 dependency cycle(s): rg1/a -> rg1/b -> rg2/c -> rg1/a",
+		},
+		{
+			name: "cycle through inputs",
+			pipeline: &Pipeline{
+				ResourceGroups: []*ResourceGroup{
+					group("rg1",
+						&ShellStep{
+							StepMeta: StepMeta{Name: "a", Action: "Shell"},
+							Variables: []Variable{{
+								Name:  "FOO",
+								Value: Value{Input: &Input{StepDependency: dep("rg1", "b"), Name: "foo"}},
+							}},
+						},
+						shell("b", dep("rg1", "a")),
+					),
+				},
+			},
+			cycles: [][]StepDependency{
+				{dep("rg1", "a"), dep("rg1", "b"), dep("rg1", "a")},
+			},
+			err: "pipeline has 1 dependency cycle(s): rg1/a -> rg1/b -> rg1/a",
+		},
+		{
+			name: "every cycle reported",
+			pipeline: &Pipeline{
+				ResourceGroups: []*ResourceGroup{
+					group("rg1",
+						shell("a", dep("rg1", "c")),
+						shell("b", dep("rg1", "a")),
+						shell("c", dep("rg1", "a"), dep("rg1", "b")),
+						shell("d"),
+						shell("e", dep("rg1", "d"), dep("rg1", "f")),
+						shell("f", dep("rg1", "e")),
+					),
+				},
+			},
+			cycles: [][]StepDependency{
+				{dep("rg1", "a"), dep("rg1", "b"), dep("rg1", "c"), dep("rg1", "a")},
+				{dep("rg1", "a"), dep("rg1", "c"), dep("rg1", "a")},
+				{dep("rg1", "e"), dep("rg1", "f"), dep("rg1", "e")},
+			},
+			err: "pipeline has 3 dependency cycle(s): rg1/a -> rg1/b -> rg1/c -> rg1/a; rg1/a -> rg1/c -> rg1/a; rg1/e -> rg1/f -> rg1/e",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.pipeline.Validate()
+			if tc.err == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Error(t, err, tc.err)
+			var cycleErr *CycleError
+			assert.Assert(t, errors.As(err, &cycleErr))
+			assert.DeepEqual(t, cycleErr.Cycles, tc.cycles)
+			assert.Assert(t, !cycleErr.Truncated)
+		})
+	}
+
+	t.Run("densely connected steps truncated", func(t *testing.T) {
+		// every step depends on every other, forming more cycles than could ever be enumerated
+		var names []string
+		for i := range 30 {
+			names = append(names, fmt.Sprintf("s%02d", i))
+		}
+		var steps []Step
+		for _, name := range names {
+			var dependsOn []StepDependency
+			for _, other := range names {
+				if other != name {
+					dependsOn = append(dependsOn, dep("rg1", other))
+				}
+			}
+			steps = append(steps, shell(name, dependsOn...))
+		}
+		err := (&Pipeline{ResourceGroups: []*ResourceGroup{group("rg1", steps...)}}).Validate()
+		var cycleErr *CycleError
+		assert.Assert(t, errors.As(err, &cycleErr))
+		assert.Assert(t, cycleErr.Truncated)
+		assert.Equal(t, len(cycleErr.Cycles), maxCyclesPerComponent)
+		assert.Assert(t, strings.HasPrefix(err.Error(), fmt.Sprintf("pipeline has more than %d dependency cycle(s), showing the first: ", maxCyclesPerComponent)))
+	})
+}
+
 func TestGetSchemaForPipeline(t *testing.T) {
 	testCases := []struct {
 		name              string
@@ -256,6 +409,13 @@ func TestGetSchemaForPipeline(t *testing.T) {
 			},
 			expectedSchemaRef: pipelineSchemaV1Ref,
 		},
+		{
+			name: "explicit newer schema",
+			pipeline: map[string]interface{}{
+				"$schema": pipelineSchemaV2Ref,
+			},
+			expectedSchemaRef: pipelineSchemaV2Ref,
+		},
 		{
 			name: "invalid schema",
 			pipeline: map[string]interface{}{
@@ -281,6 +441,35 @@ func TestGetSchemaForPipeline(t *testing.T) {
 	}
 }
 
+func TestPipelineSchemaVersions(t *testing.T) {
+	contents, err := pipelineSchemaContents()
+	assert.NilError(t, err)
+	assert.Equal(t, len(contents), len(pipelineSchemaVersions))
+
+	gatePromotionType := func(content []byte) any {
+		var schema map[string]any
+		assert.NilError(t, json.Unmarshal(content, &schema))
+		prowJob := schema["definitions"].(map[string]any)["prowJobStep"].(map[string]any)
+		properties := prowJob["allOf"].([]any)[1].(map[string]any)["properties"].(map[string]any)
+		return properties["gatePromotion"].(map[string]any)["type"]
+	}
+	for i, version := range pipelineSchemaVersions {
+		var schema map[string]any
+		assert.NilError(t, json.Unmarshal(contents[i], &schema))
+		assert.Equal(t, schema["title"], version.ref)
+	}
+	assert.Equal(t, gatePromotionType(contents[0]), "string")
+	assert.Equal(t, gatePromotionType(contents[1]), "boolean")
+	assert.Assert(t, bytes.Contains(pipelineSchemaV1Content, []byte(`"title": "pipeline.schema.v1"`)), "deriving newer schemas must not modify the embedded schema")
+
+	document := map[string]any{"a/b": []any{map[string]any{"c": 1}}}
+	assert.NilError(t, replaceAtPointer(document, "/a~1b/0/c", 2))
+	assert.DeepEqual(t, document, map[string]any{"a/b": []any{map[string]any{"c": 2}}})
+	assert.Error(t, replaceAtPointer(document, "/a~1b/0/missing", 2), `/a~1b/0/missing: "missing" not found`)
+	assert.Error(t, replaceAtPointer(document, "/a~1b/1/c", 2), `/a~1b/1/c: invalid index "1"`)
+	assert.Error(t, replaceAtPointer(document, "/a~1b/0/c/d", 2), `/a~1b/0/c/d: cannot index int with "d"`)
+}
+
 func TestValidatePipelineSchema(t *testing.T) {
 	testCases := []struct {
 		name              string
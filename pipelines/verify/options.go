@@ -0,0 +1,143 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+)
+
+func DefaultOptions() *RawOptions {
+	return &RawOptions{}
+}
+
+func BindOptions(opts *RawOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringSliceVar(&opts.TopologyPaths, "topology", opts.TopologyPaths, "Path to a topology file. May be provided more than once to combine topologies.")
+	cmd.Flags().StringVar(&opts.ConfigPath, "config", opts.ConfigPath, "Path to the service configuration file.")
+	cmd.Flags().StringVar(&opts.Cloud, "cloud", opts.Cloud, "Only verify contexts in this cloud.")
+	cmd.Flags().StringVar(&opts.Environment, "environment", opts.Environment, "Only verify contexts in this environment.")
+	cmd.Flags().StringVar(&opts.Region, "region", opts.Region, "Only verify contexts in this region.")
+
+	for _, flag := range []string{
+		"topology",
+		"config",
+	} {
+		if err := cmd.MarkFlagFilename(flag); err != nil {
+			return fmt.Errorf("failed to mark flag %q as a file: %w", flag, err)
+		}
+	}
+	return nil
+}
+
+// RawOptions holds input values.
+type RawOptions struct {
+	TopologyPaths []string
+	ConfigPath    string
+	Cloud         string
+	Environment   string
+	Region        string
+}
+
+// validatedOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedOptions struct {
+	TopologyPaths []string
+	ConfigPath    string
+	Cloud         string
+	Environment   string
+	Region        string
+}
+
+type ValidatedOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedOptions
+}
+
+// completedOptions is a private wrapper that enforces a call of Complete() before verification can be invoked.
+type completedOptions struct {
+	Topology *topology.CombinedTopology
+	Provider config.ConfigProvider
+	Contexts []Context
+}
+
+type Options struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedOptions
+}
+
+func (o *RawOptions) Validate() (*ValidatedOptions, error) {
+	if len(o.TopologyPaths) == 0 {
+		return nil, fmt.Errorf("at least one topology file must be provided with --topology")
+	}
+	if o.ConfigPath == "" {
+		return nil, fmt.Errorf("the service configuration file must be provided with --config")
+	}
+
+	return &ValidatedOptions{
+		validatedOptions: &validatedOptions{
+			TopologyPaths: o.TopologyPaths,
+			ConfigPath:    o.ConfigPath,
+			Cloud:         o.Cloud,
+			Environment:   o.Environment,
+			Region:        o.Region,
+		},
+	}, nil
+}
+
+func (o *ValidatedOptions) Complete() (*Options, error) {
+	topo, err := topology.LoadCombined(o.TopologyPaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load topology: %w", err)
+	}
+	provider, err := config.NewConfigProvider(o.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service configuration: %w", err)
+	}
+
+	contexts := slices.DeleteFunc(Contexts(provider), func(c Context) bool {
+		return o.Cloud != "" && c.Cloud != o.Cloud ||
+			o.Environment != "" && c.Environment != o.Environment ||
+			o.Region != "" && c.Region != o.Region
+	})
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("no contexts in %s match the --cloud, --environment and --region provided", o.ConfigPath)
+	}
+
+	return &Options{
+		completedOptions: &completedOptions{
+			Topology: topo,
+			Provider: provider,
+			Contexts: contexts,
+		},
+	}, nil
+}
+
+// Verify verifies the topology in every context, writing the report to out. An error is returned if any context
+// failed verification.
+func (opts *Options) Verify(out io.Writer) error {
+	report := Topology(opts.Topology, opts.Provider, VerifyOptions{Contexts: opts.Contexts})
+	if _, err := io.WriteString(out, report.String()); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if report.HasFailures() {
+		return fmt.Errorf("topology failed verification")
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/config"
+	types2 "github.com/Azure/ARO-Tools/config/types"
+	"github.com/Azure/ARO-Tools/pipelines/graph"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+	"github.com/Azure/ARO-Tools/pipelines/types"
+)
+
+// Context identifies one cloud, environment and region for which the topology is verified.
+type Context struct {
+	Cloud       string `json:"cloud"`
+	Environment string `json:"environment"`
+	Region      string `json:"region"`
+}
+
+func (c Context) String() string {
+	return fmt.Sprintf("%s/%s/%s", c.Cloud, c.Environment, c.Region)
+}
+
+// Contexts lists every cloud, environment and region the provider has explicit records for, in sorted order.
+// Environments without any regions are omitted, as there is no region configuration to verify pipelines with.
+func Contexts(provider config.ConfigProvider) []Context {
+	var contexts []Context
+	for cloud, environments := range provider.AllContexts() {
+		for environment, regions := range environments {
+			for _, region := range regions {
+				contexts = append(contexts, Context{Cloud: cloud, Environment: environment, Region: region})
+			}
+		}
+	}
+	slices.SortFunc(contexts, func(a, b Context) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return contexts
+}
+
+// Replacements determines the configuration replacements used to resolve configuration for a context.
+type Replacements func(Context) (*config.ConfigReplacements, error)
+
+// DefaultReplacements resolves configuration for the first stamp in each context, as config.DefaultReplacements does.
+func DefaultReplacements(c Context) (*config.ConfigReplacements, error) {
+	return config.DefaultReplacements(c.Cloud, c.Environment, c.Region), nil
+}
+
+// Failure records one problem found while verifying a context. ServiceGroup is set when a pipeline could not be
+// loaded, Entrypoint when the graph for an entrypoint could not be built, and neither when the configuration for the
+// context could not be resolved.
+type Failure struct {
+	ServiceGroup string
+	Entrypoint   string
+	Err          error
+}
+
+func (f Failure) Error() string {
+	switch {
+	case f.ServiceGroup != "":
+		return fmt.Sprintf("service group %s: %v", f.ServiceGroup, f.Err)
+	case f.Entrypoint != "":
+		return fmt.Sprintf("entrypoint %s: %v", f.Entrypoint, f.Err)
+	}
+	return f.Err.Error()
+}
+
+func (f Failure) Unwrap() error {
+	return f.Err
+}
+
+// ContextReport records the failures found while verifying one context.
+type ContextReport struct {
+	Context  Context
+	Failures []Failure
+}
+
+// Report records every failure found while verifying a topology, aggregated by context.
+type Report struct {
+	// Topology records failures in the structure of the topology itself, which apply to every context.
+	Topology []error
+	// Contexts holds a report for every context that was verified, in sorted order, even if it had no failures.
+	Contexts []ContextReport
+}
+
+// HasFailures determines if any failures were found.
+func (r *Report) HasFailures() bool {
+	if len(r.Topology) > 0 {
+		return true
+	}
+	for _, context := range r.Contexts {
+		if len(context.Failures) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Err combines every failure into one error, qualifying each with its context, or returns nil if there were none.
+func (r *Report) Err() error {
+	errs := slices.Clone(r.Topology)
+	for _, context := range r.Contexts {
+		for _, failure := range context.Failures {
+			errs = append(errs, fmt.Errorf("%s: %w", context.Context, failure))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String renders the report for humans, listing the failures under each context that had any.
+func (r *Report) String() string {
+	var out strings.Builder
+	for _, err := range r.Topology {
+		fmt.Fprintf(&out, "topology: %v\n", err)
+	}
+	var failed int
+	for _, context := range r.Contexts {
+		if len(context.Failures) == 0 {
+			continue
+		}
+		failed++
+		fmt.Fprintf(&out, "%s:\n", context.Context)
+		for _, failure := range context.Failures {
+			fmt.Fprintf(&out, "  %s\n", strings.ReplaceAll(failure.Error(), "\n", "\n    "))
+		}
+	}
+	fmt.Fprintf(&out, "%d of %d contexts failed verification.\n", failed, len(r.Contexts))
+	return out.String()
+}
+
+// VerifyOptions configure how a topology is verified.
+type VerifyOptions struct {
+	// Contexts limits verification to these contexts. If unset, every context the provider has records for is verified.
+	Contexts []Context
+	// Replacements determines how configuration is resolved for each context. If unset, DefaultReplacements is used.
+	Replacements Replacements
+	// PipelineOptions are used when loading every pipeline.
+	PipelineOptions []types.PipelineOption
+}
+
+// Topology verifies that a topology is valid and that, for every context, every service's pipeline loads and every
+// entrypoint's graph builds. Graphs omit resource groups whose execution constraints exclude the context. Failures are
+// aggregated rather than returned at the first one, so that the report shows everything that needs to be fixed.
+func Topology(topo *topology.CombinedTopology, provider config.ConfigProvider, opts VerifyOptions) *Report {
+	report := &Report{}
+	if err := topo.Validate(); err != nil {
+		report.Topology = append(report.Topology, err)
+		return report
+	}
+
+	contexts := opts.Contexts
+	if len(contexts) == 0 {
+		contexts = Contexts(provider)
+	}
+	replacements := opts.Replacements
+	if replacements == nil {
+		replacements = DefaultReplacements
+	}
+	for _, context := range contexts {
+		report.Contexts = append(report.Contexts, ContextReport{
+			Context:  context,
+			Failures: verifyContext(topo, provider, context, replacements, opts.PipelineOptions),
+		})
+	}
+	return report
+}
+
+func verifyContext(topo *topology.CombinedTopology, provider config.ConfigProvider, context Context, replacements Replacements, pipelineOptions []types.PipelineOption) []Failure {
+	cfg, err := resolveConfiguration(provider, context, replacements)
+	if err != nil {
+		return []Failure{{Err: err}}
+	}
+
+	var failures []Failure
+	pipelines := map[string]*types.Pipeline{}
+	for _, service := range services(topo.Services) {
+		path, err := PipelinePath(topo, service)
+		if err != nil {
+			failures = append(failures, Failure{ServiceGroup: service.ServiceGroup, Err: err})
+			continue
+		}
+		pipeline, err := types.NewPipelineFromFile(path, cfg, pipelineOptions...)
+		if err != nil {
+			failures = append(failures, Failure{ServiceGroup: service.ServiceGroup, Err: err})
+			continue
+		}
+		pipelines[service.ServiceGroup] = pipeline
+	}
+
+	for _, entrypoint := range topo.Entrypoints {
+		root, err := topo.Lookup(entrypoint.Identifier)
+		if err != nil {
+			failures = append(failures, Failure{Entrypoint: entrypoint.Identifier, Err: err})
+			continue
+		}
+		// services whose pipeline failed to load have already been reported, and graphs can't be built without them
+		if slices.ContainsFunc(services([]topology.Service{*root}), func(service *topology.Service) bool {
+			_, loaded := pipelines[service.ServiceGroup]
+			return !loaded
+		}) {
+			continue
+		}
+		if _, err := graph.ForEntrypoint(&topo.Topology, &entrypoint, pipelines, graph.WithExecutionContext(context.Cloud, context.Environment, context.Region)); err != nil {
+			failures = append(failures, Failure{Entrypoint: entrypoint.Identifier, Err: err})
+		}
+	}
+	return failures
+}
+
+func resolveConfiguration(provider config.ConfigProvider, context Context, replacements Replacements) (types2.Configuration, error) {
+	configReplacements, err := replacements(context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine configuration replacements: %w", err)
+	}
+	resolver, err := provider.GetResolver(configReplacements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration resolver: %w", err)
+	}
+	cfg, err := resolver.GetRegionConfiguration(context.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve region configuration: %w", err)
+	}
+	if err := resolver.ValidateSchema(cfg); err != nil {
+		return nil, fmt.Errorf("failed to validate region configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// services flattens the service trees, visiting parents before their children.
+func services(roots []topology.Service) []*topology.Service {
+	var all []*topology.Service
+	for i := range roots {
+		all = append(all, &roots[i])
+		all = append(all, services(roots[i].Children)...)
+	}
+	return all
+}
+
+// PipelinePath resolves the pipeline for the service relative to the topology file that declared it, defaulting to
+// the pipeline recorded in the service's metadata like Topology.Validate does.
+func PipelinePath(topo *topology.CombinedTopology, service *topology.Service) (string, error) {
+	path := service.PipelinePath
+	if path == "" {
+		path = service.Metadata["pipeline"]
+	}
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+	dir, err := topo.GetTopologyDirForServiceGroup(service.ServiceGroup)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, path), nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-Tools/config"
+	"github.com/Azure/ARO-Tools/pipelines/topology"
+)
+
+func loadTopology(t *testing.T, path string) *topology.CombinedTopology {
+	t.Helper()
+	topo, err := topology.LoadCombined([]string{path})
+	require.NoError(t, err)
+	return topo
+}
+
+func TestTopology(t *testing.T) {
+	provider, err := config.NewConfigProvider("../testdata/config.yaml")
+	require.NoError(t, err)
+	uksouth := Context{Cloud: "public", Environment: "int", Region: "uksouth"}
+	require.Equal(t, []Context{uksouth}, Contexts(provider))
+
+	t.Run("valid topology", func(t *testing.T) {
+		report := Topology(loadTopology(t, "testdata/topology.yaml"), provider, VerifyOptions{})
+		assert.False(t, report.HasFailures())
+		assert.NoError(t, report.Err())
+		assert.Equal(t, []ContextReport{{Context: uksouth}}, report.Contexts)
+		assert.Equal(t, "0 of 1 contexts failed verification.\n", report.String())
+	})
+
+	t.Run("failures are aggregated by context", func(t *testing.T) {
+		report := Topology(loadTopology(t, "testdata/broken-topology.yaml"), provider, VerifyOptions{})
+		require.True(t, report.HasFailures())
+		require.Len(t, report.Contexts, 1)
+		failures := report.Contexts[0].Failures
+		require.Len(t, failures, 2)
+
+		assert.Equal(t, "Microsoft.Azure.ARO.HCP.Service", failures[0].ServiceGroup)
+		assert.ErrorContains(t, failures[0], "pipeline file failed validation")
+
+		// the region entrypoint can't be built without the broken service, so only the cycle is reported
+		assert.Equal(t, "Microsoft.Azure.ARO.HCP.Cycle", failures[1].Entrypoint)
+		assert.ErrorContains(t, failures[1], "cycle detected")
+
+		assert.ErrorContains(t, report.Err(), "public/int/uksouth: entrypoint Microsoft.Azure.ARO.HCP.Cycle: ")
+		assert.Contains(t, report.String(), "public/int/uksouth:\n  service group Microsoft.Azure.ARO.HCP.Service: ")
+		assert.Contains(t, report.String(), "1 of 1 contexts failed verification.\n")
+	})
+
+	t.Run("invalid topology", func(t *testing.T) {
+		topo := loadTopology(t, "testdata/topology.yaml")
+		topo.Entrypoints = append(topo.Entrypoints, topology.Entrypoint{Identifier: "Microsoft.Azure.ARO.HCP.Missing"})
+		report := Topology(topo, provider, VerifyOptions{})
+		require.Len(t, report.Topology, 1)
+		assert.ErrorContains(t, report.Topology[0], "entrypoint Microsoft.Azure.ARO.HCP.Missing was not found in the dependency tree")
+		assert.Empty(t, report.Contexts)
+	})
+
+	t.Run("unresolvable context", func(t *testing.T) {
+		report := Topology(loadTopology(t, "testdata/topology.yaml"), provider, VerifyOptions{
+			Contexts: []Context{{Cloud: "public", Environment: "", Region: "uksouth"}},
+		})
+		require.Len(t, report.Contexts, 1)
+		require.Len(t, report.Contexts[0].Failures, 1)
+		assert.ErrorContains(t, report.Contexts[0].Failures[0], `failed to get configuration resolver: "environment" override is required`)
+	})
+}